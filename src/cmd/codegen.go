@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"concurrente/internal/codegen"
+	"concurrente/internal/config"
+	"concurrente/internal/decisiontree"
+)
+
+// codegenCommand entrena el modelo de un experimento con todos los registros
+// utilizables y escribe un paquete de Go que lo evalúa sin reservar memoria.
+func codegenCommand(args []string) int {
+	flags := flag.NewFlagSet("codegen", flag.ContinueOnError)
+	configFile := flags.String("config", "", "archivo JSON con la definición del experimento")
+	var overrides overrideFlags
+	flags.Var(
+		&overrides,
+		"set",
+		"sobrescribe un campo, p. ej. -set hyperparameters.numTrees=50 (repetible)",
+	)
+	out := flags.String("out", "modelo", "directorio del paquete generado")
+	pkg := flags.String("package", "", "nombre del paquete (por defecto, el del directorio)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *configFile == "" {
+		fmt.Fprintln(os.Stderr, "Uso: concurrente codegen -config experimento.json [-out dir] [-package nombre]")
+		return 2
+	}
+
+	exp, err := config.Load(*configFile, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Configuración inválida:")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	supported := (exp.Algorithm == "decisiontree" && exp.Variant == "sequential") ||
+		(exp.Algorithm == "randomforest" && exp.Variant == "parallel")
+	if !supported {
+		fmt.Fprintf(
+			os.Stderr,
+			"codegen solo admite decisiontree (sequential) y randomforest (parallel), no %s (%s)\n",
+			exp.Algorithm,
+			exp.Variant,
+		)
+		return 2
+	}
+	if *pkg == "" {
+		*pkg = filepath.Base(*out)
+	}
+
+	if err := generateModel(exp, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+func generateModel(exp *config.Experiment, dir, pkg string) error {
+	separator := []rune(exp.Dataset.Separator)[0]
+	header, records, err := readCSV(exp.Dataset.Path, separator, exp.Dataset.Limit)
+	if err != nil {
+		return err
+	}
+	rows, featureNames, err := preprocess(header, records, exp.Preprocessing, exp.Task == "regression")
+	if err != nil {
+		return err
+	}
+
+	model := newExperimentModel(exp, len(rows[0])-1)
+	model.Train(prepareLabels(exp, rows))
+	var m codegen.Model
+	switch trained := model.(type) {
+	case *decisiontree.SequentialDecisionTree:
+		m = codegen.Tree(pkg, trained)
+	case parallelForestModel:
+		// Las columnas de los registros del bosque son las de las filas.
+		m = codegen.ParallelForest(pkg, trained.rf)
+	}
+	m.FeatureNames = featureNames
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, pkg+".go")
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := codegen.Generate(file, m); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	trees := "1 árbol"
+	if len(m.Roots) > 1 {
+		trees = fmt.Sprintf("%d árboles", len(m.Roots))
+	}
+	fmt.Printf(
+		"Modelo entrenado con %d registros y generado en %s (%s, %d atributos)\n",
+		len(rows),
+		filename,
+		trees,
+		len(featureNames),
+	)
+	return nil
+}
@@ -0,0 +1,763 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"concurrente/internal/ann"
+	"concurrente/internal/config"
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/experiments"
+	"concurrente/internal/metrics"
+	"concurrente/internal/randomforest"
+	"concurrente/internal/svm"
+	"concurrente/internal/tuning"
+)
+
+// overrideFlags acumula los -set repetidos.
+type overrideFlags []string
+
+func (o *overrideFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrideFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+func runExperimentCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	configFile := flags.String("config", "", "archivo JSON con la definición del experimento")
+	var overrides overrideFlags
+	flags.Var(&overrides, "set", "sobrescribe un campo, p. ej. -set hyperparameters.numTrees=50 (repetible)")
+	check := flags.Bool("check", false, "solo valida el archivo sin ejecutarlo")
+	ccpCV := flags.Bool(
+		"ccp-cv",
+		false,
+		"elige hyperparameters.ccpAlpha por validación cruzada sobre los datos de entrenamiento",
+	)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *configFile == "" {
+		fmt.Fprintln(os.Stderr, "Uso: concurrente run -config experimento.json [-set campo=valor]...")
+		return 2
+	}
+
+	exp, err := config.Load(*configFile, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Configuración inválida:")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *ccpCV && exp.Algorithm != "decisiontree" && exp.Algorithm != "randomforest" {
+		fmt.Fprintf(os.Stderr, "-ccp-cv solo se aplica a algoritmos de árboles, no a %s\n", exp.Algorithm)
+		return 2
+	}
+	if *check {
+		fmt.Println("Configuración válida.")
+		return 0
+	}
+
+	if err := runExperiment(exp, *ccpCV); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// runExperiment entrena y evalúa exp. Con ccpCV, antes de cada entrenamiento
+// se elige ccpAlpha por validación cruzada sobre los datos de entrenamiento,
+// de modo que con el protocolo cv la selección queda anidada en cada
+// partición.
+func runExperiment(exp *config.Experiment, ccpCV bool) error {
+	fmt.Printf("\n--- Experimento %s ---\n", exp.Name)
+	fmt.Printf("Algoritmo: %s (%s), Hiperparámetros: %s\n",
+		exp.Algorithm, exp.Variant, formatParams(exp.Hyperparameters))
+
+	separator := []rune(exp.Dataset.Separator)[0]
+	header, records, err := readCSV(exp.Dataset.Path, separator, exp.Dataset.Limit)
+	if err != nil {
+		return err
+	}
+	rows, featureNames, err := preprocess(header, records, exp.Preprocessing, exp.Task == "regression")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Se leyeron %d registros, %d utilizables con %d atributos\n",
+		len(records), len(rows), len(rows[0])-1)
+
+	run := experiments.NewRun("config", exp.Algorithm+"/"+exp.Variant)
+	run.Name = exp.Name
+	for name, value := range exp.Hyperparameters {
+		run.Params[name] = value
+	}
+	run.Dataset = exp.Dataset.Path
+	run.DatasetHash = experiments.HashRecords(records)
+	run.DatasetRows = len(rows)
+	run.Seed = exp.Evaluation.Seed
+
+	numFeatures := len(rows[0]) - 1
+	var testData [][]float64
+	var predictions []float64
+
+	session := startProfiling(exp.Outputs.Profile, run.ID, exp.Outputs.Profile != "")
+	switch exp.Evaluation.Protocol {
+	case "holdout":
+		run.Params["trainRatio"] = exp.Evaluation.TrainRatio
+		shuffled := shuffleRows(rows, exp.Evaluation.Seed)
+		splitIndex := int(float64(len(shuffled)) * exp.Evaluation.TrainRatio)
+		trainData := shuffled[:splitIndex]
+		testData = shuffled[splitIndex:]
+
+		if ccpCV {
+			alpha, err := selectCCPAlpha(exp, trainData)
+			if err != nil {
+				session.Stop()
+				return err
+			}
+			exp = withCCPAlpha(exp, alpha)
+			run.Params["ccpAlpha"] = alpha
+			fmt.Printf("ccpAlpha elegido por validación cruzada: %g\n", alpha)
+		}
+
+		model := newExperimentModel(exp, numFeatures)
+		trainStart := time.Now()
+		model.Train(prepareLabels(exp, trainData))
+		trainTime := time.Since(trainStart)
+
+		evalStart := time.Now()
+		predictions = predictionsOf(exp, model, testData)
+		evalTime := time.Since(evalStart)
+
+		fmt.Printf("Tiempo de Entrenamiento: %v\n", trainTime)
+		fmt.Printf("Tiempo de Evaluación: %v\n", evalTime)
+		if exp.Task == "regression" {
+			score := metrics.EvaluateRegression(predictions, targetsOf(testData))
+			fmt.Printf("R²: %.4f\n", score.R2)
+			fmt.Printf("RMSE: %.4f\n", score.RMSE)
+			run.Metrics["r2"] = score.R2
+			run.Metrics["rmse"] = score.RMSE
+		} else {
+			accuracy := accuracyOf(exp, predictions, testData)
+			fmt.Printf("Precisión: %.2f%%\n", accuracy*100)
+			run.Metrics["accuracy"] = accuracy
+		}
+
+		run.SetTiming("train", trainTime)
+		run.SetTiming("eval", evalTime)
+		run.SetTiming("total", trainTime+evalTime)
+
+		if m, ok := model.(importanceModel); ok {
+			printImportances(m.FeatureImportances(), featureNames)
+		}
+		if exp.Outputs.Tree != "" {
+			if err := writeTrees(exp, model, featureNames); err != nil {
+				session.Stop()
+				return err
+			}
+			fmt.Printf("Árboles exportados en %s\n", exp.Outputs.Tree)
+		}
+		if exp.Outputs.Curve != "" {
+			if err := writeCurve(exp, model, testData); err != nil {
+				session.Stop()
+				return err
+			}
+			fmt.Printf("Curva de %s por número de árboles guardada en %s\n", curveMetric(exp), exp.Outputs.Curve)
+		}
+	case "cv":
+		run.Params["folds"] = float64(exp.Evaluation.Folds)
+		if ccpCV {
+			run.Params["ccpCV"] = 1
+		}
+		start := time.Now()
+		score, err := tuning.CrossValidate(
+			rows,
+			exp.Evaluation.Folds,
+			exp.Evaluation.Seed,
+			func(train, test [][]float64) (float64, error) {
+				foldExp := exp
+				if ccpCV {
+					alpha, err := selectCCPAlpha(exp, train)
+					if err != nil {
+						return 0, err
+					}
+					foldExp = withCCPAlpha(exp, alpha)
+				}
+				return fitAndScore(foldExp, numFeatures, train, test), nil
+			},
+		)
+		if err != nil {
+			session.Stop()
+			return err
+		}
+		cvTime := time.Since(start)
+
+		fmt.Printf("Tiempo de Validación Cruzada (%d particiones): %v\n", exp.Evaluation.Folds, cvTime)
+		if exp.Task == "regression" {
+			fmt.Printf("R² Medio: %.4f\n", score)
+			run.Metrics["r2"] = score
+		} else {
+			fmt.Printf("Precisión Media: %.2f%%\n", score*100)
+			run.Metrics["accuracy"] = score
+		}
+		run.SetTiming("cv", cvTime)
+		run.SetTiming("total", cvTime)
+	}
+
+	stopProfiling(session, &run)
+
+	if exp.Outputs.Predictions != "" {
+		if err := writePredictions(exp.Outputs.Predictions, testData, predictions); err != nil {
+			return err
+		}
+		fmt.Printf("Predicciones guardadas en %s\n", exp.Outputs.Predictions)
+	}
+	if exp.Outputs.Runs != "-" {
+		if err := experiments.NewStore(exp.Outputs.Runs).Append(run); err != nil {
+			return err
+		}
+		fmt.Printf("Ejecución registrada: %s\n", run.ID)
+	}
+	return nil
+}
+
+// preprocess convierte los registros en filas numéricas con los atributos
+// seleccionados y, en la última columna, la etiqueta 0/1, el índice de la
+// clase en p.Classes o, en regresión, el valor numérico de la columna de
+// etiqueta. Con p.Classes se descartan los registros de otras clases. También
+// devuelve los nombres de los atributos en el orden de las filas.
+//
+// Las columnas de p.CategoricalColumns van primero, en ese orden, y cada
+// valor se codifica con el orden en que aparece por primera vez, de modo que
+// treeOptions sabe qué atributos son categóricos sin ver la cabecera.
+func preprocess(
+	header []string,
+	records [][]string,
+	p config.Preprocessing,
+	regression bool,
+) ([][]float64, []string, error) {
+	labelIndex := -1
+	dropped := make(map[string]bool, len(p.DropColumns))
+	for _, column := range p.DropColumns {
+		dropped[column] = true
+	}
+
+	categorical := make(map[string]bool, len(p.CategoricalColumns))
+	for _, column := range p.CategoricalColumns {
+		categorical[column] = true
+	}
+	columnIndex := make(map[string]int, len(header))
+	for i, column := range header {
+		columnIndex[column] = i
+	}
+
+	var featureIndices []int
+	for _, column := range p.CategoricalColumns {
+		index, ok := columnIndex[column]
+		if !ok {
+			return nil, nil, fmt.Errorf("la columna categórica %q no existe", column)
+		}
+		featureIndices = append(featureIndices, index)
+	}
+	for i, column := range header {
+		switch {
+		case column == p.LabelColumn:
+			labelIndex = i
+		case dropped[column]:
+			delete(dropped, column)
+		case categorical[column]:
+		default:
+			featureIndices = append(featureIndices, i)
+		}
+	}
+	if labelIndex == -1 {
+		return nil, nil, fmt.Errorf("la columna de etiqueta %q no existe", p.LabelColumn)
+	}
+	for column := range dropped {
+		return nil, nil, fmt.Errorf("la columna a descartar %q no existe", column)
+	}
+	featureNames := make([]string, len(featureIndices))
+	for j, index := range featureIndices {
+		featureNames[j] = header[index]
+	}
+
+	classIndex := make(map[string]int, len(p.Classes))
+	for i, class := range p.Classes {
+		classIndex[class] = i
+	}
+
+	codes := make([]map[string]float64, len(p.CategoricalColumns))
+	for j := range codes {
+		codes[j] = make(map[string]float64)
+	}
+
+	rows := make([][]float64, 0, len(records))
+	for _, record := range records {
+		row := make([]float64, len(featureIndices)+1)
+		usable := true
+		for j, index := range featureIndices {
+			if j < len(codes) {
+				code, seen := codes[j][record[index]]
+				if !seen {
+					code = float64(len(codes[j]))
+					codes[j][record[index]] = code
+				}
+				row[j] = code
+				continue
+			}
+			value, err := strconv.ParseFloat(record[index], 64)
+			if err != nil {
+				if p.Missing == "drop" {
+					usable = false
+					break
+				}
+				value = 0
+			}
+			row[j] = value
+		}
+		if !usable {
+			continue
+		}
+		if regression {
+			target, err := strconv.ParseFloat(record[labelIndex], 64)
+			if err != nil {
+				continue
+			}
+			row[len(row)-1] = target
+		} else if len(p.Classes) > 0 {
+			class, known := classIndex[record[labelIndex]]
+			if !known {
+				continue
+			}
+			row[len(row)-1] = float64(class)
+		} else if record[labelIndex] == p.PositiveLabel {
+			row[len(row)-1] = 1
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("ningún registro tiene todos los atributos numéricos")
+	}
+	return rows, featureNames, nil
+}
+
+type experimentModel interface {
+	Train(data [][]float64)
+	Predict(sample []float64) float64
+}
+
+// classModel es un modelo de árboles que predice una de varias clases.
+type classModel interface {
+	PredictClass(sample []float64) int
+}
+
+func newExperimentModel(exp *config.Experiment, numFeatures int) experimentModel {
+	h := exp.Hyperparameters
+	seed := exp.Evaluation.Seed
+
+	switch exp.Algorithm {
+	case "decisiontree":
+		opts := treeOptions(exp, treeDefaults(exp))
+		if exp.Variant == "concurrent" {
+			return decisiontree.NewConcurrentDecisionTreeWithOptions(opts)
+		}
+		return decisiontree.NewSequentialDecisionTreeWithOptions(opts)
+	case "randomforest":
+		trees, ratio := int(h["numTrees"]), h["subsetRatio"]
+		switch {
+		case exp.Task == "regression" && exp.Variant == "sequential":
+			rf := randomforest.NewSequentialRandomForestRegressor(trees, ratio)
+			rf.SetSeed(seed)
+			rf.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			return rf
+		case exp.Task == "regression":
+			rf := randomforest.NewConcurrentRandomForestRegressor(trees, ratio)
+			rf.SetSeed(seed)
+			rf.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			return rf
+		}
+		switch exp.Variant {
+		case "sequential":
+			rf := randomforest.NewSequentialRandomForest(trees, ratio)
+			rf.SetSeed(seed)
+			rf.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			return rf
+		case "concurrent":
+			rf := randomforest.NewConcurrentRandomForest(trees, ratio)
+			rf.SetSeed(seed)
+			rf.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			return rf
+		default:
+			rf := randomforest.NewParallelRandomForest(trees, ratio)
+			rf.SetSeed(seed)
+			rf.SetNumWorkers(int(h["numWorkers"]))
+			rf.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			classes := recordClasses(exp)
+			rf.SetClasses(classes)
+			return parallelForestModel{rf, classes}
+		}
+	case "extratrees":
+		if exp.Variant == "sequential" {
+			et := randomforest.NewSequentialExtraTrees(int(h["numTrees"]))
+			et.SetSeed(seed)
+			et.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+			return et
+		}
+		et := randomforest.NewParallelExtraTrees(int(h["numTrees"]))
+		et.SetSeed(seed)
+		et.SetNumWorkers(int(h["numWorkers"]))
+		et.SetTreeOptions(treeOptions(exp, treeDefaults(exp)))
+		return et
+	case "svm":
+		if exp.Variant == "concurrent" {
+			return svm.NewConcurrentSVM(numFeatures, h["learningRate"], h["lambda"], int(h["epochs"]))
+		}
+		return svm.NewSequentialSVM(numFeatures, h["learningRate"], h["lambda"], int(h["epochs"]))
+	default:
+		if exp.Variant == "concurrent" {
+			return ann.NewConcurrentANN(numFeatures, int(h["hiddenSize"]), h["learningRate"], int(h["epochs"]))
+		}
+		return ann.NewSequentialANN(numFeatures, int(h["hiddenSize"]), h["learningRate"], int(h["epochs"]))
+	}
+}
+
+// treeDefaults son las opciones de árbol del algoritmo antes de aplicar los
+// hiperparámetros del experimento.
+func treeDefaults(exp *config.Experiment) decisiontree.Options {
+	switch {
+	case exp.Algorithm == "extratrees":
+		opts := randomforest.DefaultExtraTreesOptions()
+		if exp.Task == "regression" {
+			// En regresión cada división sortea un umbral de todos los
+			// atributos.
+			opts.Criterion = decisiontree.MSE
+			opts.MaxFeatures = decisiontree.MaxFeatures{}
+		}
+		return opts
+	case exp.Task == "regression":
+		return decisiontree.DefaultRegressionOptions()
+	case exp.Algorithm == "randomforest" && exp.Variant == "parallel":
+		return randomforest.DefaultParallelTreeOptions()
+	}
+	return decisiontree.DefaultOptions()
+}
+
+// treeOptions aplica sobre defaults los criterios de parada y el suavizado
+// presentes en los hiperparámetros, el criterio de división y los atributos
+// por división del experimento y sus atributos categóricos.
+func treeOptions(exp *config.Experiment, defaults decisiontree.Options) decisiontree.Options {
+	h := exp.Hyperparameters
+	opts := defaults
+	if exp.Criterion != "" {
+		// Validate ya comprobó el nombre.
+		opts.Criterion, _ = decisiontree.ParseCriterion(exp.Criterion)
+	}
+	if exp.MaxFeatures != "" {
+		opts.MaxFeatures, _ = decisiontree.ParseMaxFeatures(string(exp.MaxFeatures))
+		// Los bosques sustituyen la semilla por una distinta en cada árbol.
+		opts.Seed = exp.Evaluation.Seed
+	}
+	if v, ok := h["maxDepth"]; ok {
+		opts.MaxDepth = int(v)
+	}
+	if v, ok := h["minSamplesSplit"]; ok {
+		opts.MinSamplesSplit = int(v)
+	}
+	if v, ok := h["minSamplesLeaf"]; ok {
+		opts.MinSamplesLeaf = int(v)
+	}
+	if v, ok := h["minImpurityDecrease"]; ok {
+		opts.MinImpurityDecrease = v
+	}
+	if v, ok := h["maxLeafNodes"]; ok {
+		opts.MaxLeafNodes = int(v)
+	}
+	if v, ok := h["maxBins"]; ok {
+		opts.MaxBins = int(v)
+	}
+	if v, ok := h["ccpAlpha"]; ok {
+		opts.CCPAlpha = v
+	}
+	if v, ok := h["laplace"]; ok {
+		opts.Smoothing.Laplace = v
+	}
+	if v, ok := h["mEstimate"]; ok {
+		opts.Smoothing.M = v
+	}
+	// preprocess coloca los atributos categóricos al principio de las filas.
+	for i := range exp.Preprocessing.CategoricalColumns {
+		opts.Categorical = append(opts.Categorical, i)
+	}
+	return opts
+}
+
+// parallelForestModel adapta ParallelRandomForest, que trabaja con registros
+// de texto, a filas numéricas. La clase i se escribe como classes[i], que en
+// la tarea binaria son "NO" y "SI".
+type parallelForestModel struct {
+	rf      *randomforest.ParallelRandomForest
+	classes []string
+}
+
+func (m parallelForestModel) Train(data [][]float64) {
+	m.rf.Train(toRecords(data, m.classes))
+}
+
+// recordClasses son las etiquetas de texto de las clases 0, 1, ... del
+// experimento.
+func recordClasses(exp *config.Experiment) []string {
+	if len(exp.Preprocessing.Classes) > 0 {
+		return exp.Preprocessing.Classes
+	}
+	return []string{"NO", "SI"}
+}
+
+// toRecords convierte filas numéricas en registros de texto cuya última
+// columna es classes[etiqueta].
+func toRecords(data [][]float64, classes []string) [][]string {
+	records := make([][]string, len(data))
+	for i, row := range data {
+		records[i] = append(formatRow(row[:len(row)-1]), classes[int(row[len(row)-1])])
+	}
+	return records
+}
+
+func (m parallelForestModel) Predict(sample []float64) float64 {
+	return m.rf.Predict(formatRow(sample))
+}
+
+func (m parallelForestModel) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	return m.rf.Export(w, format, opts)
+}
+
+// StagedPredict devuelve la predicción de sample con los primeros 1, 2, ...
+// árboles del bosque.
+func (m parallelForestModel) StagedPredict(sample []float64) []float64 {
+	return m.rf.StagedPredict(formatRow(sample))
+}
+
+func (m parallelForestModel) FeatureImportances() []float64 {
+	return m.rf.FeatureImportances()
+}
+
+// PredictClass devuelve el índice de la clase; SetClasses fijó el orden de
+// las clases del bosque al de m.classes.
+func (m parallelForestModel) PredictClass(sample []float64) int {
+	return decisiontree.Argmax(m.rf.PredictProba(formatRow(sample)))
+}
+
+func formatRow(values []float64) []string {
+	record := make([]string, len(values), len(values)+1)
+	for i, value := range values {
+		record[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return record
+}
+
+// prepareLabels adapta las etiquetas 0/1 a la codificación del algoritmo; la
+// SVM usa -1/+1 en la pérdida hinge.
+func prepareLabels(exp *config.Experiment, data [][]float64) [][]float64 {
+	if exp.Algorithm != "svm" {
+		return data
+	}
+	signed := make([][]float64, len(data))
+	for i, row := range data {
+		signed[i] = make([]float64, len(row))
+		copy(signed[i], row)
+		if row[len(row)-1] == 0 {
+			signed[i][len(row)-1] = -1
+		}
+	}
+	return signed
+}
+
+func shuffleRows(rows [][]float64, seed int64) [][]float64 {
+	shuffled := make([][]float64, len(rows))
+	copy(shuffled, rows)
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled
+}
+
+func targetsOf(data [][]float64) []float64 {
+	targets := make([]float64, len(data))
+	for i, row := range data {
+		targets[i] = row[len(row)-1]
+	}
+	return targets
+}
+
+// fitAndScore entrena el modelo de exp con train y devuelve su precisión o,
+// en regresión, su R² sobre test.
+func fitAndScore(exp *config.Experiment, numFeatures int, train, test [][]float64) float64 {
+	model := newExperimentModel(exp, numFeatures)
+	model.Train(prepareLabels(exp, train))
+	predictions := predictionsOf(exp, model, test)
+	if exp.Task == "regression" {
+		return metrics.R2(predictions, targetsOf(test))
+	}
+	return accuracyOf(exp, predictions, test)
+}
+
+// predictionsOf predice cada fila de data: el índice de la clase en una
+// tarea multiclase y, si no, la salida de Predict.
+func predictionsOf(exp *config.Experiment, model experimentModel, data [][]float64) []float64 {
+	predictions := make([]float64, len(data))
+	for i, row := range data {
+		if len(exp.Preprocessing.Classes) > 0 {
+			// Validate solo admite clases con algoritmos de árboles.
+			predictions[i] = float64(model.(classModel).PredictClass(row[:len(row)-1]))
+		} else {
+			predictions[i] = model.Predict(row[:len(row)-1])
+		}
+	}
+	return predictions
+}
+
+func accuracyOf(exp *config.Experiment, predictions []float64, data [][]float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	correct := 0
+	for i, row := range data {
+		label := row[len(row)-1]
+		if len(exp.Preprocessing.Classes) > 0 {
+			if predictions[i] == label {
+				correct++
+			}
+		} else if (predictions[i] >= 0.5) == (label == 1) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(data))
+}
+
+// importanceModel es un modelo de árboles que mide la importancia de cada
+// atributo por la disminución de impureza de sus divisiones.
+type importanceModel interface {
+	FeatureImportances() []float64
+}
+
+// printImportances muestra los atributos de mayor a menor importancia,
+// omitiendo los que ninguna división usa.
+func printImportances(importances []float64, featureNames []string) {
+	order := make([]int, len(importances))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return importances[order[a]] > importances[order[b]]
+	})
+	fmt.Println("Importancia de los atributos:")
+	for _, feature := range order {
+		if importances[feature] == 0 {
+			break
+		}
+		fmt.Printf("  %-20s %.4f\n", featureNames[feature], importances[feature])
+	}
+}
+
+// treeExporter es un modelo de árboles que se puede exportar.
+type treeExporter interface {
+	Export(w io.Writer, format string, opts decisiontree.ExportOptions) error
+}
+
+// writeTrees exporta los árboles de model a exp.Outputs.Tree en el formato
+// que indica su extensión.
+func writeTrees(exp *config.Experiment, model experimentModel, featureNames []string) error {
+	format := "rules"
+	switch filepath.Ext(exp.Outputs.Tree) {
+	case ".dot":
+		format = "dot"
+	case ".json":
+		format = "json"
+	}
+	opts := decisiontree.ExportOptions{FeatureNames: featureNames}
+	switch {
+	case len(exp.Preprocessing.Classes) > 0:
+		opts.ClassNames = exp.Preprocessing.Classes
+	case exp.Task == "classification":
+		opts.ClassNames = []string{"no " + exp.Preprocessing.PositiveLabel, exp.Preprocessing.PositiveLabel}
+	}
+
+	file, err := os.Create(exp.Outputs.Tree)
+	if err != nil {
+		return err
+	}
+	// Validate solo admite outputs.tree con algoritmos de árboles.
+	if err := model.(treeExporter).Export(file, format, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// curveMetric es el nombre de la métrica de la curva de exp.Outputs.Curve.
+func curveMetric(exp *config.Experiment) string {
+	if exp.Task == "regression" {
+		return "r2"
+	}
+	return "precision"
+}
+
+// writeCurve guarda en exp.Outputs.Curve la precisión o, en regresión, el R²
+// de los primeros 1, 2, ... árboles de model sobre testData.
+func writeCurve(exp *config.Experiment, model experimentModel, testData [][]float64) error {
+	score := func(predictions []float64) float64 {
+		if exp.Task == "regression" {
+			return metrics.R2(predictions, targetsOf(testData))
+		}
+		return accuracyOf(exp, predictions, testData)
+	}
+	// Validate solo admite outputs.curve con randomforest y extratrees.
+	curve := randomforest.GrowthCurve[float64](model.(randomforest.Staged[float64]), testData, score)
+
+	file, err := os.Create(exp.Outputs.Curve)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"arboles", curveMetric(exp)})
+	for k, value := range curve {
+		writer.Write([]string{strconv.Itoa(k + 1), strconv.FormatFloat(value, 'g', -1, 64)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func writePredictions(filename string, data [][]float64, predictions []float64) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"fila", "etiqueta", "prediccion"})
+	for i, row := range data {
+		writer.Write([]string{
+			strconv.Itoa(i),
+			strconv.FormatFloat(row[len(row)-1], 'g', -1, 64),
+			strconv.FormatFloat(predictions[i], 'g', -1, 64),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
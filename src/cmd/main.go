@@ -10,21 +10,34 @@ import (
 	"strings"
 	"time"
 
+	"concurrente/internal/config"
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/experiments"
 	"concurrente/internal/randomforest"
 )
 
 const (
-	labelIndex = 14 // columna exporta
+	labelIndex  = 14 // columna exporta
+	datasetFile = "datasets/bd_mujeres_2023.csv"
+	runsFile    = "runs.jsonl"
+	profileDir  = "profiles"
 )
 
 var (
 	numTrees    int     = 10
 	subsetRatio float64 = 0.8
+	maxBins     int     = 0
+	maxFeatures decisiontree.MaxFeatures
 	trainRatio  float64 = 0.8
 	datasetSize int     = 100000
+	profileRuns bool    = false
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	for {
 		printMenu()
 		choice := getUserChoice()
@@ -41,6 +54,8 @@ func main() {
 		case 5:
 			predictExporta()
 		case 6:
+			searchHyperparameters()
+		case 7:
 			fmt.Println("Saliendo del programa. ¡Hasta luego!")
 			return
 		default:
@@ -56,7 +71,8 @@ func printMenu() {
 	fmt.Println("3. Ejecutar Simulación")
 	fmt.Println("4. Comparar Tiempos de Ejecución para Diferentes Tamaños de Datos")
 	fmt.Println("5. Predecir 'exporta' para un Registro Individual")
-	fmt.Println("6. Salir")
+	fmt.Println("6. Buscar Hiperparámetros")
+	fmt.Println("7. Salir")
 	fmt.Print("Ingrese su elección: ")
 }
 
@@ -91,10 +107,34 @@ func setAlgorithmParameters() {
 		}
 	}
 
+	fmt.Printf("\nNúmero actual de bins por característica: %d (0 = umbrales exactos)\n", maxBins)
+	fmt.Print(
+		"Ingrese nuevo número de bins (0 para búsqueda exacta) (o presione Enter para mantener el actual): ",
+	)
+	input = readLine()
+	if input != "" {
+		if val, err := strconv.Atoi(input); err == nil && val >= 0 {
+			maxBins = val
+		}
+	}
+
+	fmt.Printf("\nAtributos por división actuales: %s\n", maxFeatures)
+	fmt.Print(
+		"Ingrese sqrt, log2, all, una fracción (0-1] o un número de atributos (o presione Enter para mantener el actual): ",
+	)
+	input = readLine()
+	if input != "" {
+		if val, err := decisiontree.ParseMaxFeatures(input); err == nil {
+			maxFeatures = val
+		}
+	}
+
 	fmt.Printf(
-		"\nParámetros del algoritmo actualizados: Árboles = %d, Ratio de Subconjunto = %.2f\n",
+		"\nParámetros del algoritmo actualizados: Árboles = %d, Ratio de Subconjunto = %.2f, Bins = %d, Atributos por División = %s\n",
 		numTrees,
 		subsetRatio,
+		maxBins,
+		maxFeatures,
 	)
 }
 
@@ -121,19 +161,33 @@ func setSimulationParameters() {
 		}
 	}
 
+	fmt.Printf("\nCaptura de perfiles actual: %v\n", profileRuns)
+	fmt.Print(
+		"¿Capturar perfiles de CPU, memoria, mutex, bloqueo y traza en cada ejecución? (s/n, o presione Enter para mantener el actual): ",
+	)
+	input = readLine()
+	if input == "s" || input == "S" {
+		profileRuns = true
+	} else if input == "n" || input == "N" {
+		profileRuns = false
+	}
+
 	fmt.Printf(
-		"\nParámetros de simulación actualizados: Ratio de Entrenamiento = %.2f, Tamaño del Conjunto de Datos = %d\n",
+		"\nParámetros de simulación actualizados: Ratio de Entrenamiento = %.2f, Tamaño del Conjunto de Datos = %d, Perfiles = %v\n",
 		trainRatio,
 		datasetSize,
+		profileRuns,
 	)
 }
 
 func runSimulation() {
 	fmt.Printf("\n--- Ejecutando Simulación ---\n")
 	fmt.Printf(
-		"Parámetros del Algoritmo: Árboles = %d, Ratio de Subconjunto = %.2f\n",
+		"Parámetros del Algoritmo: Árboles = %d, Ratio de Subconjunto = %.2f, Bins = %d, Atributos por División = %s\n",
 		numTrees,
 		subsetRatio,
+		maxBins,
+		maxFeatures,
 	)
 	fmt.Printf(
 		"Parámetros de Simulación: Ratio de Entrenamiento = %.2f, Tamaño del Conjunto de Datos = %d\n",
@@ -141,10 +195,20 @@ func runSimulation() {
 		datasetSize,
 	)
 
-	allData := readAndPrepareData(datasetSize)
-	trainData, testData := splitData(allData, trainRatio)
+	seed := time.Now().UnixNano()
+	header, allData := readAndPrepareData(datasetSize)
+	run := newForestRun("simulation", allData, seed)
+	rows, featureNames, err := forestData(header, allData)
+	if err != nil {
+		fmt.Println("Error al preparar los datos del bosque:", err)
+		return
+	}
+	trainRows, testRows := splitData(rows, trainRatio, seed)
+	trainData, testData := toRecords(trainRows, forestClasses), toRecords(testRows, forestClasses)
 
-	rf := randomforest.NewParallelRandomForest(numTrees, subsetRatio)
+	rf := newParallelForest()
+	rf.SetSeed(seed)
+	session := startProfiling(profileDir, run.ID, profileRuns)
 	trainTime, evalTime, accuracy := testRandomForestParallel(rf, trainData, testData)
 
 	fmt.Printf("\nResultados:\n")
@@ -152,6 +216,11 @@ func runSimulation() {
 	fmt.Printf("Tiempo de Evaluación: %v\n", evalTime)
 	fmt.Printf("Tiempo Total: %v\n", trainTime+evalTime)
 	fmt.Printf("Precisión: %.2f%%\n", accuracy*100)
+	reportOOB(rf, trainData, &run)
+	printImportances(rf.FeatureImportances(), featureNames)
+	stopProfiling(session, &run)
+
+	recordForestRun(run, trainTime, evalTime, accuracy)
 }
 
 func compareRuntimes() {
@@ -159,17 +228,59 @@ func compareRuntimes() {
 
 	for _, size := range rowSizes {
 		fmt.Printf("\n--- Probando con %d filas ---\n", size)
-		allData := readAndPrepareData(size)
-		trainData, testData := splitData(allData, trainRatio)
+		seed := time.Now().UnixNano()
+		header, allData := readAndPrepareData(size)
+		run := newForestRun("compare", allData, seed)
+		extraRun := newExtraTreesRun("compare", allData, seed)
+		rows, _, err := forestData(header, allData)
+		if err != nil {
+			fmt.Println("Error al preparar los datos del bosque:", err)
+			continue
+		}
+		trainRows, testRows := splitData(rows, trainRatio, seed)
+		trainData, testData := toRecords(trainRows, forestClasses), toRecords(testRows, forestClasses)
 
-		rf := randomforest.NewParallelRandomForest(numTrees, subsetRatio)
+		rf := newParallelForest()
+		rf.SetSeed(seed)
+		session := startProfiling(profileDir, run.ID, profileRuns)
 		trainTime, evalTime, accuracy := testRandomForestParallel(rf, trainData, testData)
 
 		fmt.Printf("Tiempo de Entrenamiento: %v\n", trainTime)
 		fmt.Printf("Tiempo de Evaluación: %v\n", evalTime)
 		fmt.Printf("Tiempo Total: %v\n", trainTime+evalTime)
 		fmt.Printf("Precisión: %.2f%%\n", accuracy*100)
+		stopProfiling(session, &run)
+
+		recordForestRun(run, trainTime, evalTime, accuracy)
+		compareExtraTrees(trainRows, testRows, extraRun)
+	}
+}
+
+// compareExtraTrees entrena ExtraTrees paralelos con tantos árboles como el
+// bosque y las mismas filas para comparar sus tiempos y su precisión con los
+// del bosque.
+func compareExtraTrees(train, test [][]float64, run experiments.Run) {
+	et := randomforest.NewParallelExtraTrees(numTrees)
+	et.SetSeed(run.Seed)
+	trainStart := time.Now()
+	et.Train(train)
+	trainTime := time.Since(trainStart)
+
+	evalStart := time.Now()
+	correct := 0
+	for _, row := range test {
+		if (et.Predict(row[:len(row)-1]) >= 0.5) == (row[len(row)-1] == 1) {
+			correct++
+		}
 	}
+	accuracy := float64(correct) / float64(len(test))
+	evalTime := time.Since(evalStart)
+
+	fmt.Printf("ExtraTrees - Tiempo de Entrenamiento: %v\n", trainTime)
+	fmt.Printf("ExtraTrees - Tiempo de Evaluación: %v\n", evalTime)
+	fmt.Printf("ExtraTrees - Tiempo Total: %v\n", trainTime+evalTime)
+	fmt.Printf("ExtraTrees - Precisión: %.2f%%\n", accuracy*100)
+	recordForestRun(run, trainTime, evalTime, accuracy)
 }
 
 func predictExporta() {
@@ -182,20 +293,56 @@ func predictExporta() {
 		return
 	}
 
-	allData := readAndPrepareData(datasetSize)
-	trainData, _ := splitData(allData, 1.0) // Usar todos los datos para entrenamiento
+	header, allData := readAndPrepareData(datasetSize)
+	rows, _, err := forestData(header, allData)
+	if err != nil {
+		fmt.Println("Error al preparar los datos del bosque:", err)
+		return
+	}
+	// La columna exporta del registro ingresado no se usa como atributo.
+	sample, _, err := forestData(header, [][]string{record})
+	if err != nil {
+		fmt.Println("Error: los atributos deben ser numéricos.")
+		return
+	}
+	trainRows, _ := splitData(rows, 1.0, time.Now().UnixNano()) // Usar todos los datos para entrenamiento
+
+	rf := newParallelForest()
+	rf.Train(toRecords(trainRows, forestClasses))
 
-	rf := randomforest.NewParallelRandomForest(numTrees, subsetRatio)
-	rf.Train(trainData)
+	prediction := rf.PredictClass(formatRow(sample[0][:len(sample[0])-1]))
 
-	prediction := rf.Predict(record[:len(record)-1]) // Excluir la última columna (fec_creacion)
+	fmt.Printf("Predicción para 'exporta': %s\n", prediction)
+}
 
-	fmt.Printf("Predicción para 'exporta': ")
-	if prediction >= 0.5 {
-		fmt.Println("SI")
-	} else {
-		fmt.Println("NO")
+// forestClasses son las etiquetas de las clases 0 y 1 de exporta.
+var forestClasses = []string{"NO", "SI"}
+
+// forestData convierte los registros leídos en filas numéricas para el
+// bosque, como compareExtraTrees y los experimentos: exporta es la etiqueta,
+// 1 para "SI", fec_creacion se descarta y los registros con atributos no
+// numéricos se dejan fuera. Devuelve también los nombres de los atributos.
+func forestData(header []string, records [][]string) ([][]float64, []string, error) {
+	p := config.Preprocessing{
+		LabelColumn:   header[labelIndex],
+		PositiveLabel: forestClasses[1],
+		DropColumns:   []string{header[len(header)-1]}, // fec_creacion
+		Missing:       "drop",
 	}
+	return preprocess(header, records, p, false)
+}
+
+// newParallelForest crea el bosque paralelo con los parámetros actuales. Las
+// clases se fijan en "NO" y "SI" para que "SI" sea siempre la clase 1, aunque
+// los datos de entrenamiento no tengan ningún "NO".
+func newParallelForest() *randomforest.ParallelRandomForest {
+	rf := randomforest.NewParallelRandomForest(numTrees, subsetRatio)
+	rf.SetClasses(forestClasses)
+	opts := randomforest.DefaultParallelTreeOptions()
+	opts.MaxBins = maxBins
+	opts.MaxFeatures = maxFeatures
+	rf.SetTreeOptions(opts)
+	return rf
 }
 
 func testRandomForestParallel(
@@ -213,39 +360,42 @@ func testRandomForestParallel(
 	return trainTime, evalTime, accuracy
 }
 
+// evaluateModel devuelve la precisión del bosque con registros cuya última
+// columna es la etiqueta.
 func evaluateModel(rf *randomforest.ParallelRandomForest, testData [][]string) float64 {
 	correct := 0
 	for _, sample := range testData {
 		features := sample[:len(sample)-1]
-		label := sample[labelIndex]
-		prediction := rf.Predict(features)
-		if (prediction >= 0.5 && label == "SI") || (prediction < 0.5 && label == "NO") {
+		if rf.PredictClass(features) == sample[len(sample)-1] {
 			correct++
 		}
 	}
 	return float64(correct) / float64(len(testData))
 }
 
-func readAndPrepareData(limit int) [][]string {
-	allData, err := readCSV("datasets/bd_mujeres_2023.csv", '|', limit)
+// readAndPrepareData devuelve el encabezado y los registros del conjunto de
+// datos.
+func readAndPrepareData(limit int) ([]string, [][]string) {
+	header, allData, err := readCSV(datasetFile, '|', limit)
 	if err != nil {
 		fmt.Println("Error al leer el CSV:", err)
 		os.Exit(1)
 	}
 	fmt.Printf("Se leyeron %d registros del conjunto de datos\n", len(allData))
-	return allData
+	return header, allData
 }
 
-func readCSV(filename string, separator rune, limit int) ([][]string, error) {
+func readCSV(filename string, separator rune, limit int) ([]string, [][]string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
 	reader.Comma = separator
 
+	var header []string
 	var data [][]string
 	for i := 0; ; i++ {
 		record, err := reader.Read()
@@ -253,19 +403,20 @@ func readCSV(filename string, separator rune, limit int) ([][]string, error) {
 			break
 		}
 		if i == 0 {
-			continue // saltar encabezado
+			header = record // encabezado
+			continue
 		}
 		if limit > 0 && i > limit {
 			break
 		}
 		data = append(data, record)
 	}
-	return data, nil
+	return header, data, nil
 }
 
-func splitData(data [][]string, trainRatio float64) ([][]string, [][]string) {
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(data), func(i, j int) { data[i], data[j] = data[j], data[i] })
+func splitData[T any](data [][]T, trainRatio float64, seed int64) ([][]T, [][]T) {
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(data), func(i, j int) { data[i], data[j] = data[j], data[i] })
 	splitIndex := int(float64(len(data)) * trainRatio)
 	return data[:splitIndex], data[splitIndex:]
 }
@@ -275,4 +426,3 @@ func readLine() string {
 	input, _ := reader.ReadString('\n')
 	return strings.TrimSpace(input)
 }
-
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"concurrente/internal/experiments"
+	"concurrente/internal/tuning"
+)
+
+// writeDataset escribe un CSV con las columnas del conjunto de datos real:
+// exporta en labelIndex, "SI" cuando la segunda columna pasa de 0.5, y
+// fec_creacion al final. Los árboles del bosque no dividen por la primera
+// columna, que en el conjunto real identifica el registro.
+func writeDataset(t *testing.T, rows int) string {
+	t.Helper()
+	header := make([]string, 18)
+	for i := range header {
+		header[i] = fmt.Sprintf("c%d", i)
+	}
+	header[labelIndex], header[len(header)-1] = "exporta", "fec_creacion"
+
+	rng := rand.New(rand.NewSource(1))
+	lines := []string{strings.Join(header, "|")}
+	for r := 0; r < rows; r++ {
+		record := make([]string, len(header))
+		values := make([]float64, len(header))
+		for i := range record {
+			values[i] = rng.Float64()
+			record[i] = fmt.Sprintf("%.4f", values[i])
+		}
+		record[labelIndex] = "NO"
+		if values[1] > 0.5 {
+			record[labelIndex] = "SI"
+		}
+		record[len(record)-1] = fmt.Sprintf("2023-%02d-%02d", 1+r%12, 1+r%28)
+		lines = append(lines, strings.Join(record, "|"))
+	}
+
+	path := filepath.Join(t.TempDir(), "datos.csv")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestForestLearnsExporta(t *testing.T) {
+	header, records, err := readCSV(writeDataset(t, 400), '|', 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, featureNames, err := forestData(header, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(featureNames, "exporta") || slices.Contains(featureNames, "fec_creacion") ||
+		len(featureNames) != len(header)-2 {
+		t.Fatalf("features %v", featureNames)
+	}
+	trainRows, testRows := splitData(rows, 0.75, 1)
+	trainData, testData := toRecords(trainRows, forestClasses), toRecords(testRows, forestClasses)
+
+	rf := newParallelForest()
+	rf.SetSeed(1)
+	rf.Train(trainData)
+
+	if !slices.Equal(rf.Classes(), forestClasses) {
+		t.Fatalf("classes %v, want %v", rf.Classes(), forestClasses)
+	}
+	if n := len(rf.FeatureImportances()); n != len(featureNames) {
+		t.Errorf("%d importances for %d features", n, len(featureNames))
+	}
+	if accuracy := evaluateModel(rf, testData); accuracy < 0.8 {
+		t.Errorf("accuracy %.3f on exporta", accuracy)
+	}
+}
+
+func TestOOBScoresExporta(t *testing.T) {
+	header, records, err := readCSV(writeDataset(t, 300), '|', 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _, err := forestData(header, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainData := toRecords(rows, forestClasses)
+
+	rf := newParallelForest()
+	rf.SetSeed(2)
+	rf.Train(trainData)
+	run := experiments.NewRun("test", "parallel-forest")
+	reportOOB(rf, trainData, &run)
+
+	if score, ok := run.Metrics["oob_accuracy"]; !ok || score < 0.8 {
+		t.Errorf("OOB accuracy %.3f (recorded %v) on exporta", score, ok)
+	}
+}
+
+func TestForestObjectiveScoresExporta(t *testing.T) {
+	header, records, err := readCSV(writeDataset(t, 300), '|', 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _, err := forestData(header, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objective := forestObjective(rows, 3)
+	score, err := objective(tuning.Trial{Params: tuning.Params{"numTrees": 5, "subsetRatio": 0.8}, Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score < 0.8 {
+		t.Errorf("cross-validated accuracy %.3f on exporta", score)
+	}
+}
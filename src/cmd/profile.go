@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"concurrente/internal/experiments"
+	"concurrente/internal/profiling"
+)
+
+// startProfiling abre una sesión para la ejecución runID. Sin perfiles
+// habilitados solo recoge estadísticas del runtime; con ellos escribe en
+// baseDir/runID.
+func startProfiling(baseDir, runID string, enabled bool) *profiling.Session {
+	opts := profiling.Options{}
+	dir := ""
+	if enabled {
+		opts = profiling.All()
+		dir = filepath.Join(baseDir, runID)
+	}
+	session, err := profiling.Start(dir, opts)
+	if err != nil {
+		fmt.Println("Error al iniciar el perfilado:", err)
+		session, _ = profiling.Start("", profiling.Options{})
+	}
+	return session
+}
+
+// stopProfiling cierra la sesión y agrega sus estadísticas a run.
+func stopProfiling(session *profiling.Session, run *experiments.Run) {
+	stats, err := session.Stop()
+	if err != nil {
+		fmt.Println("Error al escribir los perfiles:", err)
+	}
+
+	run.Metrics["goroutines"] = float64(stats.Goroutines)
+	run.Metrics["goroutines_peak"] = float64(stats.PeakGoroutines)
+	run.Metrics["gc_count"] = float64(stats.NumGC)
+	run.SetTiming("gc_pause_total", stats.GCPauseTotal)
+	run.SetTiming("gc_pause_max", stats.GCPauseMax)
+	if stats.PeakRSS > 0 {
+		run.Metrics["peak_rss_mb"] = float64(stats.PeakRSS) / (1 << 20)
+	}
+
+	fmt.Printf(
+		"Goroutines (pico): %d, GC: %d ciclos, pausa total %v (máx. %v), RSS pico: %.1f MB\n",
+		stats.PeakGoroutines,
+		stats.NumGC,
+		stats.GCPauseTotal,
+		stats.GCPauseMax,
+		float64(stats.PeakRSS)/(1<<20),
+	)
+	if len(stats.Files) > 0 {
+		fmt.Printf("Perfiles guardados en %s\n", filepath.Dir(stats.Files[0]))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"concurrente/internal/config"
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/randomforest"
+	"concurrente/internal/tuning"
+)
+
+const (
+	// ccpFolds son las particiones con que -ccp-cv evalúa cada alfa.
+	ccpFolds = 3
+	// ccpCandidates acota cuántos alfas del camino de poda se evalúan.
+	ccpCandidates = 10
+)
+
+// selectCCPAlpha elige ccpAlpha por validación cruzada sobre train. Los
+// candidatos son alfas del camino de poda de un árbol crecido con las
+// opciones del experimento sobre todo train; gana el de mejor puntuación y,
+// en empate, el mayor, que da el árbol más simple.
+func selectCCPAlpha(exp *config.Experiment, train [][]float64) (float64, error) {
+	numFeatures := len(train[0]) - 1
+	bestAlpha, bestScore := 0.0, 0.0
+	for i, alpha := range ccpCandidateAlphas(pruningPath(exp, train)) {
+		candidate := withCCPAlpha(exp, alpha)
+		score, err := tuning.CrossValidate(
+			train,
+			ccpFolds,
+			exp.Evaluation.Seed,
+			func(train, test [][]float64) (float64, error) {
+				return fitAndScore(candidate, numFeatures, train, test), nil
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 || score >= bestScore {
+			bestAlpha, bestScore = alpha, score
+		}
+	}
+	return bestAlpha, nil
+}
+
+// pruningPath crece sin podar el árbol que usaría el experimento y devuelve
+// su camino de poda.
+func pruningPath(exp *config.Experiment, train [][]float64) decisiontree.PruningPath {
+	opts := treeOptions(exp, treeDefaults(exp))
+	opts.CCPAlpha = 0
+	if exp.Algorithm == "randomforest" && exp.Variant == "parallel" {
+		classes := recordClasses(exp)
+		tree := randomforest.NewParallelDecisionTree(opts)
+		tree.SetClasses(classes)
+		tree.Train(toRecords(train, classes))
+		return tree.CostComplexityPath()
+	}
+	tree := decisiontree.NewSequentialDecisionTreeWithOptions(opts)
+	tree.Train(train)
+	return tree.CostComplexityPath()
+}
+
+// ccpCandidateAlphas toma hasta ccpCandidates alfas del camino repartidos de
+// forma uniforme, siempre con el primero (sin poda) y el último (solo la
+// raíz).
+func ccpCandidateAlphas(path decisiontree.PruningPath) []float64 {
+	alphas := path.Alphas
+	if len(alphas) <= ccpCandidates {
+		return alphas
+	}
+	candidates := make([]float64, ccpCandidates)
+	for i := range candidates {
+		candidates[i] = alphas[i*(len(alphas)-1)/(ccpCandidates-1)]
+	}
+	return candidates
+}
+
+// withCCPAlpha devuelve una copia de exp con el hiperparámetro ccpAlpha.
+func withCCPAlpha(exp *config.Experiment, alpha float64) *config.Experiment {
+	copied := *exp
+	copied.Hyperparameters = make(map[string]float64, len(exp.Hyperparameters)+1)
+	for name, value := range exp.Hyperparameters {
+		copied.Hyperparameters[name] = value
+	}
+	copied.Hyperparameters["ccpAlpha"] = alpha
+	return &copied
+}
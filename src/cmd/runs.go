@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"concurrente/internal/experiments"
+	"concurrente/internal/randomforest"
+)
+
+// runCommand ejecuta los subcomandos de línea de comandos y devuelve el
+// código de salida.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "run":
+		return runExperimentCommand(args[1:])
+	case "runs":
+		return runsCommand(args[1:])
+	case "codegen":
+		return codegenCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconocido: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Uso: concurrente [run -config archivo | runs list|show|diff | codegen -config archivo]")
+		return 2
+	}
+}
+
+func runsCommand(args []string) int {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	store := experiments.NewStore(runsFile)
+
+	switch args[0] {
+	case "list":
+		return listRuns(store, args[1:])
+	case "show":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Uso: concurrente runs show <id>")
+			return 2
+		}
+		run, err := store.Find(args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		printRun(run)
+		return 0
+	case "diff":
+		if len(args) != 3 {
+			fmt.Fprintln(os.Stderr, "Uso: concurrente runs diff <id> <id>")
+			return 2
+		}
+		return diffRuns(store, args[1], args[2])
+	default:
+		fmt.Fprintf(os.Stderr, "Subcomando desconocido: runs %s\n", args[0])
+		return 2
+	}
+}
+
+func listRuns(store *experiments.Store, args []string) int {
+	flags := flag.NewFlagSet("runs list", flag.ContinueOnError)
+	command := flags.String("command", "", "filtrar por comando (simulation, compare, ...)")
+	algorithm := flags.String("algorithm", "", "filtrar por algoritmo")
+	dataset := flags.String("dataset", "", "filtrar por archivo de datos")
+	since := flags.Duration("since", 0, "solo ejecuciones de este periodo (p. ej. 24h)")
+	params := flags.String("param", "", "filtrar por parámetros, p. ej. numTrees=10,subsetRatio=0.8")
+	limit := flags.Int("limit", 0, "mostrar solo las últimas N ejecuciones")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	filter := experiments.Filter{
+		Command:   *command,
+		Algorithm: *algorithm,
+		Dataset:   *dataset,
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+	if *params != "" {
+		filter.Params = make(map[string]float64)
+		for _, pair := range strings.Split(*params, ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			parsed, err := strconv.ParseFloat(value, 64)
+			if !ok || err != nil {
+				fmt.Fprintf(os.Stderr, "Parámetro inválido: %s\n", pair)
+				return 2
+			}
+			filter.Params[name] = parsed
+		}
+	}
+
+	runs, err := store.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error al leer las ejecuciones:", err)
+		return 1
+	}
+	runs = experiments.Select(runs, filter)
+	if *limit > 0 && len(runs) > *limit {
+		runs = runs[len(runs)-*limit:]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFECHA\tCOMANDO\tALGORITMO\tFILAS\tPARÁMETROS\tPRECISIÓN\tENTRENAMIENTO (ms)")
+	for _, run := range runs {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%d\t%s\t%.2f%%\t%.1f\n",
+			run.ID,
+			run.Time.Format("2006-01-02 15:04:05"),
+			run.Command,
+			run.Algorithm,
+			run.DatasetRows,
+			formatParams(run.Params),
+			run.Metrics["accuracy"]*100,
+			run.Timings["train"],
+		)
+	}
+	w.Flush()
+	return 0
+}
+
+func diffRuns(store *experiments.Store, idA, idB string) int {
+	a, err := store.Find(idA)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	b, err := store.Find(idB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+
+	diffs := experiments.Diff(a, b)
+	if len(diffs) == 0 {
+		fmt.Println("Las ejecuciones no tienen diferencias.")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CAMPO\t%s\t%s\n", a.ID, b.ID)
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Field, d.A, d.B)
+	}
+	w.Flush()
+	return 0
+}
+
+func printRun(run experiments.Run) {
+	fmt.Printf("ID: %s\n", run.ID)
+	fmt.Printf("Fecha: %s\n", run.Time.Format(time.RFC3339))
+	fmt.Printf("Comando: %s\n", run.Command)
+	fmt.Printf("Algoritmo: %s\n", run.Algorithm)
+	fmt.Printf("Parámetros: %s\n", formatParams(run.Params))
+	fmt.Printf("Datos: %s (%d filas, sha256 %s)\n", run.Dataset, run.DatasetRows, run.DatasetHash)
+	fmt.Printf("Semilla: %d\n", run.Seed)
+	fmt.Printf("Go: %s, GOMAXPROCS = %d, CPUs = %d\n", run.GoVersion, run.GOMAXPROCS, run.NumCPU)
+	fmt.Printf("Métricas: %s\n", formatParams(run.Metrics))
+	fmt.Printf("Tiempos (ms): %s\n", formatParams(run.Timings))
+}
+
+func formatParams(values map[string]float64) string {
+	pairs := make([]string, 0, len(values))
+	for name, value := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=%g", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// newForestRun prepara el registro de una ejecución del bosque paralelo con
+// los parámetros actuales. Debe llamarse antes de que splitData baraje los
+// datos para que el hash corresponda al orden leído del archivo.
+func newForestRun(command string, allData [][]string, seed int64) experiments.Run {
+	run := experiments.NewRun(command, "parallel-forest")
+	run.Params["numTrees"] = float64(numTrees)
+	run.Params["subsetRatio"] = subsetRatio
+	run.Params["maxBins"] = float64(maxBins)
+	run.Params["trainRatio"] = trainRatio
+	run.Dataset = datasetFile
+	run.DatasetHash = experiments.HashRecords(allData)
+	run.DatasetRows = len(allData)
+	run.Seed = seed
+	return run
+}
+
+// newExtraTreesRun es newForestRun para los ExtraTrees paralelos, que usan
+// todos los registros de entrenamiento en cada árbol.
+func newExtraTreesRun(command string, allData [][]string, seed int64) experiments.Run {
+	run := newForestRun(command, allData, seed)
+	run.Algorithm = "parallel-extratrees"
+	delete(run.Params, "subsetRatio")
+	delete(run.Params, "maxBins")
+	return run
+}
+
+// reportOOB estima la precisión del bosque con los registros de
+// entrenamiento, cada uno votado solo por los árboles que no lo vieron, y la
+// añade a las métricas de run. Los registros deben ser los mismos con los que
+// se entrenó el bosque, con exporta como última columna, como los que
+// prepara forestData.
+func reportOOB(rf *randomforest.ParallelRandomForest, trainData [][]string, run *experiments.Run) {
+	start := time.Now()
+	oob, err := rf.OOB(trainData)
+	if err != nil {
+		fmt.Println("Error al calcular la estimación OOB:", err)
+		return
+	}
+	elapsed := time.Since(start)
+	fmt.Printf(
+		"Precisión OOB: %.2f%% (%d de %d registros de entrenamiento, en %v)\n",
+		oob.Score*100,
+		oob.Rows,
+		len(trainData),
+		elapsed,
+	)
+	run.Metrics["oob_accuracy"] = oob.Score
+	run.SetTiming("oob", elapsed)
+}
+
+func recordForestRun(run experiments.Run, trainTime, evalTime time.Duration, accuracy float64) {
+	run.Metrics["accuracy"] = accuracy
+	run.SetTiming("train", trainTime)
+	run.SetTiming("eval", evalTime)
+	run.SetTiming("total", trainTime+evalTime)
+
+	if err := experiments.NewStore(runsFile).Append(run); err != nil {
+		fmt.Println("Error al guardar la ejecución:", err)
+		return
+	}
+	fmt.Printf("Ejecución registrada: %s\n", run.ID)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"concurrente/internal/randomforest"
+	"concurrente/internal/tuning"
+)
+
+const (
+	leaderboardFile = "leaderboard.json"
+	tuningFolds     = 3
+	tuningTrials    = 20
+	tuningSeed      = 1
+)
+
+func searchHyperparameters() {
+	fmt.Println("\n--- Búsqueda de Hiperparámetros ---")
+	fmt.Println("1. Búsqueda en Rejilla")
+	fmt.Println("2. Búsqueda Aleatoria")
+	fmt.Println("3. Successive Halving")
+	fmt.Print("Ingrese el método: ")
+	method := getUserChoice()
+
+	fmt.Printf("CPUs disponibles para la búsqueda (Enter para %d): ", runtime.GOMAXPROCS(0))
+	cpus := runtime.GOMAXPROCS(0)
+	if input := readLine(); input != "" {
+		if val, err := strconv.Atoi(input); err == nil && val > 0 {
+			cpus = val
+		}
+	}
+
+	header, allData := readAndPrepareData(datasetSize)
+	rows, _, err := forestData(header, allData)
+	if err != nil {
+		fmt.Println("Error al preparar los datos del bosque:", err)
+		return
+	}
+	// splitData baraja las filas, así los presupuestos parciales de
+	// successive halving usan una muestra aleatoria.
+	rows, _ = splitData(rows, 1.0, tuningSeed)
+
+	cfg := tuning.Config{
+		CPUs:         cpus,
+		CPUsPerTrial: 1,
+		Budget:       len(rows),
+		MinBudget:    len(rows) / 9,
+		Eta:          3,
+		Seed:         tuningSeed,
+	}
+	objective := forestObjective(rows, cfg.Seed)
+
+	var board tuning.Leaderboard
+	switch method {
+	case 1:
+		space := tuning.Space{
+			{Name: "numTrees", Values: []float64{5, 10, 20, 50}},
+			{Name: "subsetRatio", Values: []float64{0.5, 0.7, 0.8, 1.0}},
+		}
+		board, err = tuning.GridSearch(space, objective, cfg)
+	case 2:
+		board, err = tuning.RandomSearch(forestSearchSpace(), tuningTrials, objective, cfg)
+	case 3:
+		board, err = tuning.SuccessiveHalving(forestSearchSpace(), tuningTrials, objective, cfg)
+	default:
+		fmt.Println("Método no válido.")
+		return
+	}
+	if err != nil {
+		fmt.Println("Error en la búsqueda:", err)
+		return
+	}
+
+	printLeaderboard(board, 5)
+	if err := board.Save(leaderboardFile); err != nil {
+		fmt.Println("Error al guardar la tabla de resultados:", err)
+	} else {
+		fmt.Printf("Tabla de resultados guardada en %s\n", leaderboardFile)
+	}
+
+	best, ok := board.Best()
+	if !ok {
+		fmt.Println("Ninguna prueba terminó correctamente.")
+		return
+	}
+	fmt.Print("¿Aplicar los mejores parámetros? (s/n): ")
+	if input := readLine(); input == "s" || input == "S" {
+		numTrees = best.Params.Int("numTrees")
+		subsetRatio = best.Params["subsetRatio"]
+		fmt.Printf(
+			"Parámetros del algoritmo actualizados: Árboles = %d, Ratio de Subconjunto = %.2f\n",
+			numTrees,
+			subsetRatio,
+		)
+	}
+}
+
+func forestSearchSpace() tuning.Space {
+	return tuning.Space{
+		{Name: "numTrees", Min: 5, Max: 100, Integer: true, Log: true},
+		{Name: "subsetRatio", Min: 0.3, Max: 1.0},
+	}
+}
+
+// forestObjective evalúa un ParallelRandomForest por su precisión con
+// validación cruzada sobre las primeras trial.Budget filas de forestData, que
+// tienen exporta como etiqueta y no como atributo.
+func forestObjective(data [][]float64, seed int64) tuning.Objective {
+	return func(trial tuning.Trial) (float64, error) {
+		rows := data
+		if trial.Budget > 0 && trial.Budget < len(rows) {
+			rows = rows[:trial.Budget]
+		}
+		return tuning.CrossValidate(
+			rows,
+			tuningFolds,
+			seed,
+			func(train, test [][]float64) (float64, error) {
+				rf := randomforest.NewParallelRandomForest(
+					trial.Params.Int("numTrees"),
+					trial.Params["subsetRatio"],
+				)
+				rf.SetSeed(seed)
+				rf.SetNumWorkers(trial.Workers)
+				rf.SetClasses(forestClasses)
+				rf.Train(toRecords(train, forestClasses))
+				return evaluateModel(rf, toRecords(test, forestClasses)), nil
+			},
+		)
+	}
+}
+
+func printLeaderboard(board tuning.Leaderboard, limit int) {
+	fmt.Printf("\nMejores resultados:\n")
+	for i, trial := range board {
+		if i >= limit {
+			break
+		}
+		if trial.Err != nil {
+			fmt.Printf("%d. %v: error: %v\n", i+1, trial.Params, trial.Err)
+			continue
+		}
+		fmt.Printf(
+			"%d. %v (filas = %d): Precisión = %.2f%%, Tiempo = %v\n",
+			i+1,
+			trial.Params,
+			trial.Budget,
+			trial.Score*100,
+			trial.Duration,
+		)
+	}
+}
@@ -13,6 +13,7 @@ type ConcurrentANN struct {
 	outputBias   float64
 	learningRate float64
 	epochs       int
+	mu           sync.Mutex
 }
 
 func NewConcurrentANN(
@@ -45,6 +46,24 @@ func NewConcurrentANN(
 }
 
 func (ann *ConcurrentANN) Train(data [][]float64) {
+	ann.train(data, nil)
+}
+
+// TrainWeighted is Train with the error of row i, and so every gradient it
+// backpropagates, scaled by weights[i]. Nil weights train like Train. It
+// returns an error, and leaves the network as it was, unless there is one
+// non-negative, finite weight per row and they add up to more than 0.
+func (ann *ConcurrentANN) TrainWeighted(data [][]float64, weights []float64) error {
+	if weights != nil {
+		if err := checkWeights(weights, len(data)); err != nil {
+			return err
+		}
+	}
+	ann.train(data, weights)
+	return nil
+}
+
+func (ann *ConcurrentANN) train(data [][]float64, weights []float64) {
 	var wg sync.WaitGroup
 	numGoroutines := 4 // Adjust based on your system's capabilities
 
@@ -52,6 +71,10 @@ func (ann *ConcurrentANN) Train(data [][]float64) {
 		wg.Add(numGoroutines)
 		chunkSize := len(data) / numGoroutines
 
+		// Every chunk starts from the weights at the beginning of the epoch, so
+		// the goroutines never read what another one is writing.
+		snapshot := ann.snapshot()
+
 		for i := 0; i < numGoroutines; i++ {
 			start := i * chunkSize
 			end := start + chunkSize
@@ -59,9 +82,13 @@ func (ann *ConcurrentANN) Train(data [][]float64) {
 				end = len(data)
 			}
 
+			var chunkWeights []float64
+			if weights != nil {
+				chunkWeights = weights[start:end]
+			}
 			go func(start, end int) {
 				defer wg.Done()
-				ann.trainChunk(data[start:end])
+				ann.trainChunk(data[start:end], chunkWeights, snapshot)
 			}(start, end)
 		}
 
@@ -69,17 +96,30 @@ func (ann *ConcurrentANN) Train(data [][]float64) {
 	}
 }
 
-func (ann *ConcurrentANN) trainChunk(chunk [][]float64) {
-	localHiddenLayer := make([][]float64, len(ann.hiddenLayer))
-	for i := range localHiddenLayer {
-		localHiddenLayer[i] = make([]float64, len(ann.hiddenLayer[i]))
-		copy(localHiddenLayer[i], ann.hiddenLayer[i])
-	}
-	localOutputWeight := make([]float64, len(ann.outputWeight))
-	copy(localOutputWeight, ann.outputWeight)
-	localOutputBias := ann.outputBias
+// annWeights is a copy of the trainable parameters of a ConcurrentANN.
+type annWeights struct {
+	hiddenLayer  [][]float64
+	outputWeight []float64
+	outputBias   float64
+}
+
+func (ann *ConcurrentANN) snapshot() annWeights {
+	return annWeights{
+		hiddenLayer:  ann.hiddenLayer,
+		outputWeight: ann.outputWeight,
+		outputBias:   ann.outputBias,
+	}.copy()
+}
 
-	for _, sample := range chunk {
+// trainChunk backpropagates over chunk, whose row i weighs weights[i], on a
+// private copy of start and adds the resulting change to the shared weights.
+func (ann *ConcurrentANN) trainChunk(chunk [][]float64, weights []float64, start annWeights) {
+	local := start.copy()
+	localHiddenLayer := local.hiddenLayer
+	localOutputWeight := local.outputWeight
+	localOutputBias := local.outputBias
+
+	for row, sample := range chunk {
 		features := sample[:len(sample)-1]
 		label := sample[len(sample)-1]
 
@@ -100,7 +140,8 @@ func (ann *ConcurrentANN) trainChunk(chunk [][]float64) {
 		finalOutput = sigmoid(finalOutput)
 
 		// Backpropagation
-		outputDelta := (label - finalOutput) * sigmoidDerivative(finalOutput)
+		weight := sampleWeight(weights, row)
+		outputDelta := weight * (label - finalOutput) * sigmoidDerivative(finalOutput)
 
 		for i := range localOutputWeight {
 			localOutputWeight[i] += ann.learningRate * outputDelta * hiddenOutputs[i]
@@ -120,19 +161,32 @@ func (ann *ConcurrentANN) trainChunk(chunk [][]float64) {
 	}
 
 	// Update the shared resources once, after processing the entire chunk
-	var mutex sync.Mutex
-	mutex.Lock()
-	defer mutex.Unlock()
+	ann.mu.Lock()
+	defer ann.mu.Unlock()
 
 	for i := range ann.hiddenLayer {
 		for j := range ann.hiddenLayer[i] {
-			ann.hiddenLayer[i][j] += localHiddenLayer[i][j]
+			ann.hiddenLayer[i][j] += localHiddenLayer[i][j] - start.hiddenLayer[i][j]
 		}
 	}
 	for i := range ann.outputWeight {
-		ann.outputWeight[i] += localOutputWeight[i]
+		ann.outputWeight[i] += localOutputWeight[i] - start.outputWeight[i]
+	}
+	ann.outputBias += localOutputBias - start.outputBias
+}
+
+func (w annWeights) copy() annWeights {
+	c := annWeights{
+		hiddenLayer:  make([][]float64, len(w.hiddenLayer)),
+		outputWeight: make([]float64, len(w.outputWeight)),
+		outputBias:   w.outputBias,
+	}
+	for i := range w.hiddenLayer {
+		c.hiddenLayer[i] = make([]float64, len(w.hiddenLayer[i]))
+		copy(c.hiddenLayer[i], w.hiddenLayer[i])
 	}
-	ann.outputBias += localOutputBias
+	copy(c.outputWeight, w.outputWeight)
+	return c
 }
 
 func (ann *ConcurrentANN) Predict(sample []float64) float64 {
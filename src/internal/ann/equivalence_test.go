@@ -0,0 +1,44 @@
+package ann
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestConcurrentANNLearnsSameModelAsSequential(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		data := harness.Classification(2000, 4, 0.02, seed)
+		train, test := data[:1500], data[1500:]
+
+		sequential := NewSequentialANN(4, 6, 0.1, 60)
+		concurrent := NewConcurrentANN(4, 6, 0.1, 60)
+		// Both networks start from the same weights so that any difference
+		// comes from how training is scheduled.
+		for i := range sequential.hiddenLayer {
+			copy(concurrent.hiddenLayer[i], sequential.hiddenLayer[i])
+		}
+		copy(concurrent.outputWeight, sequential.outputWeight)
+		concurrent.outputBias = sequential.outputBias
+
+		sequential.Train(train)
+		concurrent.Train(train)
+
+		want := harness.Predictions(sequential, test)
+		got := harness.Predictions(concurrent, test)
+
+		sequentialAccuracy := harness.Accuracy(want, test, 0.5)
+		concurrentAccuracy := harness.Accuracy(got, test, 0.5)
+		if sequentialAccuracy < 0.9 || concurrentAccuracy < 0.9 {
+			t.Fatalf(
+				"seed %d: accuracy sequential %.3f, concurrent %.3f",
+				seed,
+				sequentialAccuracy,
+				concurrentAccuracy,
+			)
+		}
+		if agreement := harness.Agreement(want, got, 0.5); agreement < 0.9 {
+			t.Fatalf("seed %d: models agree on %.3f of the test rows", seed, agreement)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ann
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 )
@@ -45,8 +46,26 @@ func NewSequentialANN(
 }
 
 func (ann *SequentialANN) Train(data [][]float64) {
+	ann.train(data, nil)
+}
+
+// TrainWeighted is Train with the error of row i, and so every gradient it
+// backpropagates, scaled by weights[i]. Nil weights train like Train. It
+// returns an error, and leaves the network as it was, unless there is one
+// non-negative, finite weight per row and they add up to more than 0.
+func (ann *SequentialANN) TrainWeighted(data [][]float64, weights []float64) error {
+	if weights != nil {
+		if err := checkWeights(weights, len(data)); err != nil {
+			return err
+		}
+	}
+	ann.train(data, weights)
+	return nil
+}
+
+func (ann *SequentialANN) train(data [][]float64, weights []float64) {
 	for epoch := 0; epoch < ann.epochs; epoch++ {
-		for _, sample := range data {
+		for row, sample := range data {
 			features := sample[:len(sample)-1]
 			label := sample[len(sample)-1]
 
@@ -67,7 +86,8 @@ func (ann *SequentialANN) Train(data [][]float64) {
 			finalOutput = sigmoid(finalOutput)
 
 			// Backpropagation
-			outputDelta := (label - finalOutput) * sigmoidDerivative(finalOutput)
+			weight := sampleWeight(weights, row)
+			outputDelta := weight * (label - finalOutput) * sigmoidDerivative(finalOutput)
 
 			for i := range ann.outputWeight {
 				ann.outputWeight[i] += ann.learningRate * outputDelta * hiddenOutputs[i]
@@ -112,6 +132,33 @@ func (ann *SequentialANN) Predict(sample []float64) float64 {
 	return 0
 }
 
+// checkWeights returns an error unless weights holds one non-negative,
+// finite weight for each of rows training rows, adding up to more than 0.
+func checkWeights(weights []float64, rows int) error {
+	if len(weights) != rows {
+		return fmt.Errorf("ann: %d weights for %d rows", len(weights), rows)
+	}
+	sum := 0.0
+	for i, weight := range weights {
+		if !(weight >= 0) || math.IsInf(weight, 1) {
+			return fmt.Errorf("ann: weight %d is %g, want a non-negative number", i, weight)
+		}
+		sum += weight
+	}
+	if !(sum > 0) || math.IsInf(sum, 1) {
+		return fmt.Errorf("ann: weights add up to %g, want a positive number", sum)
+	}
+	return nil
+}
+
+// sampleWeight is the weight of row i, 1 when there are no weights.
+func sampleWeight(weights []float64, i int) float64 {
+	if weights == nil {
+		return 1
+	}
+	return weights[i]
+}
+
 func sigmoid(x float64) float64 {
 	return 1 / (1 + math.Exp(-x))
 }
@@ -0,0 +1,82 @@
+package ann
+
+import (
+	"math"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestUnitWeightsTrainTheUnweightedANN(t *testing.T) {
+	data := harness.Classification(600, 3, 0.05, 4)
+	ones := make([]float64, len(data))
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	trained := NewSequentialANN(3, 4, 0.1, 5)
+	weighted := NewSequentialANN(3, 4, 0.1, 5)
+	for i := range trained.hiddenLayer {
+		copy(weighted.hiddenLayer[i], trained.hiddenLayer[i])
+	}
+	copy(weighted.outputWeight, trained.outputWeight)
+	weighted.outputBias = trained.outputBias
+
+	trained.Train(data)
+	if err := weighted.TrainWeighted(data, ones); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range trained.hiddenLayer {
+		if diff := harness.MaxAbsDiff(trained.hiddenLayer[i], weighted.hiddenLayer[i]); diff != 0 {
+			t.Fatalf("hidden unit %d differs by %g", i, diff)
+		}
+	}
+	if diff := harness.MaxAbsDiff(trained.outputWeight, weighted.outputWeight); diff != 0 ||
+		trained.outputBias != weighted.outputBias {
+		t.Fatalf("output layer differs by %g", diff)
+	}
+}
+
+func TestWeightsPullTheANNTowardsHeavyRows(t *testing.T) {
+	// The same point is labelled both ways; the heavier label wins.
+	data := [][]float64{{0.3, 0.7, 1}, {0.3, 0.7, 0}}
+	for _, weights := range [][]float64{{4, 1}, {1, 4}} {
+		for _, model := range []interface {
+			TrainWeighted([][]float64, []float64) error
+			Predict([]float64) float64
+		}{NewSequentialANN(2, 3, 0.5, 200), NewConcurrentANN(2, 3, 0.5, 200)} {
+			if err := model.TrainWeighted(data, weights); err != nil {
+				t.Fatal(err)
+			}
+			want := 1.0
+			if weights[1] > weights[0] {
+				want = 0
+			}
+			if got := model.Predict([]float64{0.3, 0.7}); got != want {
+				t.Errorf("%T with weights %v predicts %g, want %g", model, weights, got, want)
+			}
+		}
+	}
+}
+
+func TestTrainWeightedRejectsBadWeights(t *testing.T) {
+	data := [][]float64{{0.3, 0.7, 1}, {0.3, 0.7, 0}}
+	for name, weights := range map[string][]float64{
+		"short":    {1},
+		"zero":     {0, 0},
+		"negative": {-1, 2},
+		"infinite": {1, math.Inf(1)},
+	} {
+		sequential := NewSequentialANN(2, 3, 0.5, 1)
+		bias := sequential.outputBias
+		if err := sequential.TrainWeighted(data, weights); err == nil || sequential.outputBias != bias {
+			t.Errorf("%s: sequential ANN trained with error %v", name, err)
+		}
+		concurrent := NewConcurrentANN(2, 3, 0.5, 1)
+		bias = concurrent.outputBias
+		if err := concurrent.TrainWeighted(data, weights); err == nil || concurrent.outputBias != bias {
+			t.Errorf("%s: concurrent ANN trained with error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package codegen compiles trained trees and forests into standalone Go
+// source: every tree becomes a function of nested if and switch statements
+// over a feature slice, so serving a prediction neither allocates nor walks
+// a node structure.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/randomforest"
+)
+
+// Model describes the trees to compile.
+type Model struct {
+	// Package is the name of the generated package.
+	Package string
+	// Roots are the trees. Predict returns the prediction of the only tree
+	// or the mean of the trees' predictions.
+	Roots []*decisiontree.Node
+	// Strict trees send values below the threshold left instead of values at
+	// or below it.
+	Strict bool
+	// FeatureNames[i], when present, is written as a comment next to the
+	// splits on feature i.
+	FeatureNames []string
+	// Source describes where the trees come from in the file's comment.
+	Source string
+}
+
+// Tree returns the Model of a trained SequentialDecisionTree.
+func Tree(pkg string, tree *decisiontree.SequentialDecisionTree) Model {
+	return Model{
+		Package: pkg,
+		Roots:   []*decisiontree.Node{tree.Root()},
+		Source:  "a SequentialDecisionTree",
+	}
+}
+
+// ParallelForest returns the Model of a trained ParallelRandomForest.
+// Feature i of the generated Predict is column i of the forest's records.
+func ParallelForest(pkg string, rf *randomforest.ParallelRandomForest) Model {
+	roots := rf.Roots()
+	return Model{
+		Package: pkg,
+		Roots:   roots,
+		Strict:  true,
+		Source:  fmt.Sprintf("a ParallelRandomForest of %d trees", len(roots)),
+	}
+}
+
+// Generate writes m as a gofmt-formatted Go file. Its Predict function gives
+// the same result as the in-memory model for every sample: thresholds and
+// leaf values are written with enough digits to parse back exactly, and a
+// forest sums its trees in the order the model does.
+func Generate(w io.Writer, m Model) error {
+	if !token.IsIdentifier(m.Package) {
+		return fmt.Errorf("codegen: %q is not a valid package name", m.Package)
+	}
+	if len(m.Roots) == 0 {
+		return fmt.Errorf("codegen: the model has no trees")
+	}
+
+	g := &generator{model: m}
+	g.printf("// Code generated by concurrente codegen. DO NOT EDIT.\n\n")
+	if m.Source != "" {
+		g.printf("// Package %s predicts with %s compiled to Go.\n", m.Package, m.Source)
+	}
+	g.printf("package %s\n\n", m.Package)
+	g.printf("// NumFeatures is the fewest features Predict reads: one more than the\n")
+	g.printf("// highest feature the trees split on.\n")
+	g.printf("const NumFeatures = %d\n\n", numFeatures(m.Roots))
+
+	if len(m.Roots) == 1 {
+		g.printf("// Predict returns the model's prediction for features.\n")
+		g.printf("func Predict(features []float64) float64 {\n")
+		g.node(m.Roots[0])
+		g.printf("}\n")
+	} else {
+		g.printf("// Predict returns the mean prediction of the model's %d trees for\n", len(m.Roots))
+		g.printf("// features.\n")
+		g.printf("func Predict(features []float64) float64 {\n")
+		g.printf("sum := 0.0\n")
+		for i := range m.Roots {
+			g.printf("sum += tree%d(features)\n", i)
+		}
+		g.printf("return sum / %d\n", len(m.Roots))
+		g.printf("}\n")
+		for i, root := range m.Roots {
+			g.printf("\nfunc tree%d(features []float64) float64 {\n", i)
+			g.node(root)
+			g.printf("}\n")
+		}
+	}
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: formatting the generated source: %w", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+type generator struct {
+	model Model
+	buf   bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// node writes the statements that return the prediction of the subtree
+// rooted at node.
+func (g *generator) node(node *decisiontree.Node) {
+	if node.Left == nil && node.Right == nil {
+		g.printf("return %s\n", literal(node.Prediction))
+		return
+	}
+	comment := ""
+	if node.Feature < len(g.model.FeatureNames) && g.model.FeatureNames[node.Feature] != "" {
+		comment = " // " + g.model.FeatureNames[node.Feature]
+	}
+	if node.Categories != nil {
+		cases := make([]string, len(node.Categories))
+		for i, category := range node.Categories {
+			cases[i] = literal(category)
+		}
+		g.printf("switch features[%d] {%s\n", node.Feature, comment)
+		g.printf("case %s:\n", strings.Join(cases, ", "))
+		g.node(node.Left)
+		g.printf("}\n")
+		g.node(node.Right)
+		return
+	}
+	operator := "<="
+	if g.model.Strict {
+		operator = "<"
+	}
+	g.printf("if features[%d] %s %s {%s\n", node.Feature, operator, literal(node.Threshold), comment)
+	g.node(node.Left)
+	g.printf("}\n")
+	g.node(node.Right)
+}
+
+// literal writes value as a Go constant that converts back to it exactly.
+func literal(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// numFeatures is one more than the highest feature any split reads.
+func numFeatures(roots []*decisiontree.Node) int {
+	n := 0
+	var visit func(node *decisiontree.Node)
+	visit = func(node *decisiontree.Node) {
+		if node.Left == nil && node.Right == nil {
+			return
+		}
+		n = max(n, node.Feature+1)
+		visit(node.Left)
+		visit(node.Right)
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return n
+}
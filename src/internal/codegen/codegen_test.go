@@ -0,0 +1,144 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+	"concurrente/internal/randomforest"
+)
+
+// predictTest follows the samples and predictions written by checkGenerated.
+const predictTest = `
+func TestPredict(t *testing.T) {
+	for i, sample := range samples {
+		if got := Predict(sample); got != want[i] {
+			t.Fatalf("sample %d: generated %v, model %v", i, got, want[i])
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { Predict(samples[0]) }); allocs != 0 {
+		t.Errorf("Predict allocates %v times", allocs)
+	}
+}
+`
+
+// checkGenerated writes the generated model to a module of its own, next to
+// a test asserting that Predict returns want[i] for samples[i] without
+// allocating, and runs that test with the go tool.
+func checkGenerated(t *testing.T, m Model, samples [][]float64, want []float64) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiles a generated module")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+
+	var source bytes.Buffer
+	if err := Generate(&source, m); err != nil {
+		t.Fatal(err)
+	}
+	var test bytes.Buffer
+	fmt.Fprintf(&test, "package %s\n\nimport \"testing\"\n\n", m.Package)
+	fmt.Fprintln(&test, "var samples = [][]float64{")
+	for _, sample := range samples {
+		values := make([]string, len(sample))
+		for i, value := range sample {
+			values[i] = literal(value)
+		}
+		fmt.Fprintf(&test, "\t{%s},\n", strings.Join(values, ", "))
+	}
+	fmt.Fprintln(&test, "}\n\nvar want = []float64{")
+	for _, value := range want {
+		fmt.Fprintf(&test, "\t%s,\n", literal(value))
+	}
+	fmt.Fprintln(&test, "}")
+	test.WriteString(predictTest)
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"go.mod":        []byte("module generated\n\ngo 1.21\n"),
+		"model.go":      source.Bytes(),
+		"model_test.go": test.Bytes(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goTool, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated code:\n%s\ngo test: %v\n%s", source.String(), err, output)
+	}
+}
+
+func TestGeneratedTreeMatchesModel(t *testing.T) {
+	data := harness.Classification(600, 4, 0.1, 3)
+	// Feature 0 becomes a category code so categorical splits are compiled
+	// too.
+	for _, row := range data {
+		row[0] = float64(int((row[0] + 1) * 5))
+	}
+	tree := decisiontree.NewSequentialDecisionTreeWithOptions(decisiontree.Options{
+		MaxDepth:    8,
+		Categorical: []int{0},
+	})
+	tree.Train(data)
+
+	samples := make([][]float64, len(data)+1)
+	want := make([]float64, len(samples))
+	for i, row := range data {
+		samples[i] = row[:len(row)-1]
+	}
+	// A category unseen in training takes the same branch in both.
+	samples[len(data)] = []float64{42, 0, 0, 0}
+	for i, sample := range samples {
+		want[i] = tree.Predict(sample)
+	}
+	checkGenerated(t, Tree("arbol", tree), samples, want)
+}
+
+func TestGeneratedParallelForestMatchesModel(t *testing.T) {
+	records := harness.StringRecords(harness.Classification(500, 5, 0.1, 4))
+	rf := randomforest.NewParallelRandomForest(7, 0.8)
+	rf.SetSeed(5)
+	rf.Train(records)
+
+	samples := make([][]float64, len(records))
+	want := make([]float64, len(records))
+	for i, record := range records {
+		sample := record[:len(record)-1]
+		samples[i] = make([]float64, len(sample))
+		for j, value := range sample {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			samples[i][j] = parsed
+		}
+		want[i] = rf.Predict(sample)
+	}
+	checkGenerated(t, ParallelForest("bosque", rf), samples, want)
+}
+
+func TestGenerateRejectsInvalidModels(t *testing.T) {
+	leaf := &decisiontree.Node{Prediction: 1}
+	for name, m := range map[string]Model{
+		"package name": {Package: "mi-modelo", Roots: []*decisiontree.Node{leaf}},
+		"no trees":     {Package: "modelo"},
+	} {
+		if err := Generate(&bytes.Buffer{}, m); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -27,35 +27,45 @@ func NewConcurrentMatrixFactorization(
 	}
 }
 
+// Train partitions users and items into numGoroutines ranges each and visits
+// the resulting blocks in strata: within a stratum every goroutine owns a
+// distinct user range and a distinct item range, so no two goroutines ever
+// update the same factor row.
 func (mf *ConcurrentMatrixFactorization) Train(ratings [][]float64) {
+	if len(ratings) == 0 {
+		return
+	}
+
 	var wg sync.WaitGroup
 	numGoroutines := 4 // Adjust based on your system's capabilities
+	userBounds := blockBounds(len(ratings), numGoroutines)
+	itemBounds := blockBounds(len(ratings[0]), numGoroutines)
 
 	for epoch := 0; epoch < mf.Epochs; epoch++ {
-		wg.Add(numGoroutines)
-		chunkSize := len(ratings) / numGoroutines
+		for stratum := 0; stratum < numGoroutines; stratum++ {
+			wg.Add(numGoroutines)
 
-		for i := 0; i < numGoroutines; i++ {
-			start := i * chunkSize
-			end := start + chunkSize
-			if i == numGoroutines-1 {
-				end = len(ratings)
+			for i := 0; i < numGoroutines; i++ {
+				j := (i + stratum) % numGoroutines
+
+				go func(userStart, userEnd, itemStart, itemEnd int) {
+					defer wg.Done()
+					mf.trainBlock(ratings, userStart, userEnd, itemStart, itemEnd)
+				}(userBounds[i], userBounds[i+1], itemBounds[j], itemBounds[j+1])
 			}
 
-			go func(start, end int) {
-				defer wg.Done()
-				mf.trainChunk(ratings[start:end])
-			}(start, end)
+			wg.Wait()
 		}
-
-		wg.Wait()
 	}
 }
 
-func (mf *ConcurrentMatrixFactorization) trainChunk(chunk [][]float64) {
-	for userID, userRatings := range chunk {
-		for itemID, rating := range userRatings {
-			if rating > 0 {
+func (mf *ConcurrentMatrixFactorization) trainBlock(
+	ratings [][]float64,
+	userStart, userEnd, itemStart, itemEnd int,
+) {
+	for userID := userStart; userID < userEnd; userID++ {
+		for itemID := itemStart; itemID < itemEnd; itemID++ {
+			if rating := ratings[userID][itemID]; rating > 0 {
 				mf.updateFactors(userID, itemID, rating)
 			}
 		}
@@ -74,3 +84,15 @@ func (mf *ConcurrentMatrixFactorization) updateFactors(userID, itemID int, ratin
 		mf.ItemFactors[itemID][f] += mf.LearningRate * (err*userFactor - mf.Regularization*itemFactor)
 	}
 }
+
+// blockBounds splits n rows into parts contiguous ranges and returns their
+// boundaries, with the last range absorbing the remainder.
+func blockBounds(n, parts int) []int {
+	bounds := make([]int, parts+1)
+	size := n / parts
+	for i := 1; i < parts; i++ {
+		bounds[i] = i * size
+	}
+	bounds[parts] = n
+	return bounds
+}
@@ -0,0 +1,59 @@
+package collaborativefiltering
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestConcurrentFactorizationMatchesSequential(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		ratings := harness.Ratings(60, 40, 3, 0.3, seed)
+
+		sequential := NewSequentialMatrixFactorization(60, 40, 5, 0.01, 0.02, 200)
+		concurrent := NewConcurrentMatrixFactorization(60, 40, 5, 0.01, 0.02, 200)
+		// Both models start from the same factors so that any difference
+		// comes from the order in which ratings are visited.
+		for i := range sequential.UserFactors {
+			copy(concurrent.UserFactors[i], sequential.UserFactors[i])
+		}
+		for i := range sequential.ItemFactors {
+			copy(concurrent.ItemFactors[i], sequential.ItemFactors[i])
+		}
+		initialRMSE := sequential.CalculateRMSE(ratings)
+
+		sequential.Train(ratings)
+		concurrent.Train(ratings)
+
+		sequentialRMSE := sequential.CalculateRMSE(ratings)
+		concurrentRMSE := concurrent.CalculateRMSE(ratings)
+		if sequentialRMSE > initialRMSE/2 || concurrentRMSE > initialRMSE/2 {
+			t.Fatalf(
+				"seed %d: RMSE went from %.3f to %.3f sequential, %.3f concurrent",
+				seed,
+				initialRMSE,
+				sequentialRMSE,
+				concurrentRMSE,
+			)
+		}
+		if !harness.WithinTolerance(sequentialRMSE, concurrentRMSE, 0.05) {
+			t.Fatalf(
+				"seed %d: RMSE sequential %.4f, concurrent %.4f",
+				seed,
+				sequentialRMSE,
+				concurrentRMSE,
+			)
+		}
+
+		var want, got []float64
+		for u := range ratings {
+			for i := range ratings[u] {
+				want = append(want, sequential.Predict(u, i))
+				got = append(got, concurrent.Predict(u, i))
+			}
+		}
+		if diff := harness.MaxAbsDiff(want, got); diff > 0.25 {
+			t.Fatalf("seed %d: predicted ratings differ by up to %.3f", seed, diff)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package config describes experiments declaratively: which dataset to load,
+// how to turn it into features, which algorithm and variant to train with
+// which hyperparameters, how to evaluate it and where to write the results.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Experiment struct {
+	Name          string        `json:"name,omitempty"`
+	Dataset       Dataset       `json:"dataset"`
+	Preprocessing Preprocessing `json:"preprocessing"`
+	// Task is "classification" (default), with a 0/1 label taken from
+	// PositiveLabel or a class index taken from Preprocessing.Classes, or
+	// "regression", with the label column as the target.
+	Task            string             `json:"task,omitempty"`
+	Algorithm       string             `json:"algorithm"`
+	Variant         string             `json:"variant"`
+	Hyperparameters map[string]float64 `json:"hyperparameters,omitempty"`
+	// Criterion is the split criterion of tree algorithms. Empty means gini,
+	// or mse for regression.
+	Criterion string `json:"criterion,omitempty"`
+	// MaxFeatures is how many features each split of a tree algorithm
+	// searches, drawn at random at every node: "sqrt", "log2", a fraction
+	// such as 0.5 or a count such as 4. Empty searches every feature.
+	MaxFeatures MaxFeatures `json:"max_features,omitempty"`
+	Evaluation  Evaluation  `json:"evaluation"`
+	Outputs     Outputs     `json:"outputs"`
+}
+
+// MaxFeatures is a JSON string or number, kept as its text.
+type MaxFeatures string
+
+func (m *MaxFeatures) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*m = MaxFeatures(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("max_features must be a string or a number: %w", err)
+	}
+	*m = MaxFeatures(text)
+	return nil
+}
+
+type Dataset struct {
+	Path string `json:"path"`
+	// Separator is the single-character field delimiter. Defaults to "|".
+	Separator string `json:"separator,omitempty"`
+	// Limit caps the number of records read; 0 reads the whole file.
+	Limit int `json:"limit,omitempty"`
+}
+
+type Preprocessing struct {
+	// LabelColumn is the header name of the target column.
+	LabelColumn string `json:"label_column"`
+	// PositiveLabel is the label value treated as the positive class.
+	// Defaults to "SI".
+	PositiveLabel string `json:"positive_label,omitempty"`
+	// Classes, if set, lists the label values of a multiclass task; the
+	// label of a record is the index of its value, and records with other
+	// values are dropped. It replaces PositiveLabel and needs a tree
+	// algorithm.
+	Classes []string `json:"classes,omitempty"`
+	// DropColumns are header names excluded from the features.
+	DropColumns []string `json:"drop_columns,omitempty"`
+	// CategoricalColumns are header names of features whose values are
+	// categories rather than numbers. Trees split them on subsets of their
+	// values; it needs decisiontree, extratrees or a sequential or
+	// concurrent randomforest.
+	CategoricalColumns []string `json:"categorical_columns,omitempty"`
+	// Missing says what to do with non-numeric feature values: "drop" the
+	// record (default) or replace the value with "zero".
+	Missing string `json:"missing,omitempty"`
+}
+
+type Evaluation struct {
+	// Protocol is "holdout" (default) or "cv".
+	Protocol   string  `json:"protocol,omitempty"`
+	TrainRatio float64 `json:"train_ratio,omitempty"`
+	Folds      int     `json:"folds,omitempty"`
+	Seed       int64   `json:"seed,omitempty"`
+}
+
+type Outputs struct {
+	// Runs is the experiment store the run is appended to. Defaults to
+	// "runs.jsonl"; "-" disables recording.
+	Runs string `json:"runs,omitempty"`
+	// Predictions, if set, receives a CSV with the holdout predictions.
+	Predictions string `json:"predictions,omitempty"`
+	// Tree, if set, receives the trees of a holdout run with the feature
+	// names of the dataset: Graphviz DOT for a .dot file, JSON for .json and
+	// if/then rules otherwise.
+	Tree string `json:"tree,omitempty"`
+	// Curve, if set, receives a CSV with the holdout accuracy, or R² for
+	// regression, of the first 1, 2, ... trees of a randomforest or
+	// extratrees run, to see how many trees the ensemble needs.
+	Curve string `json:"curve,omitempty"`
+	// Profile, if set, is the directory that receives a subdirectory per run
+	// with CPU, heap, mutex and block profiles and an execution trace.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Load reads the experiment in filename, applies the overrides in order and
+// validates the result. Unknown fields are rejected.
+func Load(filename string, overrides []string) (*Experiment, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	exp, err := Parse(file, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return exp, nil
+}
+
+// Parse is Load for an already opened reader.
+func Parse(r io.Reader, overrides []string) (*Experiment, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		if err := applyOverride(doc, override); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	exp := &Experiment{}
+	if err := decoder.Decode(exp); err != nil {
+		return nil, err
+	}
+
+	exp.setDefaults()
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// applyOverride sets a dotted path such as "hyperparameters.numTrees=50".
+// The value is parsed as JSON when possible and taken as a string otherwise.
+func applyOverride(doc map[string]any, override string) error {
+	path, rawValue, ok := strings.Cut(override, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("override %q is not of the form key=value", override)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+		value = rawValue
+	}
+
+	keys := strings.Split(path, ".")
+	node := doc
+	for _, key := range keys[:len(keys)-1] {
+		child, exists := node[key]
+		if !exists {
+			child = map[string]any{}
+			node[key] = child
+		}
+		childMap, ok := child.(map[string]any)
+		if !ok {
+			return fmt.Errorf("override %q: %q is not an object", override, key)
+		}
+		node = childMap
+	}
+	node[keys[len(keys)-1]] = value
+	return nil
+}
+
+func (e *Experiment) setDefaults() {
+	if e.Dataset.Separator == "" {
+		e.Dataset.Separator = "|"
+	}
+	if e.Preprocessing.PositiveLabel == "" {
+		e.Preprocessing.PositiveLabel = "SI"
+	}
+	if e.Preprocessing.Missing == "" {
+		e.Preprocessing.Missing = "drop"
+	}
+	if e.Task == "" {
+		e.Task = "classification"
+	}
+	if e.Evaluation.Protocol == "" {
+		e.Evaluation.Protocol = "holdout"
+	}
+	if e.Evaluation.Protocol == "holdout" && e.Evaluation.TrainRatio == 0 {
+		e.Evaluation.TrainRatio = 0.8
+	}
+	if e.Evaluation.Protocol == "cv" && e.Evaluation.Folds == 0 {
+		e.Evaluation.Folds = 5
+	}
+	if e.Outputs.Runs == "" {
+		e.Outputs.Runs = "runs.jsonl"
+	}
+
+	spec, ok := algorithms[e.Algorithm]
+	if !ok {
+		return
+	}
+	if e.Variant == "" {
+		e.Variant = spec.variants[0]
+	}
+	if e.Hyperparameters == nil {
+		e.Hyperparameters = make(map[string]float64)
+	}
+	for name, param := range spec.params {
+		if _, set := e.Hyperparameters[name]; !set && !param.optional {
+			e.Hyperparameters[name] = param.def
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const forestExperiment = `{
+	"name": "bosque paralelo",
+	"dataset": {"path": "datasets/bd_mujeres_2023.csv", "limit": 10000},
+	"preprocessing": {"label_column": "exporta", "drop_columns": ["fec_creacion"]},
+	"algorithm": "randomforest",
+	"hyperparameters": {"numTrees": 20},
+	"evaluation": {"seed": 7}
+}`
+
+func TestParseAppliesDefaults(t *testing.T) {
+	exp, err := Parse(strings.NewReader(forestExperiment), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Variant != "parallel" {
+		t.Errorf("variant %q, want the randomforest default \"parallel\"", exp.Variant)
+	}
+	if exp.Hyperparameters["numTrees"] != 20 || exp.Hyperparameters["subsetRatio"] != 0.8 {
+		t.Errorf("hyperparameters %v", exp.Hyperparameters)
+	}
+	if exp.Dataset.Separator != "|" || exp.Preprocessing.PositiveLabel != "SI" {
+		t.Errorf("dataset %+v, preprocessing %+v", exp.Dataset, exp.Preprocessing)
+	}
+	if exp.Evaluation.Protocol != "holdout" || exp.Evaluation.TrainRatio != 0.8 {
+		t.Errorf("evaluation %+v", exp.Evaluation)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	exp, err := Parse(strings.NewReader(forestExperiment), []string{
+		"hyperparameters.numTrees=50",
+		"variant=sequential",
+		"evaluation.protocol=cv",
+		"evaluation.folds=3",
+		"outputs.runs=-",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Hyperparameters["numTrees"] != 50 || exp.Variant != "sequential" {
+		t.Errorf("overrides not applied: %+v", exp)
+	}
+	if exp.Evaluation.Protocol != "cv" || exp.Evaluation.Folds != 3 || exp.Outputs.Runs != "-" {
+		t.Errorf("evaluation %+v, outputs %+v", exp.Evaluation, exp.Outputs)
+	}
+
+	// Unlike the parallel random forest, parallel extra trees work on numeric
+	// rows, so they take regression and categorical columns.
+	exp, err = Parse(strings.NewReader(forestExperiment), []string{
+		"algorithm=extratrees",
+		"hyperparameters={}",
+		"task=regression",
+		`preprocessing.categorical_columns=["region"]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Variant != "parallel" || exp.Hyperparameters["numTrees"] != 10 {
+		t.Errorf("extra trees variant %q, hyperparameters %v", exp.Variant, exp.Hyperparameters)
+	}
+
+	if _, err := Parse(strings.NewReader(forestExperiment), []string{"name.first=x"}); err == nil {
+		t.Error("overriding inside a string should fail")
+	}
+}
+
+func TestParseRejectsInvalidExperiments(t *testing.T) {
+	tests := map[string]struct {
+		overrides []string
+		want      []string
+	}{
+		"unknown field": {
+			overrides: []string{"dataset.columns=3"},
+			want:      []string{"unknown field"},
+		},
+		"unknown algorithm": {
+			overrides: []string{"algorithm=knn"},
+			want:      []string{"algorithm must be one of"},
+		},
+		"unknown criterion": {
+			overrides: []string{"criterion=chi2"},
+			want:      []string{"criterion must be one of gini, entropy"},
+		},
+		"criterion for a non-tree algorithm": {
+			overrides: []string{"algorithm=svm", "hyperparameters={}", "criterion=entropy"},
+			want:      []string{"criterion only applies to tree algorithms"},
+		},
+		"max features": {
+			overrides: []string{"max_features=1.5"},
+			want:      []string{"max_features must be sqrt, log2"},
+		},
+		"max features for a non-tree algorithm": {
+			overrides: []string{"algorithm=svm", "hyperparameters={}", "max_features=sqrt"},
+			want:      []string{"max_features only applies to tree algorithms"},
+		},
+		"unknown task": {
+			overrides: []string{"task=ranking"},
+			want:      []string{"task must be"},
+		},
+		"regression with the parallel forest": {
+			overrides: []string{"task=regression", "variant=parallel", "criterion=gini"},
+			want: []string{
+				"regression needs decisiontree, extratrees or a sequential",
+				"regression needs the mse or mae criterion",
+			},
+		},
+		"classes for a non-tree algorithm": {
+			overrides: []string{
+				"algorithm=ann",
+				"hyperparameters={}",
+				`preprocessing.classes=["bajo", "medio", "bajo"]`,
+			},
+			want: []string{
+				"preprocessing.classes repeats \"bajo\"",
+				"preprocessing.classes needs a tree algorithm",
+			},
+		},
+		"classes with a regression criterion": {
+			overrides: []string{`preprocessing.classes=["bajo", "alto"]`, "criterion=mse"},
+			want:      []string{"preprocessing.classes needs a classification criterion"},
+		},
+		"categorical columns": {
+			overrides: []string{
+				`preprocessing.categorical_columns=["region", "exporta", "fec_creacion", "region"]`,
+			},
+			want: []string{
+				"preprocessing.categorical_columns repeats \"region\"",
+				"must not contain the label column \"exporta\"",
+				"contains the dropped column \"fec_creacion\"",
+				"preprocessing.categorical_columns needs decisiontree, extratrees or a sequential",
+			},
+		},
+		"tree output": {
+			overrides: []string{
+				"algorithm=svm",
+				"hyperparameters={}",
+				"evaluation.protocol=cv",
+				"outputs.tree=arbol.dot",
+			},
+			want: []string{
+				"outputs.tree needs a tree algorithm",
+				"outputs.tree is only available with the holdout protocol",
+			},
+		},
+		"curve output": {
+			overrides: []string{
+				"algorithm=decisiontree",
+				"hyperparameters={}",
+				`preprocessing.classes=["bajo", "alto"]`,
+				"evaluation.protocol=cv",
+				"outputs.curve=curva.csv",
+			},
+			want: []string{
+				"outputs.curve needs randomforest or extratrees",
+				"outputs.curve only applies to binary classification",
+				"outputs.curve is only available with the holdout protocol",
+			},
+		},
+		"every problem reported": {
+			overrides: []string{
+				"variant=gpu",
+				"hyperparameters.numTrees=2.5",
+				"hyperparameters.subsetRatio=0",
+				"hyperparameters.depth=3",
+				"evaluation.train_ratio=1",
+			},
+			want: []string{
+				"variant for randomforest",
+				"numTrees must be an integer",
+				"subsetRatio = 0 is out of range (0, 1]",
+				"depth is not a parameter",
+				"train_ratio must be in (0, 1)",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(forestExperiment), tt.overrides)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"concurrente/internal/decisiontree"
+)
+
+// param is the schema of one hyperparameter.
+type param struct {
+	def      float64
+	min, max float64
+	// positive excludes min itself from the range.
+	positive bool
+	integer  bool
+	// optional parameters are not filled in with def; the model keeps its
+	// own default when they are absent.
+	optional bool
+}
+
+// treeParams are the stopping criteria, pruning and probability smoothing
+// shared by every tree-based algorithm.
+var treeParams = map[string]param{
+	"maxDepth":            {min: 0, max: math.Inf(1), integer: true, optional: true},
+	"minSamplesSplit":     {min: 0, max: math.Inf(1), integer: true, optional: true},
+	"minSamplesLeaf":      {min: 0, max: math.Inf(1), integer: true, optional: true},
+	"minImpurityDecrease": {min: 0, max: math.Inf(1), optional: true},
+	"maxLeafNodes":        {min: 0, max: math.Inf(1), integer: true, optional: true},
+	"maxBins":             {min: 0, max: math.Inf(1), integer: true, optional: true},
+	"ccpAlpha":            {min: 0, max: math.Inf(1), optional: true},
+	"laplace":             {min: 0, max: math.Inf(1), optional: true},
+	"mEstimate":           {min: 0, max: math.Inf(1), optional: true},
+}
+
+func criterionNames() string {
+	names := make([]string, len(decisiontree.Criteria))
+	for i, c := range decisiontree.Criteria {
+		names[i] = c.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+func withTreeParams(params map[string]param) map[string]param {
+	for name, p := range treeParams {
+		params[name] = p
+	}
+	return params
+}
+
+type algorithm struct {
+	// variants lists the accepted variants; the first is the default.
+	variants []string
+	params   map[string]param
+	// tree algorithms accept a split criterion.
+	tree bool
+}
+
+var algorithms = map[string]algorithm{
+	"decisiontree": {
+		variants: []string{"sequential", "concurrent"},
+		params:   withTreeParams(map[string]param{}),
+		tree:     true,
+	},
+	"randomforest": {
+		variants: []string{"parallel", "sequential", "concurrent"},
+		params: withTreeParams(map[string]param{
+			"numTrees":    {def: 10, min: 1, max: math.Inf(1), integer: true},
+			"subsetRatio": {def: 0.8, min: 0, max: 1, positive: true},
+			"numWorkers":  {def: 0, min: 0, max: math.Inf(1), integer: true},
+		}),
+		tree: true,
+	},
+	"extratrees": {
+		variants: []string{"parallel", "sequential"},
+		params: withTreeParams(map[string]param{
+			"numTrees":   {def: 10, min: 1, max: math.Inf(1), integer: true},
+			"numWorkers": {def: 0, min: 0, max: math.Inf(1), integer: true},
+		}),
+		tree: true,
+	},
+	"svm": {
+		variants: []string{"sequential", "concurrent"},
+		params: map[string]param{
+			"learningRate": {def: 0.001, min: 0, max: math.Inf(1), positive: true},
+			"lambda":       {def: 0.01, min: 0, max: math.Inf(1)},
+			"epochs":       {def: 100, min: 1, max: math.Inf(1), integer: true},
+		},
+	},
+	"ann": {
+		variants: []string{"sequential", "concurrent"},
+		params: map[string]param{
+			"hiddenSize":   {def: 8, min: 1, max: math.Inf(1), integer: true},
+			"learningRate": {def: 0.1, min: 0, max: math.Inf(1), positive: true},
+			"epochs":       {def: 50, min: 1, max: math.Inf(1), integer: true},
+		},
+	},
+}
+
+// Validate checks the experiment against the schema and reports every
+// problem it finds, not just the first.
+func (e *Experiment) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if e.Dataset.Path == "" {
+		fail("dataset.path is required")
+	}
+	if len([]rune(e.Dataset.Separator)) != 1 {
+		fail("dataset.separator must be a single character, got %q", e.Dataset.Separator)
+	}
+	if e.Dataset.Limit < 0 {
+		fail("dataset.limit must not be negative")
+	}
+
+	if e.Preprocessing.LabelColumn == "" {
+		fail("preprocessing.label_column is required")
+	}
+	for _, column := range e.Preprocessing.DropColumns {
+		if column == e.Preprocessing.LabelColumn {
+			fail("preprocessing.drop_columns must not contain the label column %q", column)
+		}
+	}
+	if columns := e.Preprocessing.CategoricalColumns; len(columns) > 0 {
+		seen := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			if seen[column] {
+				fail("preprocessing.categorical_columns repeats %q", column)
+			}
+			seen[column] = true
+			if column == e.Preprocessing.LabelColumn {
+				fail("preprocessing.categorical_columns must not contain the label column %q", column)
+			}
+			if contains(e.Preprocessing.DropColumns, column) {
+				fail("preprocessing.categorical_columns contains the dropped column %q", column)
+			}
+		}
+		if spec, ok := algorithms[e.Algorithm]; ok && (!spec.tree || e.recordForest()) {
+			fail("preprocessing.categorical_columns needs decisiontree, extratrees or a sequential or concurrent randomforest")
+		}
+	}
+	if m := e.Preprocessing.Missing; m != "drop" && m != "zero" {
+		fail("preprocessing.missing must be \"drop\" or \"zero\", got %q", m)
+	}
+
+	spec, ok := algorithms[e.Algorithm]
+	if !ok {
+		fail("algorithm must be one of %s, got %q", names(algorithms), e.Algorithm)
+	} else {
+		if !contains(spec.variants, e.Variant) {
+			fail(
+				"variant for %s must be one of %s, got %q",
+				e.Algorithm,
+				strings.Join(spec.variants, ", "),
+				e.Variant,
+			)
+		}
+		for name, value := range e.Hyperparameters {
+			p, known := spec.params[name]
+			switch {
+			case !known:
+				fail("hyperparameters.%s is not a parameter of %s", name, e.Algorithm)
+			case value < p.min || value > p.max || (p.positive && value == p.min):
+				fail("hyperparameters.%s = %g is out of range %s", name, value, p.rangeString())
+			case p.integer && value != math.Trunc(value):
+				fail("hyperparameters.%s must be an integer, got %g", name, value)
+			}
+		}
+		if e.Criterion != "" {
+			if !spec.tree {
+				fail("criterion only applies to tree algorithms, not %s", e.Algorithm)
+			} else if _, err := decisiontree.ParseCriterion(e.Criterion); err != nil {
+				fail("criterion must be one of %s, got %q", criterionNames(), e.Criterion)
+			}
+		}
+		if e.MaxFeatures != "" {
+			if !spec.tree {
+				fail("max_features only applies to tree algorithms, not %s", e.Algorithm)
+			} else if _, err := decisiontree.ParseMaxFeatures(string(e.MaxFeatures)); err != nil {
+				fail("max_features must be sqrt, log2, all, a fraction in (0, 1] or a positive count, got %q", e.MaxFeatures)
+			}
+		}
+		if e.Task == "regression" {
+			if !spec.tree || e.recordForest() {
+				fail("regression needs decisiontree, extratrees or a sequential or concurrent randomforest")
+			}
+			if e.Criterion != "" && e.Criterion != "mse" && e.Criterion != "mae" {
+				fail("regression needs the mse or mae criterion, got %q", e.Criterion)
+			}
+		}
+	}
+	if e.Outputs.Tree != "" && ok && !spec.tree {
+		fail("outputs.tree needs a tree algorithm, not %s", e.Algorithm)
+	}
+	if e.Outputs.Curve != "" {
+		if ok && e.Algorithm != "randomforest" && e.Algorithm != "extratrees" {
+			fail("outputs.curve needs randomforest or extratrees, not %s", e.Algorithm)
+		}
+		if len(e.Preprocessing.Classes) > 0 {
+			fail("outputs.curve only applies to binary classification and regression")
+		}
+	}
+	if classes := e.Preprocessing.Classes; len(classes) > 0 {
+		if len(classes) < 2 {
+			fail("preprocessing.classes needs at least two values")
+		}
+		seen := make(map[string]bool, len(classes))
+		for _, class := range classes {
+			if seen[class] {
+				fail("preprocessing.classes repeats %q", class)
+			}
+			seen[class] = true
+		}
+		if ok && !spec.tree {
+			fail("preprocessing.classes needs a tree algorithm, not %s", e.Algorithm)
+		}
+		if e.Task == "regression" {
+			fail("preprocessing.classes only applies to classification")
+		}
+		if c, err := decisiontree.ParseCriterion(e.Criterion); err == nil && !decisiontree.IsClassifier(c) {
+			fail("preprocessing.classes needs a classification criterion, got %q", e.Criterion)
+		}
+	}
+	if e.Task != "classification" && e.Task != "regression" {
+		fail("task must be \"classification\" or \"regression\", got %q", e.Task)
+	}
+
+	switch e.Evaluation.Protocol {
+	case "holdout":
+		if e.Evaluation.TrainRatio <= 0 || e.Evaluation.TrainRatio >= 1 {
+			fail("evaluation.train_ratio must be in (0, 1), got %g", e.Evaluation.TrainRatio)
+		}
+	case "cv":
+		if e.Evaluation.Folds < 2 {
+			fail("evaluation.folds must be at least 2, got %d", e.Evaluation.Folds)
+		}
+		if e.Outputs.Predictions != "" {
+			fail("outputs.predictions is only available with the holdout protocol")
+		}
+		if e.Outputs.Tree != "" {
+			fail("outputs.tree is only available with the holdout protocol")
+		}
+		if e.Outputs.Curve != "" {
+			fail("outputs.curve is only available with the holdout protocol")
+		}
+	default:
+		fail("evaluation.protocol must be \"holdout\" or \"cv\", got %q", e.Evaluation.Protocol)
+	}
+
+	return errors.Join(errs...)
+}
+
+// recordForest reports whether the experiment runs the parallel random
+// forest, whose trees work on text records.
+func (e *Experiment) recordForest() bool {
+	return e.Algorithm == "randomforest" && e.Variant == "parallel"
+}
+
+func (p param) rangeString() string {
+	open := "["
+	if p.positive {
+		open = "("
+	}
+	return fmt.Sprintf("%s%g, %g]", open, p.min, p.max)
+}
+
+func names(m map[string]algorithm) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package decisiontree
+
+import (
+	"math"
+	"sort"
+)
+
+// Categorical features hold category codes, float64 values compared only for
+// equality. A categorical node sends the rows whose category is in its
+// Categories to the left child and every other row to the right one.
+//
+// Trying every subset of k categories costs 2^(k-1) splits, so the categories
+// of a node are instead sorted by their target rate and only the k-1 splits
+// between consecutive categories are tried. For binary classification and
+// for regression with MSE the rate is the share of class 1 or the mean
+// target, and that order is known to contain the best subset. Multiclass
+// trees sort by the share of the node's most frequent class and MAE trees by
+// the median, which is a heuristic.
+//
+// Of the two sides of the chosen split, the one holding fewer training rows is
+// listed in Categories, so a category the node never saw in training, which
+// is in neither side, goes right, to the child that received more rows.
+
+// categorical reports whether feature is one of o.Categorical.
+func (o Options) categorical(feature int) bool {
+	for _, f := range o.Categorical {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// goesLeft reports whether a row whose value for the node's feature is value
+// goes to the left child.
+func (n *Node) goesLeft(value float64) bool {
+	if n.Categories != nil {
+		return hasCategory(n.Categories, value)
+	}
+	return value <= n.Threshold
+}
+
+// hasCategory reports whether value is in categories, which are sorted.
+func hasCategory(categories []float64, value float64) bool {
+	i := sort.SearchFloat64s(categories, value)
+	return i < len(categories) && categories[i] == value
+}
+
+// splitCategories partitions data into the rows whose feature value is in
+// categories and the rest.
+func splitCategories(data [][]float64, feature int, categories []float64) ([][]float64, [][]float64) {
+	var left, right [][]float64
+	for _, row := range data {
+		if hasCategory(categories, row[feature]) {
+			left = append(left, row)
+		} else {
+			right = append(right, row)
+		}
+	}
+	return left, right
+}
+
+// splitNode partitions data like node routes samples.
+func splitNode(data [][]float64, node *Node) ([][]float64, [][]float64) {
+	split, _ := partitionSplit(data, Split[float64]{
+		Feature:    node.Feature,
+		Threshold:  node.Threshold,
+		Categories: node.Categories,
+	})
+	return split.Left, split.Right
+}
+
+// findBestCategories returns the categories of the best split of data on the
+// categorical feature and its weighted impurity, or +Inf when no split leaves
+// minLeaf rows on both sides. Rows are weighted by rowWeight.
+func findBestCategories(
+	data [][]float64,
+	feature, minLeaf int,
+	criterion Criterion,
+	weighted bool,
+) ([]float64, float64) {
+	bins := make(map[float64]Stats)
+	for _, row := range data {
+		stats, ok := bins[row[feature]]
+		if !ok {
+			stats = criterion.NewStats()
+			bins[row[feature]] = stats
+		}
+		stats.AddWeighted(row[len(row)-1], rowWeight(row, weighted))
+	}
+	categories := make([]float64, 0, len(bins))
+	for category := range bins {
+		categories = append(categories, category)
+	}
+	sort.Float64s(categories)
+	stats := make([]Stats, len(categories))
+	for i, category := range categories {
+		stats[i] = bins[category]
+	}
+	return bestCategorySplit(categories, stats, criterion, minLeaf)
+}
+
+// bestCategorySplit sorts the non-empty categories by rate and returns the
+// side of the best split between consecutive categories that holds fewer
+// rows, sorted, with the split's weighted impurity. stats[i] summarizes the
+// rows of categories[i]. Ties keep the first split found, and categories of
+// equal rate are ordered by code, so the split is deterministic.
+func bestCategorySplit(
+	categories []float64,
+	stats []Stats,
+	criterion Criterion,
+	minLeaf int,
+) ([]float64, float64) {
+	total := criterion.NewStats()
+	var order []int
+	for i, s := range stats {
+		if s.Count() > 0 {
+			total.Merge(s)
+			order = append(order, i)
+		}
+	}
+	class := rateClass(total)
+	rates := make([]float64, len(stats))
+	for _, i := range order {
+		rates[i] = categoryRate(stats[i], class)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rates[order[a]] < rates[order[b]] })
+
+	left, right := criterion.NewStats(), total.Clone()
+	best, bestImpurity := -1, math.Inf(1)
+	bestLeftRows := 0
+	for k, i := range order[:max(0, len(order)-1)] {
+		left.Merge(stats[i])
+		right.Subtract(stats[i])
+		if left.Count() < minLeaf || right.Count() < minLeaf {
+			continue
+		}
+		impurity := weightedImpurity(left, right)
+		if impurity < bestImpurity {
+			best, bestImpurity, bestLeftRows = k, impurity, left.Count()
+		}
+	}
+	if best == -1 {
+		return nil, math.Inf(1)
+	}
+
+	side := order[:best+1]
+	if bestLeftRows > total.Count()-bestLeftRows {
+		side = order[best+1:]
+	}
+	chosen := make([]float64, len(side))
+	for j, i := range side {
+		chosen[j] = categories[i]
+	}
+	sort.Float64s(chosen)
+	return chosen, bestImpurity
+}
+
+// rateClass is the class whose share orders the categories of a
+// classification node: class 1 in binary trees and the most frequent class
+// of the node otherwise. Regression criteria ignore it.
+func rateClass(total Stats) int {
+	classes, ok := total.(*classStats)
+	if !ok || len(classes.counts) <= 2 {
+		return 1
+	}
+	return Argmax(classes.counts)
+}
+
+// categoryRate is the value categories are sorted by: the share of class in
+// classification, the mean target with MSE and the median with MAE.
+func categoryRate(stats Stats, class int) float64 {
+	switch s := stats.(type) {
+	case *classStats:
+		if class >= len(s.counts) {
+			return 0
+		}
+		return s.counts[class] / s.total
+	case *momentStats:
+		return s.mean
+	case *sortedStats:
+		return weightedMedian(s.labels, s.total)
+	}
+	return 0
+}
@@ -0,0 +1,166 @@
+package decisiontree
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// categoricalData returns rows of a category code in [0, categories), a
+// uniform noise feature and a 0/1 label. The label is 1 for a random half of
+// the categories, with noise flipping roughly noise*100% of the labels, so
+// no threshold on the codes separates the classes.
+func categoricalData(rows, categories int, noise float64, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	positive := rng.Perm(categories)[:categories/2]
+	isPositive := make(map[float64]bool)
+	for _, c := range positive {
+		isPositive[float64(c)] = true
+	}
+	data := make([][]float64, rows)
+	for r := range data {
+		category := float64(rng.Intn(categories))
+		label := 0.0
+		if isPositive[category] {
+			label = 1
+		}
+		if rng.Float64() < noise {
+			label = 1 - label
+		}
+		data[r] = []float64{category, rng.Float64(), label}
+	}
+	return data
+}
+
+func TestCategoricalSplitBeatsThresholds(t *testing.T) {
+	data := categoricalData(2000, 20, 0.05, 1)
+	categorical := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, Categorical: []int{0}})
+	categorical.Train(data)
+	numeric := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1})
+	numeric.Train(data)
+
+	if categorical.root.Categories == nil || categorical.root.Feature != 0 {
+		t.Fatalf("root splits feature %d on categories %v", categorical.root.Feature, categorical.root.Categories)
+	}
+	got := harness.Accuracy(harness.Predictions(categorical, data), data, 0.5)
+	baseline := harness.Accuracy(harness.Predictions(numeric, data), data, 0.5)
+	if got < 0.9 || got <= baseline {
+		t.Errorf("categorical stump accuracy %.3f, threshold stump %.3f", got, baseline)
+	}
+}
+
+// TestBestCategorySplitIsOptimal checks the sorted scan against every subset
+// of the categories for the criteria where the order is known to hold the
+// best split.
+func TestBestCategorySplitIsOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, criterion := range []Criterion{Gini, Entropy, MSE} {
+		for trial := 0; trial < 20; trial++ {
+			k := 2 + rng.Intn(6)
+			categories := make([]float64, k)
+			stats := make([]Stats, k)
+			for c := range stats {
+				categories[c] = float64(c)
+				stats[c] = criterion.NewStats()
+				for n := 1 + rng.Intn(10); n > 0; n-- {
+					label := float64(rng.Intn(2))
+					if !IsClassifier(criterion) {
+						label = rng.NormFloat64()
+					}
+					stats[c].AddWeighted(label, 1+rng.Float64())
+				}
+			}
+
+			chosen, impurity := bestCategorySplit(categories, stats, criterion, 0)
+			best := math.Inf(1)
+			for mask := 1; mask < 1<<k-1; mask++ {
+				left, right := criterion.NewStats(), criterion.NewStats()
+				for c := range stats {
+					if mask&(1<<c) != 0 {
+						left.Merge(stats[c])
+					} else {
+						right.Merge(stats[c])
+					}
+				}
+				best = math.Min(best, weightedImpurity(left, right))
+			}
+			if !harness.WithinTolerance(impurity, best, 1e-9) {
+				t.Errorf("%s trial %d: split %v has impurity %g, best subset %g",
+					criterion, trial, chosen, impurity, best)
+			}
+		}
+	}
+}
+
+func TestCategoricalTreesAgree(t *testing.T) {
+	data := categoricalData(3000, 30, 0.1, 2)
+	configs := map[string]Options{
+		"classification": {MaxDepth: 6, Categorical: []int{0}},
+		"min leaf":       {MaxDepth: 6, MinSamplesLeaf: 20, Categorical: []int{0}},
+		"regression":     {MaxDepth: 5, Criterion: MSE, Categorical: []int{0}},
+	}
+	for name, opts := range configs {
+		sequential := NewSequentialDecisionTreeWithOptions(opts)
+		sequential.Train(data)
+		for _, threshold := range []int{0, 100, DefaultSubtreeThreshold} {
+			t.Run(fmt.Sprintf("%s/threshold=%d", name, threshold), func(t *testing.T) {
+				concurrent := NewConcurrentDecisionTreeWithOptions(opts)
+				concurrent.SetSubtreeThreshold(threshold)
+				concurrent.Train(data)
+				if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+		// Categorical features get a bin per category, so histograms find
+		// the exact splits as long as the numeric feature fits its bins.
+		t.Run(name+"/histogram", func(t *testing.T) {
+			histogramOpts := opts
+			histogramOpts.MaxBins = len(data)
+			histogram := NewSequentialDecisionTreeWithOptions(histogramOpts)
+			histogram.Train(data)
+			if err := compareNodes(sequential.root, histogram.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUnseenCategoriesGoToTheLargerChild(t *testing.T) {
+	data := categoricalData(1000, 10, 0, 4)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, Categorical: []int{0}})
+	tree.Train(data)
+
+	root := tree.root
+	if root.Categories == nil {
+		t.Fatal("root is not a categorical split")
+	}
+	if root.Left.Samples > root.Right.Samples {
+		t.Errorf("left child has %d rows, right %d", root.Left.Samples, root.Right.Samples)
+	}
+	for _, unseen := range []float64{-1, 10, 3.5, math.NaN()} {
+		if got := tree.Predict([]float64{unseen, 0.5}); got != root.Right.Prediction {
+			t.Errorf("category %g predicts %g, want the right child's %g", unseen, got, root.Right.Prediction)
+		}
+	}
+}
+
+func TestExportCategoricalSplit(t *testing.T) {
+	data := categoricalData(500, 6, 0, 5)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, Categorical: []int{0}})
+	tree.Train(data)
+
+	var buf bytes.Buffer
+	if err := tree.Export(&buf, "rules", ExportOptions{FeatureNames: []string{"color"}}); err != nil {
+		t.Fatal(err)
+	}
+	rules := buf.String()
+	if !strings.Contains(rules, "if color in {") || !strings.Contains(rules, "if color not in {") {
+		t.Errorf("rules do not test category sets:\n%s", rules)
+	}
+}
@@ -0,0 +1,196 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+)
+
+// IsClassifier reports whether criterion is a classification criterion, whose
+// trees expect class labels 0, 1, ..., K-1 and keep class distributions in
+// their leaves. A nil criterion is Gini, as in Options.
+func IsClassifier(criterion Criterion) bool {
+	if criterion == nil {
+		return true
+	}
+	_, ok := criterion.(*classCriterion)
+	return ok
+}
+
+// maxClasses bounds the labels CheckLabels accepts, since every node keeps
+// one count per class up to the largest label.
+const maxClasses = 1 << 16
+
+// CheckLabels returns an error unless the last column of every row of data
+// is a class label 0, 1, ..., K-1, a whole number below 65536, when criterion
+// is a classification criterion. Regression labels are not checked.
+func CheckLabels(criterion Criterion, data [][]float64) error {
+	if !IsClassifier(criterion) {
+		return nil
+	}
+	for i, row := range data {
+		label := row[len(row)-1]
+		if !(label >= 0 && label < maxClasses) || label != math.Trunc(label) {
+			return fmt.Errorf("decisiontree: label %g of row %d is not a class 0, 1, ..., %d", label, i, maxClasses-1)
+		}
+	}
+	return nil
+}
+
+// ClassOf is the class of a classification label that CheckLabels accepts.
+func ClassOf(label float64) int {
+	return int(label)
+}
+
+// NumClasses is one more than the largest class in the last column of data,
+// the K of labels 0, 1, ..., K-1.
+func NumClasses(data [][]float64) int {
+	k := 0
+	for _, row := range data {
+		k = max(k, ClassOf(row[len(row)-1])+1)
+	}
+	return k
+}
+
+// classCounts returns the weight of data, by rowWeight, in each of
+// numClasses classes.
+func classCounts(data [][]float64, numClasses int, weighted bool) []float64 {
+	counts := make([]float64, numClasses)
+	for _, row := range data {
+		counts[ClassOf(row[len(row)-1])] += rowWeight(row, weighted)
+	}
+	return counts
+}
+
+// shares returns counts divided by their sum, or an even spread when they sum
+// to 0. It returns nil for nil counts.
+func shares(counts []float64) []float64 {
+	if counts == nil {
+		return nil
+	}
+	distribution := make([]float64, len(counts))
+	total := 0.0
+	for _, count := range counts {
+		total += count
+	}
+	for class, count := range counts {
+		if total == 0 {
+			distribution[class] = 1 / float64(len(counts))
+		} else {
+			distribution[class] = count / total
+		}
+	}
+	return distribution
+}
+
+// Smoothing pulls the class probabilities of leaves with few training rows
+// towards a prior. A leaf with counts n_k summing to N predicts class k of K
+// with probability
+//
+//	(n_k + Laplace + M*prior_k) / (N + K*Laplace + M)
+//
+// where prior is the class distribution of the whole training set. Laplace
+// alone is additive smoothing, M alone the m-estimate, and the zero value
+// keeps the leaves' own distributions.
+type Smoothing struct {
+	// Laplace is the pseudo-count added to every class.
+	Laplace float64
+	// M is the number of virtual rows drawn from the prior.
+	M float64
+}
+
+// Probabilities returns the smoothed class probabilities of node, a node of
+// the tree rooted at root. It returns nil for regression trees.
+func (s Smoothing) Probabilities(node, root *Node) []float64 {
+	if node.Counts == nil || (s.Laplace <= 0 && s.M <= 0) {
+		return node.Distribution
+	}
+	laplace, m := max(s.Laplace, 0), max(s.M, 0)
+	total := 0.0
+	for _, count := range node.Counts {
+		total += count
+	}
+	denominator := total + float64(len(node.Counts))*laplace + m
+	probabilities := make([]float64, len(node.Counts))
+	for class, count := range node.Counts {
+		probabilities[class] = (count + laplace + m*root.Distribution[class]) / denominator
+	}
+	return probabilities
+}
+
+// Argmax returns the class with the highest probability, the lowest one on
+// ties.
+func Argmax(probabilities []float64) int {
+	best := 0
+	for class, p := range probabilities {
+		if p > probabilities[best] {
+			best = class
+		}
+	}
+	return best
+}
+
+// numberNodes sets the ID of every node of the tree rooted at root.
+func numberNodes(root *Node) {
+	id := 0
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if node == nil {
+			return
+		}
+		node.ID = id
+		id++
+		visit(node.Left)
+		visit(node.Right)
+	}
+	visit(root)
+}
+
+// leafOf returns the leaf sample falls into.
+func leafOf(node *Node, sample []float64) *Node {
+	for node.Left != nil || node.Right != nil {
+		if node.goesLeft(sample[node.Feature]) {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return node
+}
+
+// PredictProba returns the class distribution of the leaf sample falls into,
+// smoothed by Options.Smoothing, with one entry per class seen in training.
+// It returns nil for regression trees.
+func (dt *SequentialDecisionTree) PredictProba(sample []float64) []float64 {
+	return dt.opts.Smoothing.Probabilities(leafOf(dt.root, sample), dt.root)
+}
+
+// PredictLeaf returns the ID of the leaf sample falls into. Leaf IDs of
+// several trees make a sparse encoding of samples for other models.
+func (dt *SequentialDecisionTree) PredictLeaf(sample []float64) int {
+	return leafOf(dt.root, sample).ID
+}
+
+// PredictClass returns the most frequent class of the leaf sample falls into,
+// which smoothing does not change.
+func (dt *SequentialDecisionTree) PredictClass(sample []float64) int {
+	return Argmax(leafOf(dt.root, sample).Distribution)
+}
+
+// PredictProba returns the class distribution of the leaf sample falls into,
+// smoothed by Options.Smoothing, with one entry per class seen in training.
+// It returns nil for regression trees.
+func (dt *ConcurrentDecisionTree) PredictProba(sample []float64) []float64 {
+	return dt.opts.Smoothing.Probabilities(leafOf(dt.root, sample), dt.root)
+}
+
+// PredictLeaf returns the ID of the leaf sample falls into. Leaf IDs of
+// several trees make a sparse encoding of samples for other models.
+func (dt *ConcurrentDecisionTree) PredictLeaf(sample []float64) int {
+	return leafOf(dt.root, sample).ID
+}
+
+// PredictClass returns the most frequent class of the leaf sample falls into,
+// which smoothing does not change.
+func (dt *ConcurrentDecisionTree) PredictClass(sample []float64) int {
+	return Argmax(leafOf(dt.root, sample).Distribution)
+}
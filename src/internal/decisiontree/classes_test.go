@@ -0,0 +1,216 @@
+package decisiontree
+
+import (
+	"math"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestMulticlassTreesAgree(t *testing.T) {
+	data := harness.Multiclass(600, 4, 4, 0.1, 3)
+
+	for name, opts := range map[string]Options{
+		"depth first": {MaxDepth: 6},
+		"best first":  {MaxLeafNodes: 12},
+		"histogram":   {MaxDepth: 6, MaxBins: 32},
+		"entropy":     {MaxDepth: 6, Criterion: Entropy},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sequential := NewSequentialDecisionTreeWithOptions(opts)
+			sequential.Train(data)
+			concurrent := NewConcurrentDecisionTreeWithOptions(opts)
+			concurrent.Train(data)
+
+			if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPredictProbaIsADistribution(t *testing.T) {
+	data := harness.Multiclass(1000, 3, 3, 0.05, 8)
+	train, test := data[:700], data[700:]
+
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 8})
+	tree.Train(train)
+
+	correct := 0
+	for _, row := range test {
+		proba := tree.PredictProba(row[:len(row)-1])
+		if len(proba) != 3 {
+			t.Fatalf("%d probabilities, want 3", len(proba))
+		}
+		sum := 0.0
+		for _, p := range proba {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Fatalf("probabilities %v sum to %g", proba, sum)
+		}
+		if tree.PredictClass(row[:len(row)-1]) == int(row[len(row)-1]) {
+			correct++
+		}
+	}
+	if accuracy := float64(correct) / float64(len(test)); accuracy < 0.75 {
+		t.Errorf("accuracy %.3f on three classes", accuracy)
+	}
+}
+
+func TestBinaryProbaMatchesPrediction(t *testing.T) {
+	data := harness.Classification(400, 4, 0.1, 17)
+	tree := NewConcurrentDecisionTree()
+	tree.Train(data)
+
+	for _, row := range data {
+		sample := row[:len(row)-1]
+		if p := tree.PredictProba(sample)[1]; !harness.WithinTolerance(p, tree.Predict(sample), 1e-12) {
+			t.Fatalf("P(1) = %g, Predict = %g", p, tree.Predict(sample))
+		}
+	}
+
+	regression := NewSequentialRegressionTree()
+	regression.Train(harness.Regression(200, 3, 0.1, 2))
+	if proba := regression.PredictProba([]float64{0, 0, 0}); proba != nil {
+		t.Errorf("regression tree returned probabilities %v", proba)
+	}
+}
+
+func TestLeavesKeepClassCounts(t *testing.T) {
+	data := harness.Multiclass(500, 3, 3, 0.1, 4)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 5})
+	tree.Train(data)
+
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		sum := 0.0
+		for _, count := range node.Counts {
+			sum += count
+		}
+		if len(node.Counts) != 3 || sum != float64(node.Samples) {
+			t.Fatalf("node %d has counts %v for %d samples", node.ID, node.Counts, node.Samples)
+		}
+		// Empty nodes, which splits without MinSamplesLeaf may create, spread
+		// their distribution evenly.
+		for class, count := range node.Counts {
+			if sum > 0 && node.Distribution[class] != count/sum {
+				t.Fatalf("node %d has counts %v and distribution %v", node.ID, node.Counts, node.Distribution)
+			}
+		}
+		if !isLeaf(node) {
+			visit(node.Left)
+			visit(node.Right)
+		}
+	}
+	visit(tree.root)
+}
+
+func TestSmoothedProbabilities(t *testing.T) {
+	data := harness.Classification(300, 3, 0.2, 6)
+	plain := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6})
+	plain.Train(data)
+	laplace := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6, Smoothing: Smoothing{Laplace: 1}})
+	laplace.Train(data)
+	prior := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6, Smoothing: Smoothing{M: 1e9}})
+	prior.Train(data)
+
+	for _, row := range data {
+		sample := row[:len(row)-1]
+		leaf := leafOf(laplace.root, sample)
+		n := leaf.Counts[0] + leaf.Counts[1]
+		proba := laplace.PredictProba(sample)
+		for class, count := range leaf.Counts {
+			if want := (count + 1) / (n + 2); !harness.WithinTolerance(proba[class], want, 1e-12) {
+				t.Fatalf("Laplace P(%d) = %g with counts %v, want %g", class, proba[class], leaf.Counts, want)
+			}
+		}
+		if got := prior.PredictProba(sample); harness.MaxAbsDiff(got, prior.root.Distribution) > 1e-6 {
+			t.Fatalf("a large m gives %v, prior %v", got, prior.root.Distribution)
+		}
+		if laplace.PredictClass(sample) != plain.PredictClass(sample) {
+			t.Fatal("smoothing changed the predicted class")
+		}
+	}
+}
+
+func TestPredictLeafNumbersNodes(t *testing.T) {
+	data := harness.Classification(800, 4, 0.1, 9)
+	tree := NewConcurrentDecisionTreeWithOptions(Options{MaxDepth: 7})
+	tree.Train(data)
+	tree.Prune(0.002)
+
+	// IDs follow a depth-first walk that visits a node before its subtrees.
+	nodes := map[int]*Node{}
+	next := 0
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if node.ID != next {
+			t.Fatalf("node with ID %d visited in position %d", node.ID, next)
+		}
+		nodes[node.ID] = node
+		next++
+		if !isLeaf(node) {
+			visit(node.Left)
+			visit(node.Right)
+		}
+	}
+	visit(tree.root)
+
+	rows := map[int]int{}
+	for _, row := range data {
+		id := tree.PredictLeaf(row[:len(row)-1])
+		if !isLeaf(nodes[id]) {
+			t.Fatalf("PredictLeaf returned internal node %d", id)
+		}
+		rows[id]++
+	}
+	for id, n := range rows {
+		if n != nodes[id].Samples {
+			t.Errorf("leaf %d holds %d training rows, recorded %d", id, n, nodes[id].Samples)
+		}
+	}
+}
+
+func TestRejectsInvalidLabels(t *testing.T) {
+	data := harness.Classification(200, 4, 0.1, 12)
+	for _, label := range []float64{-1, 0.5, 2.5, 1e9, math.NaN(), math.Inf(1)} {
+		bad := append([][]float64(nil), data...)
+		bad[7] = append([]float64(nil), data[7]...)
+		bad[7][len(bad[7])-1] = label
+
+		for name, tree := range map[string]interface {
+			Train(data [][]float64)
+			TrainWeighted(data [][]float64, weights []float64) error
+			PredictClass(sample []float64) int
+		}{
+			"sequential": NewSequentialDecisionTree(),
+			"concurrent": NewConcurrentDecisionTree(),
+		} {
+			tree.Train(data)
+			before := tree.PredictClass(data[0][:len(data[0])-1])
+			if err := tree.TrainWeighted(bad, nil); err == nil {
+				t.Errorf("%s: label %g accepted", name, label)
+			}
+			if err := tree.TrainWeighted(bad, integerWeights(len(bad), 1)); err == nil {
+				t.Errorf("%s: label %g accepted with weights", name, label)
+			}
+			if got := tree.PredictClass(data[0][:len(data[0])-1]); got != before {
+				t.Errorf("%s: rejected labels changed the tree", name)
+			}
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: Train accepted label %g", name, label)
+					}
+				}()
+				tree.Train(bad)
+			}()
+		}
+	}
+
+	regression := NewSequentialRegressionTree()
+	if err := regression.TrainWeighted(harness.SignedLabels(data), nil); err != nil {
+		t.Errorf("regression tree rejected labels: %v", err)
+	}
+}
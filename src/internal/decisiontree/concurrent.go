@@ -2,74 +2,200 @@ package decisiontree
 
 import (
 	"math"
+	"sort"
 	"sync"
+
+	"concurrente/internal/workpool"
 )
 
 type ConcurrentDecisionTree struct {
 	root *Node
+	opts Options
+	// subtreeThreshold is the fewest rows a node needs for its subtrees to
+	// be grown as parallel tasks and its features searched in parallel.
+	subtreeThreshold int
+	// pool, if set, runs the subtree tasks in place of a pool of Train's own.
+	pool *workpool.Pool
+	// numClasses is the number of classes seen by Train, or 0 for regression
+	// trees.
+	numClasses int
+	// numFeatures is the number of features of the data Train was given.
+	numFeatures int
+	// weighted says the rows being trained on carry a sample weight before
+	// the label.
+	weighted bool
 }
 
 func NewConcurrentDecisionTree() *ConcurrentDecisionTree {
-	return &ConcurrentDecisionTree{}
+	return NewConcurrentDecisionTreeWithOptions(DefaultOptions())
+}
+
+// NewConcurrentRegressionTree returns a tree that predicts a continuous
+// target by minimizing the MSE of its leaves.
+func NewConcurrentRegressionTree() *ConcurrentDecisionTree {
+	return NewConcurrentDecisionTreeWithOptions(DefaultRegressionOptions())
+}
+
+func NewConcurrentDecisionTreeWithOptions(opts Options) *ConcurrentDecisionTree {
+	return &ConcurrentDecisionTree{opts: opts, subtreeThreshold: DefaultSubtreeThreshold}
 }
 
+// DefaultSubtreeThreshold is the node size below which ConcurrentDecisionTree
+// stops creating parallel work.
+const DefaultSubtreeThreshold = 2048
+
+// SetSubtreeThreshold sets the fewest rows a node needs for its two subtrees
+// to be grown in parallel on a work-stealing pool. Smaller nodes grow their
+// whole subtree sequentially, searching one feature after another. A value
+// of 0 or less disables subtree tasks: children are grown one after the
+// other and every node searches its features in parallel.
+func (dt *ConcurrentDecisionTree) SetSubtreeThreshold(rows int) {
+	dt.subtreeThreshold = rows
+}
+
+// SetPool makes Train grow subtree tasks on pool, which the caller closes,
+// instead of starting and closing a pool of GOMAXPROCS workers of its own.
+// Trees trained at the same time, like those of a forest, can share one
+// pool so they do not start a set of workers each.
+func (dt *ConcurrentDecisionTree) SetPool(pool *workpool.Pool) {
+	dt.pool = pool
+}
+
+// Train grows the tree on data, whose last column is the label. It panics
+// with the error TrainWeighted would return for labels that are not classes.
 func (dt *ConcurrentDecisionTree) Train(data [][]float64) {
+	if err := dt.TrainWeighted(data, nil); err != nil {
+		panic(err)
+	}
+}
+
+// TrainWeighted is Train with weights[i], a positive number, weighing row i
+// in impurities, leaf predictions, class distributions, node weights and
+// the pruning and importances built on them as if it appeared weights[i]
+// times. The row-count stopping rules still count rows. Nil weights train
+// like Train. It returns an error, and leaves the tree as it was, unless there is
+// one positive, finite weight per row and, for classification trees, the
+// labels pass CheckLabels.
+func (dt *ConcurrentDecisionTree) TrainWeighted(data [][]float64, weights []float64) error {
+	if err := CheckLabels(dt.opts.criterion(), data); err != nil {
+		return err
+	}
+	if weights == nil {
+		dt.train(data, false)
+		return nil
+	}
+	if err := checkWeights(weights, len(data)); err != nil {
+		return err
+	}
+	dt.train(withWeights(data, weights), true)
+	return nil
+}
+
+func (dt *ConcurrentDecisionTree) train(data [][]float64, weighted bool) {
+	dt.weighted = weighted
+	dt.numClasses = 0
+	dt.numFeatures = 0
+	if len(data) > 0 {
+		dt.numFeatures = featureCount(data[0], weighted)
+	}
+	if IsClassifier(dt.opts.criterion()) {
+		dt.numClasses = NumClasses(data)
+	}
 	// fmt.Println("Starting concurrent decision tree training...")
 	// startTime := time.Now()
-	dt.root = dt.buildTree(data, 0, 5)
+	var splitter Splitter[float64] = concurrentSplitter{dt}
+	if dt.opts.MaxBins > 0 && !dt.opts.RandomThresholds && len(data) > 0 {
+		cfg := histogramConfig(dt.numFeatures, dt.opts, true, weighted)
+		splitter = NewHistogramSplitter(data, splitter, cfg)
+	}
+	if dt.subtreeThreshold > 0 && dt.pool != nil {
+		dt.root = GrowParallel(data, splitter, dt.opts, dt.pool, dt.subtreeThreshold)
+	} else if dt.subtreeThreshold > 0 {
+		pool := workpool.New(0)
+		dt.root = GrowParallel(data, splitter, dt.opts, pool, dt.subtreeThreshold)
+		pool.Close()
+	} else {
+		dt.root = Grow(data, splitter, dt.opts)
+	}
+	if dt.opts.CCPAlpha > 0 {
+		dt.root = PruneCostComplexity(dt.root, dt.opts.CCPAlpha)
+	}
 	// fmt.Printf("Concurrent training completed in %v\n", time.Since(startTime))
 }
 
-func (dt *ConcurrentDecisionTree) buildTree(data [][]float64, depth, maxDepth int) *Node {
-	if len(data) == 0 || depth >= maxDepth {
-		return &Node{Prediction: calculatePrediction(data)}
+// concurrentSplitter searches every feature in its own goroutine.
+type concurrentSplitter struct {
+	dt *ConcurrentDecisionTree
+}
+
+func (s concurrentSplitter) Impurity(data [][]float64) float64 {
+	return nodeImpurity(data, s.dt.opts.criterion(), s.dt.weighted)
+}
+
+func (s concurrentSplitter) LeafValue(data [][]float64) float64 {
+	if s.dt.opts.criterion() == MAE {
+		return medianLabel(data, s.dt.weighted)
 	}
+	return calculatePrediction(data, s.dt.weighted)
+}
 
-	// splitStart := time.Now()
-	bestFeature, bestThreshold := dt.findBestSplitConcurrent(data)
-	// fmt.Printf("Depth %d: Found best split in %v\n", depth, time.Since(splitStart))
+// LeafCounts returns nil for regression trees.
+func (s concurrentSplitter) LeafCounts(data [][]float64) []float64 {
+	if s.dt.numClasses == 0 {
+		return nil
+	}
+	return classCounts(data, s.dt.numClasses, s.dt.weighted)
+}
+
+func (s concurrentSplitter) Weight(data [][]float64) float64 {
+	return totalWeight(data, s.dt.weighted)
+}
+
+func (s concurrentSplitter) BestSplit(data [][]float64) (Split[float64], bool) {
+	return s.BestSplitAmong(data, nil, nil)
+}
+
+func (s concurrentSplitter) NumCandidates() int {
+	return s.dt.numFeatures
+}
 
-	if bestFeature == -1 {
-		return &Node{Prediction: calculatePrediction(data)}
+// BestSplitAmong searches features in place of every feature, or every
+// feature when features is nil.
+func (s concurrentSplitter) BestSplitAmong(data [][]float64, _ any, features []int) (Split[float64], bool) {
+	// splitStart := time.Now()
+	var best Split[float64]
+	if len(data) < s.dt.subtreeThreshold {
+		best = findBestSplitAmong(data, features, s.dt.opts, s.dt.weighted)
+	} else {
+		best = s.dt.findBestSplitConcurrent(data, features)
 	}
+	// fmt.Printf("Found best split in %v\n", time.Since(splitStart))
 
-	leftData, rightData := splitData(data, bestFeature, bestThreshold)
+	split, ok := partitionSplit(data, best)
 	/*
 		fmt.Printf(
-			"Depth %d: Split data into %d left and %d right\n",
-			depth,
-			len(leftData),
-			len(rightData),
+			"Split data into %d left and %d right\n",
+			len(split.Left),
+			len(split.Right),
 		)
 	*/
-
-	return &Node{
-		Feature:   bestFeature,
-		Threshold: bestThreshold,
-		Left:      dt.buildTree(leftData, depth+1, maxDepth),
-		Right:     dt.buildTree(rightData, depth+1, maxDepth),
-	}
+	return split, ok
 }
 
-func (dt *ConcurrentDecisionTree) findBestSplitConcurrent(data [][]float64) (int, float64) {
-	numFeatures := len(data[0]) - 1
-	results := make(chan struct {
-		feature   int
-		threshold float64
-		gini      float64
-	}, numFeatures)
+// findBestSplitConcurrent searches each of features, or every feature when
+// features is nil, in its own goroutine.
+func (dt *ConcurrentDecisionTree) findBestSplitConcurrent(data [][]float64, features []int) Split[float64] {
+	if features == nil {
+		features = allFeatureIndices(featureCount(data[0], dt.weighted))
+	}
+	results := make(chan Split[float64], len(features))
 
 	var wg sync.WaitGroup
-	for feature := 0; feature < numFeatures; feature++ {
+	for _, feature := range features {
 		wg.Add(1)
 		go func(f int) {
 			defer wg.Done()
-			bestThreshold, bestGini := findBestThresholdForFeature(data, f)
-			results <- struct {
-				feature   int
-				threshold float64
-				gini      float64
-			}{f, bestThreshold, bestGini}
+			results <- findFeatureSplit(data, f, dt.opts, dt.weighted)
 		}(feature)
 	}
 
@@ -78,35 +204,18 @@ func (dt *ConcurrentDecisionTree) findBestSplitConcurrent(data [][]float64) (int
 		close(results)
 	}()
 
-	bestFeature := -1
-	bestThreshold := 0.0
-	bestGini := math.Inf(1)
+	best := Split[float64]{Feature: -1, Impurity: math.Inf(1)}
 
+	// Results arrive in completion order; ties go to the lowest feature so the
+	// tree matches the one SequentialDecisionTree builds.
 	for result := range results {
-		if result.gini < bestGini {
-			bestFeature = result.feature
-			bestThreshold = result.threshold
-			bestGini = result.gini
+		if result.Impurity < best.Impurity ||
+			(result.Impurity == best.Impurity && result.Feature < best.Feature) {
+			best = result
 		}
 	}
 
-	return bestFeature, bestThreshold
-}
-
-func findBestThresholdForFeature(data [][]float64, feature int) (float64, float64) {
-	thresholds := getUniqueValues(data, feature)
-	bestThreshold := 0.0
-	bestGini := math.Inf(1)
-
-	for _, threshold := range thresholds {
-		gini := calculateGiniIndex(data, feature, threshold)
-		if gini < bestGini {
-			bestGini = gini
-			bestThreshold = threshold
-		}
-	}
-
-	return bestThreshold, bestGini
+	return best
 }
 
 func (dt *ConcurrentDecisionTree) Predict(sample []float64) float64 {
@@ -116,41 +225,6 @@ func (dt *ConcurrentDecisionTree) Predict(sample []float64) float64 {
 // The following functions can be shared between sequential and concurrent versions
 // You may want to move them to a common file if they're identical
 
-func getUniqueValues(data [][]float64, feature int) []float64 {
-	uniqueMap := make(map[float64]bool)
-	for _, row := range data {
-		uniqueMap[row[feature]] = true
-	}
-	unique := make([]float64, 0, len(uniqueMap))
-	for value := range uniqueMap {
-		unique = append(unique, value)
-	}
-	return unique
-}
-
-func calculateGiniIndex(data [][]float64, feature int, threshold float64) float64 {
-	leftData, rightData := splitData(data, feature, threshold)
-	leftGini := calculateGini(leftData)
-	rightGini := calculateGini(rightData)
-	totalSize := float64(len(data))
-	weightedGini := (float64(len(leftData))/totalSize)*leftGini + (float64(len(rightData))/totalSize)*rightGini
-	return weightedGini
-}
-
-func calculateGini(data [][]float64) float64 {
-	if len(data) == 0 {
-		return 0
-	}
-	positiveCount := 0
-	for _, row := range data {
-		if row[len(row)-1] == 1 {
-			positiveCount++
-		}
-	}
-	p := float64(positiveCount) / float64(len(data))
-	return 2 * p * (1 - p)
-}
-
 func splitData(data [][]float64, feature int, threshold float64) ([][]float64, [][]float64) {
 	var left, right [][]float64
 	for _, row := range data {
@@ -163,22 +237,42 @@ func splitData(data [][]float64, feature int, threshold float64) ([][]float64, [
 	return left, right
 }
 
-func calculatePrediction(data [][]float64) float64 {
+// calculatePrediction is the mean of the labels in the last column of data,
+// weighted by rowWeight.
+func calculatePrediction(data [][]float64, weighted bool) float64 {
 	if len(data) == 0 {
 		return 0
 	}
-	sum := 0.0
+	sum, total := 0.0, 0.0
 	for _, row := range data {
-		sum += row[len(row)-1]
+		weight := rowWeight(row, weighted)
+		sum += weight * row[len(row)-1]
+		total += weight
+	}
+	return sum / total
+}
+
+// medianLabel is the median of the labels in the last column of data,
+// weighted by rowWeight, the value that minimizes a leaf's MAE.
+func medianLabel(data [][]float64, weighted bool) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	labels := make([]weightedLabel, len(data))
+	total := 0.0
+	for i, row := range data {
+		labels[i] = weightedLabel{row[len(row)-1], rowWeight(row, weighted)}
+		total += labels[i].weight
 	}
-	return sum / float64(len(data))
+	sort.Slice(labels, func(i, j int) bool { return labels[i].label < labels[j].label })
+	return weightedMedian(labels, total)
 }
 
 func predictNode(node *Node, sample []float64) float64 {
 	if node.Left == nil && node.Right == nil {
 		return node.Prediction
 	}
-	if sample[node.Feature] <= node.Threshold {
+	if node.goesLeft(sample[node.Feature]) {
 		return predictNode(node.Left, sample)
 	}
 	return predictNode(node.Right, sample)
@@ -0,0 +1,354 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Criterion measures how mixed the labels of a node are; splits are chosen to
+// minimize the size-weighted criterion of the children. Classification
+// criteria expect class labels 0, 1, ..., K-1.
+type Criterion interface {
+	// NewStats returns empty statistics for the labels of a node.
+	NewStats() Stats
+	String() string
+}
+
+// Stats summarizes the labels of a set of rows so the criterion can be
+// updated as rows move from one side of a candidate split to the other.
+type Stats interface {
+	Add(label float64)
+	Remove(label float64)
+	// AddWeighted adds a label that counts weight times and RemoveWeighted
+	// removes one added with the same weight. Add and Remove use weight 1.
+	AddWeighted(label, weight float64)
+	RemoveWeighted(label, weight float64)
+	// Merge adds the labels summarized by other, and Subtract removes them.
+	// other must come from the same criterion.
+	Merge(other Stats)
+	Subtract(other Stats)
+	Clone() Stats
+	// Count is the number of labels and Weight their total weight.
+	Count() int
+	Weight() float64
+	Impurity() float64
+}
+
+var (
+	// Gini is the Gini impurity, 1 - sum of squared class proportions.
+	Gini Criterion = &classCriterion{"gini", gini}
+	// Entropy is the Shannon entropy of the class proportions in bits, so
+	// minimizing it maximizes information gain.
+	Entropy Criterion = &classCriterion{"entropy", entropy}
+	// LogLoss is the cross-entropy, in nats, of predicting each row with the
+	// node's class proportions.
+	LogLoss Criterion = &classCriterion{"log_loss", logLoss}
+	// MSE is the variance of the labels around their mean.
+	MSE Criterion = mseCriterion{}
+	// MAE is the mean absolute deviation of the labels from their median. It
+	// keeps every label, so it is much slower than the other criteria.
+	MAE Criterion = maeCriterion{}
+)
+
+// Criteria lists every criterion by name.
+var Criteria = []Criterion{Gini, Entropy, LogLoss, MSE, MAE}
+
+// ParseCriterion returns the criterion called name.
+func ParseCriterion(name string) (Criterion, error) {
+	for _, c := range Criteria {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("decisiontree: unknown criterion %q", name)
+}
+
+// nodeImpurity is the criterion of the labels in the last column of data,
+// weighted by rowWeight.
+func nodeImpurity(data [][]float64, criterion Criterion, weighted bool) float64 {
+	stats := criterion.NewStats()
+	for _, row := range data {
+		stats.AddWeighted(row[len(row)-1], rowWeight(row, weighted))
+	}
+	return stats.Impurity()
+}
+
+// weightedImpurity is the size-weighted impurity of two children, where a
+// child's size is the total weight of its labels.
+func weightedImpurity(left, right Stats) float64 {
+	totalSize := left.Weight() + right.Weight()
+	return (left.Weight()/totalSize)*left.Impurity() +
+		(right.Weight()/totalSize)*right.Impurity()
+}
+
+type classCriterion struct {
+	name     string
+	impurity func(counts []float64, total float64) float64
+}
+
+func (c *classCriterion) NewStats() Stats { return &classStats{impurity: c.impurity} }
+func (c *classCriterion) String() string  { return c.name }
+
+// classStats sums the weight of the rows in each class.
+type classStats struct {
+	counts   []float64
+	n        int
+	total    float64
+	impurity func(counts []float64, total float64) float64
+}
+
+func (s *classStats) Add(label float64)    { s.AddWeighted(label, 1) }
+func (s *classStats) Remove(label float64) { s.RemoveWeighted(label, 1) }
+
+func (s *classStats) AddWeighted(label, weight float64) {
+	class := ClassOf(label)
+	for len(s.counts) <= class {
+		s.counts = append(s.counts, 0)
+	}
+	s.counts[class] += weight
+	s.n++
+	s.total += weight
+}
+
+func (s *classStats) RemoveWeighted(label, weight float64) {
+	s.counts[ClassOf(label)] -= weight
+	s.n--
+	s.total -= weight
+}
+
+func (s *classStats) Merge(other Stats) {
+	o := other.(*classStats)
+	for len(s.counts) < len(o.counts) {
+		s.counts = append(s.counts, 0)
+	}
+	for class, count := range o.counts {
+		s.counts[class] += count
+	}
+	s.n += o.n
+	s.total += o.total
+}
+
+func (s *classStats) Subtract(other Stats) {
+	o := other.(*classStats)
+	for class, count := range o.counts {
+		s.counts[class] -= count
+	}
+	s.n -= o.n
+	s.total -= o.total
+}
+
+func (s *classStats) Clone() Stats {
+	clone := *s
+	clone.counts = append([]float64(nil), s.counts...)
+	return &clone
+}
+
+func (s *classStats) Count() int      { return s.n }
+func (s *classStats) Weight() float64 { return s.total }
+
+func (s *classStats) Impurity() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	return s.impurity(s.counts, s.total)
+}
+
+func gini(counts []float64, total float64) float64 {
+	sum := 0.0
+	for _, count := range counts {
+		p := count / total
+		sum += p * p
+	}
+	return 1 - sum
+}
+
+func entropy(counts []float64, total float64) float64 {
+	return logLoss(counts, total) / math.Ln2
+}
+
+func logLoss(counts []float64, total float64) float64 {
+	loss := 0.0
+	for _, count := range counts {
+		if count > 0 {
+			p := count / total
+			loss -= p * math.Log(p)
+		}
+	}
+	return loss
+}
+
+type mseCriterion struct{}
+
+func (mseCriterion) NewStats() Stats { return &momentStats{} }
+func (mseCriterion) String() string  { return "mse" }
+
+// momentStats keeps the count, total weight, weighted mean and weighted sum
+// of squared deviations of the labels, updated with West's weighted form of
+// Welford's method so large targets keep their precision.
+type momentStats struct {
+	n    int
+	w    float64
+	mean float64
+	m2   float64
+}
+
+func (s *momentStats) Add(label float64)    { s.AddWeighted(label, 1) }
+func (s *momentStats) Remove(label float64) { s.RemoveWeighted(label, 1) }
+
+func (s *momentStats) AddWeighted(label, weight float64) {
+	s.n++
+	s.w += weight
+	delta := label - s.mean
+	s.mean += delta * weight / s.w
+	s.m2 += weight * delta * (label - s.mean)
+}
+
+func (s *momentStats) RemoveWeighted(label, weight float64) {
+	if s.n <= 1 {
+		*s = momentStats{}
+		return
+	}
+	s.n--
+	s.w -= weight
+	delta := label - s.mean
+	s.mean -= delta * weight / s.w
+	s.m2 -= weight * delta * (label - s.mean)
+}
+
+func (s *momentStats) Merge(other Stats) {
+	o := other.(*momentStats)
+	if o.n == 0 {
+		return
+	}
+	w := s.w + o.w
+	delta := o.mean - s.mean
+	s.mean += delta * o.w / w
+	s.m2 += o.m2 + delta*delta*s.w*o.w/w
+	s.n += o.n
+	s.w = w
+}
+
+func (s *momentStats) Subtract(other Stats) {
+	o := other.(*momentStats)
+	n := s.n - o.n
+	if n <= 0 {
+		*s = momentStats{}
+		return
+	}
+	w := s.w - o.w
+	mean := (s.w*s.mean - o.w*o.mean) / w
+	delta := o.mean - mean
+	s.m2 -= o.m2 + delta*delta*w*o.w/s.w
+	s.mean = mean
+	s.n = n
+	s.w = w
+}
+
+func (s *momentStats) Clone() Stats {
+	clone := *s
+	return &clone
+}
+
+func (s *momentStats) Count() int      { return s.n }
+func (s *momentStats) Weight() float64 { return s.w }
+
+func (s *momentStats) Impurity() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	// Removing labels can leave a tiny negative sum for constant labels.
+	return math.Max(0, s.m2/s.w)
+}
+
+type maeCriterion struct{}
+
+func (maeCriterion) NewStats() Stats { return &sortedStats{} }
+func (maeCriterion) String() string  { return "mae" }
+
+// sortedStats keeps the labels in increasing order with their weights.
+type sortedStats struct {
+	labels []weightedLabel
+	total  float64
+}
+
+// weightedLabel is a label and the weight of its row.
+type weightedLabel struct {
+	label, weight float64
+}
+
+func (s *sortedStats) Add(label float64)    { s.AddWeighted(label, 1) }
+func (s *sortedStats) Remove(label float64) { s.RemoveWeighted(label, 1) }
+
+func (s *sortedStats) AddWeighted(label, weight float64) {
+	i := s.search(label)
+	s.labels = append(s.labels, weightedLabel{})
+	copy(s.labels[i+1:], s.labels[i:])
+	s.labels[i] = weightedLabel{label, weight}
+	s.total += weight
+}
+
+func (s *sortedStats) RemoveWeighted(label, weight float64) {
+	i := s.search(label)
+	// Equal labels may carry different weights.
+	for i+1 < len(s.labels) && s.labels[i+1].label == label && s.labels[i].weight != weight {
+		i++
+	}
+	s.labels = append(s.labels[:i], s.labels[i+1:]...)
+	s.total -= weight
+}
+
+// search returns the index of the first label not below label.
+func (s *sortedStats) search(label float64) int {
+	return sort.Search(len(s.labels), func(i int) bool { return s.labels[i].label >= label })
+}
+
+func (s *sortedStats) Merge(other Stats) {
+	for _, l := range other.(*sortedStats).labels {
+		s.AddWeighted(l.label, l.weight)
+	}
+}
+
+func (s *sortedStats) Subtract(other Stats) {
+	for _, l := range other.(*sortedStats).labels {
+		s.RemoveWeighted(l.label, l.weight)
+	}
+}
+
+func (s *sortedStats) Clone() Stats {
+	return &sortedStats{labels: append([]weightedLabel(nil), s.labels...), total: s.total}
+}
+
+func (s *sortedStats) Count() int      { return len(s.labels) }
+func (s *sortedStats) Weight() float64 { return s.total }
+
+func (s *sortedStats) Impurity() float64 {
+	if len(s.labels) == 0 {
+		return 0
+	}
+	median := weightedMedian(s.labels, s.total)
+	deviation := 0.0
+	for _, l := range s.labels {
+		deviation += l.weight * math.Abs(l.label-median)
+	}
+	return deviation / s.total
+}
+
+// weightedMedian returns the label at which the cumulative weight of labels,
+// sorted by label and weighing total, reaches half, or the midpoint of the
+// two labels around it when one side weighs exactly half. With unit weights
+// this is the usual median.
+func weightedMedian(labels []weightedLabel, total float64) float64 {
+	half := total / 2
+	cumulative := 0.0
+	for i, l := range labels {
+		cumulative += l.weight
+		if cumulative == half && i+1 < len(labels) {
+			return (l.label + labels[i+1].label) / 2
+		}
+		if cumulative > half {
+			return l.label
+		}
+	}
+	return labels[len(labels)-1].label
+}
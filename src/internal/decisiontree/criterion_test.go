@@ -0,0 +1,134 @@
+package decisiontree
+
+import (
+	"math"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestCriterionValues(t *testing.T) {
+	tests := []struct {
+		criterion Criterion
+		labels    []float64
+		want      float64
+	}{
+		{Gini, []float64{0, 0, 1, 1}, 0.5},
+		{Gini, []float64{0, 1, 2}, 2.0 / 3},
+		{Gini, []float64{1, 1, 1}, 0},
+		{Entropy, []float64{0, 0, 1, 1}, 1},
+		{Entropy, []float64{0, 1, 2, 3}, 2},
+		{LogLoss, []float64{0, 0, 1, 1}, math.Ln2},
+		{MSE, []float64{1, 2, 3, 4}, 1.25},
+		{MSE, []float64{7, 7}, 0},
+		{MSE, []float64{1e9 + 1, 1e9 + 2, 1e9 + 3, 1e9 + 4}, 1.25},
+		{MAE, []float64{1, 2, 3, 10}, 2.5},
+		{MAE, []float64{4, 1, 9}, 8.0 / 3},
+	}
+
+	for _, tt := range tests {
+		stats := tt.criterion.NewStats()
+		for _, label := range tt.labels {
+			stats.Add(label)
+		}
+		if got := stats.Impurity(); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s of %v = %v, want %v", tt.criterion, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestStatsUpdatesMatchRebuilding(t *testing.T) {
+	kept := []float64{0, 2, 1, 1, 0, 2, 2}
+	moved := []float64{1, 0, 0, 2}
+
+	for _, criterion := range Criteria {
+		want := criterion.NewStats()
+		for _, label := range kept {
+			want.Add(label)
+		}
+
+		all, removed, part := criterion.NewStats(), criterion.NewStats(), criterion.NewStats()
+		for _, label := range append(append([]float64(nil), kept...), moved...) {
+			all.Add(label)
+			removed.Add(label)
+		}
+		for _, label := range moved {
+			part.Add(label)
+			removed.Remove(label)
+		}
+		subtracted := all.Clone()
+		subtracted.Subtract(part)
+		merged := want.Clone()
+		merged.Merge(part)
+
+		for name, got := range map[string]Stats{"removed": removed, "subtracted": subtracted} {
+			if got.Count() != want.Count() || math.Abs(got.Impurity()-want.Impurity()) > 1e-12 {
+				t.Errorf("%s %s: %d rows, impurity %v; want %d, %v",
+					criterion, name, got.Count(), got.Impurity(), want.Count(), want.Impurity())
+			}
+		}
+		if merged.Count() != all.Count() || math.Abs(merged.Impurity()-all.Impurity()) > 1e-12 {
+			t.Errorf("%s merged: impurity %v, want %v", criterion, merged.Impurity(), all.Impurity())
+		}
+		if all.Count() != len(kept)+len(moved) {
+			t.Errorf("%s: Subtract changed the stats it was cloned from", criterion)
+		}
+	}
+}
+
+func TestParseCriterion(t *testing.T) {
+	for _, criterion := range Criteria {
+		got, err := ParseCriterion(criterion.String())
+		if err != nil || got != criterion {
+			t.Errorf("ParseCriterion(%q) = %v, %v", criterion, got, err)
+		}
+	}
+	if _, err := ParseCriterion("variance"); err == nil {
+		t.Error("ParseCriterion accepted an unknown name")
+	}
+}
+
+func TestTreesAgreeForEveryCriterion(t *testing.T) {
+	classification := harness.DiscreteClassification(400, 4, 5, 0.15, 12)
+	regression := harness.DiscreteRegression(400, 4, 7, 0.1, 13)
+
+	for _, criterion := range Criteria {
+		data := classification
+		if criterion == MSE || criterion == MAE {
+			data = regression
+		}
+		opts := Options{MaxDepth: 5, Criterion: criterion}
+		binned := opts
+		binned.MaxBins = 8
+
+		sequential := NewSequentialDecisionTreeWithOptions(opts)
+		sequential.Train(data)
+		for name, tree := range map[string]trainedTree{
+			"concurrent": NewConcurrentDecisionTreeWithOptions(opts),
+			"histogram":  NewSequentialDecisionTreeWithOptions(binned),
+		} {
+			tree.Train(data)
+			if err := compareNodes(sequential.root, treeRoot(tree), "root"); err != nil {
+				t.Errorf("%s, %s: %v", criterion, name, err)
+			}
+		}
+	}
+}
+
+func TestRegressionLeafValues(t *testing.T) {
+	data := [][]float64{{0, 1}, {0, 2}, {0, 10}, {0, 3}}
+
+	for criterion, want := range map[Criterion]float64{MSE: 4, MAE: 2.5} {
+		opts := DefaultRegressionOptions()
+		opts.Criterion = criterion
+		for name, tree := range map[string]trainedTree{
+			"sequential": NewSequentialDecisionTreeWithOptions(opts),
+			"concurrent": NewConcurrentDecisionTreeWithOptions(opts),
+		} {
+			tree.Train(data)
+			if got := tree.Predict([]float64{0}); got != want {
+				t.Errorf("%s %s leaf predicts %v, want %v", criterion, name, got, want)
+			}
+		}
+	}
+}
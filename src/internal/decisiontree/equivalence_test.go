@@ -0,0 +1,96 @@
+package decisiontree
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+const treeTolerance = 1e-12
+
+func TestConcurrentTreeMatchesSequential(t *testing.T) {
+	datasets := map[string][][]float64{
+		"continuous":     harness.Classification(400, 5, 0.05, 1),
+		"noisy":          harness.Classification(300, 8, 0.3, 2),
+		"discrete":       harness.DiscreteClassification(500, 6, 4, 0.1, 3),
+		"single feature": harness.Classification(200, 1, 0.1, 4),
+	}
+
+	for name, data := range datasets {
+		t.Run(name, func(t *testing.T) {
+			sequential := NewSequentialDecisionTree()
+			sequential.Train(data)
+			concurrent := NewConcurrentDecisionTree()
+			concurrent.Train(data)
+
+			if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := harness.Predictions(sequential, data)
+			got := harness.Predictions(concurrent, data)
+			if diff := harness.MaxAbsDiff(want, got); diff > treeTolerance {
+				t.Fatalf("predictions differ by up to %g", diff)
+			}
+		})
+	}
+}
+
+func compareNodes(want, got *Node, path string) error {
+	if (want == nil) != (got == nil) {
+		return fmt.Errorf("%s: one tree has a node and the other does not", path)
+	}
+	if want == nil {
+		return nil
+	}
+	wantLeaf := want.Left == nil && want.Right == nil
+	gotLeaf := got.Left == nil && got.Right == nil
+	if wantLeaf != gotLeaf {
+		return fmt.Errorf("%s: leaf mismatch (sequential %v, concurrent %v)", path, wantLeaf, gotLeaf)
+	}
+	if wantLeaf {
+		if !harness.WithinTolerance(want.Prediction, got.Prediction, treeTolerance) {
+			return fmt.Errorf(
+				"%s: prediction %g, concurrent %g",
+				path,
+				want.Prediction,
+				got.Prediction,
+			)
+		}
+		if len(want.Distribution) != len(got.Distribution) ||
+			harness.MaxAbsDiff(want.Distribution, got.Distribution) > treeTolerance {
+			return fmt.Errorf(
+				"%s: distribution %v, concurrent %v",
+				path,
+				want.Distribution,
+				got.Distribution,
+			)
+		}
+		return nil
+	}
+	if want.Feature != got.Feature ||
+		!harness.WithinTolerance(want.Threshold, got.Threshold, treeTolerance) {
+		return fmt.Errorf(
+			"%s: split feature %d <= %g, concurrent feature %d <= %g",
+			path,
+			want.Feature,
+			want.Threshold,
+			got.Feature,
+			got.Threshold,
+		)
+	}
+	if !slices.Equal(want.Categories, got.Categories) {
+		return fmt.Errorf(
+			"%s: split categories %v, concurrent categories %v",
+			path,
+			want.Categories,
+			got.Categories,
+		)
+	}
+	if err := compareNodes(want.Left, got.Left, path+".left"); err != nil {
+		return err
+	}
+	return compareNodes(want.Right, got.Right, path+".right")
+}
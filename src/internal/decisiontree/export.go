@@ -0,0 +1,290 @@
+package decisiontree
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats Export writes.
+var ExportFormats = []string{"dot", "json", "rules"}
+
+// ExportOptions says how exported trees name their features and classes.
+type ExportOptions struct {
+	// FeatureNames[i] names feature i. Features without a name are written
+	// as x[i].
+	FeatureNames []string
+	// ClassNames[i] names class i. Classes without a name are written as
+	// their index.
+	ClassNames []string
+	// Strict trees send rows below the threshold left instead of rows at or
+	// below it.
+	Strict bool
+}
+
+// Export writes the trees rooted at roots in format, one of ExportFormats.
+// Every node shows its training samples and impurity and, in classification
+// trees, its class distribution.
+//
+//   - "dot" is a Graphviz digraph, with a cluster per tree when there are
+//     several.
+//   - "json" is an object with the feature and class names and a "trees"
+//     array of nested nodes.
+//   - "rules" lists one if/then rule per leaf.
+func Export(w io.Writer, format string, opts ExportOptions, roots ...*Node) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case "dot":
+		writeDOT(bw, opts, roots)
+	case "json":
+		err = writeJSON(bw, opts, roots)
+	case "rules":
+		writeRules(bw, opts, roots)
+	default:
+		return fmt.Errorf(
+			"decisiontree: unknown export format %q, want one of %s",
+			format,
+			strings.Join(ExportFormats, ", "),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (o ExportOptions) featureName(feature int) string {
+	if feature < len(o.FeatureNames) && o.FeatureNames[feature] != "" {
+		return o.FeatureNames[feature]
+	}
+	return fmt.Sprintf("x[%d]", feature)
+}
+
+func (o ExportOptions) className(class int) string {
+	if class < len(o.ClassNames) {
+		return o.ClassNames[class]
+	}
+	return strconv.Itoa(class)
+}
+
+// operators returns the comparisons that send rows left and right.
+func (o ExportOptions) operators() (string, string) {
+	if o.Strict {
+		return "<", ">="
+	}
+	return "<=", ">"
+}
+
+// tests returns the conditions on node's feature that send rows left and
+// right, such as "<= 2.5" and "> 2.5", or "in {1, 4}" and "not in {1, 4}"
+// for a categorical split.
+func (o ExportOptions) tests(node *Node) (string, string) {
+	if node.Categories != nil {
+		parts := make([]string, len(node.Categories))
+		for i, category := range node.Categories {
+			parts[i] = formatNumber(category)
+		}
+		set := "{" + strings.Join(parts, ", ") + "}"
+		return "in " + set, "not in " + set
+	}
+	left, right := o.operators()
+	threshold := formatNumber(node.Threshold)
+	return left + " " + threshold, right + " " + threshold
+}
+
+// outcome describes what a node predicts: its majority class in
+// classification trees and its value otherwise.
+func (o ExportOptions) outcome(node *Node) string {
+	if node.Distribution != nil {
+		return "class = " + o.className(Argmax(node.Distribution))
+	}
+	return "value = " + formatNumber(node.Prediction)
+}
+
+func (o ExportOptions) distribution(node *Node) string {
+	parts := make([]string, len(node.Distribution))
+	for class, p := range node.Distribution {
+		parts[class] = fmt.Sprintf("%s: %.3f", o.className(class), p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatNumber writes the shortest decimal that parses back to value, so
+// exported thresholds route rows exactly like the tree.
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func writeDOT(w *bufio.Writer, opts ExportOptions, roots []*Node) {
+	fmt.Fprintln(w, "digraph Tree {")
+	fmt.Fprintln(w, `node [shape=box, style="rounded", fontname="helvetica"];`)
+	fmt.Fprintln(w, `edge [fontname="helvetica"];`)
+	id := 0
+	for t, root := range roots {
+		if len(roots) > 1 {
+			fmt.Fprintf(w, "subgraph cluster_%d {\nlabel=\"tree %d\";\n", t, t)
+		}
+		var visit func(node *Node) int
+		visit = func(node *Node) int {
+			nodeID := id
+			id++
+			lines := []string{}
+			if !isLeaf(node) {
+				left, _ := opts.tests(node)
+				lines = append(lines, opts.featureName(node.Feature)+" "+left)
+			}
+			lines = append(lines,
+				fmt.Sprintf("impurity = %.4f", node.Impurity),
+				fmt.Sprintf("samples = %d", node.Samples),
+			)
+			if node.Distribution != nil {
+				lines = append(lines, "distribution = ["+opts.distribution(node)+"]")
+			}
+			lines = append(lines, opts.outcome(node))
+			fmt.Fprintf(w, "%d [label=%s];\n", nodeID, strconv.Quote(strings.Join(lines, "\n")))
+
+			if !isLeaf(node) {
+				left, right := opts.tests(node)
+				leftID := visit(node.Left)
+				fmt.Fprintf(w, "%d -> %d [label=%s];\n", nodeID, leftID, strconv.Quote(left))
+				rightID := visit(node.Right)
+				fmt.Fprintf(w, "%d -> %d [label=%s];\n", nodeID, rightID, strconv.Quote(right))
+			}
+			return nodeID
+		}
+		visit(root)
+		if len(roots) > 1 {
+			fmt.Fprintln(w, "}")
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// jsonNode is the exported form of a Node. Split fields are omitted in
+// leaves and Distribution, Counts and Class in regression trees. Categorical splits
+// have the operator "in" and list the categories sent left instead of a
+// threshold.
+type jsonNode struct {
+	ID           int       `json:"id"`
+	Feature      string    `json:"feature,omitempty"`
+	FeatureIndex *int      `json:"feature_index,omitempty"`
+	Operator     string    `json:"operator,omitempty"`
+	Threshold    *float64  `json:"threshold,omitempty"`
+	Categories   []float64 `json:"categories,omitempty"`
+	Samples      int       `json:"samples"`
+	Impurity     float64   `json:"impurity"`
+	Value        float64   `json:"value"`
+	Distribution []float64 `json:"distribution,omitempty"`
+	Counts       []float64 `json:"counts,omitempty"`
+	Class        string    `json:"class,omitempty"`
+	Left         *jsonNode `json:"left,omitempty"`
+	Right        *jsonNode `json:"right,omitempty"`
+}
+
+func writeJSON(w *bufio.Writer, opts ExportOptions, roots []*Node) error {
+	operator, _ := opts.operators()
+	var convert func(node *Node) *jsonNode
+	convert = func(node *Node) *jsonNode {
+		out := &jsonNode{
+			ID:           node.ID,
+			Samples:      node.Samples,
+			Impurity:     node.Impurity,
+			Value:        node.Prediction,
+			Distribution: node.Distribution,
+			Counts:       node.Counts,
+		}
+		if node.Distribution != nil {
+			out.Class = opts.className(Argmax(node.Distribution))
+		}
+		if !isLeaf(node) {
+			feature, threshold := node.Feature, node.Threshold
+			out.Feature = opts.featureName(feature)
+			out.FeatureIndex = &feature
+			if node.Categories != nil {
+				out.Operator = "in"
+				out.Categories = node.Categories
+			} else {
+				out.Operator = operator
+				out.Threshold = &threshold
+			}
+			out.Left = convert(node.Left)
+			out.Right = convert(node.Right)
+		}
+		return out
+	}
+
+	doc := struct {
+		FeatureNames []string    `json:"feature_names,omitempty"`
+		ClassNames   []string    `json:"class_names,omitempty"`
+		Trees        []*jsonNode `json:"trees"`
+	}{
+		FeatureNames: opts.FeatureNames,
+		ClassNames:   opts.ClassNames,
+		Trees:        make([]*jsonNode, len(roots)),
+	}
+	for i, root := range roots {
+		doc.Trees[i] = convert(root)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(doc)
+}
+
+func writeRules(w *bufio.Writer, opts ExportOptions, roots []*Node) {
+	for t, root := range roots {
+		if len(roots) > 1 {
+			if t > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "# tree %d\n", t)
+		}
+		var visit func(node *Node, conditions []string)
+		visit = func(node *Node, conditions []string) {
+			if isLeaf(node) {
+				condition := "true"
+				if len(conditions) > 0 {
+					condition = strings.Join(conditions, " and ")
+				}
+				details := fmt.Sprintf("samples = %d, impurity = %.4f", node.Samples, node.Impurity)
+				if node.Distribution != nil {
+					details += ", " + opts.distribution(node)
+				}
+				fmt.Fprintf(w, "if %s then %s (%s)\n", condition, opts.outcome(node), details)
+				return
+			}
+			name := opts.featureName(node.Feature)
+			left, right := opts.tests(node)
+			// Full slice expressions keep the two branches from sharing
+			// the appended element.
+			conditions = conditions[:len(conditions):len(conditions)]
+			visit(node.Left, append(conditions, name+" "+left))
+			visit(node.Right, append(conditions, name+" "+right))
+		}
+		visit(root, nil)
+	}
+}
+
+// Export writes the trained tree in format, one of ExportFormats.
+func (dt *SequentialDecisionTree) Export(w io.Writer, format string, opts ExportOptions) error {
+	return Export(w, format, opts, dt.root)
+}
+
+// Export writes the trained tree in format, one of ExportFormats.
+func (dt *ConcurrentDecisionTree) Export(w io.Writer, format string, opts ExportOptions) error {
+	return Export(w, format, opts, dt.root)
+}
+
+// Root returns the root of the trained tree.
+func (dt *SequentialDecisionTree) Root() *Node {
+	return dt.root
+}
+
+// Root returns the root of the trained tree.
+func (dt *ConcurrentDecisionTree) Root() *Node {
+	return dt.root
+}
@@ -0,0 +1,164 @@
+package decisiontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+var exportNames = ExportOptions{
+	FeatureNames: []string{"edad", "ingreso", "ventas"},
+	ClassNames:   []string{"bajo", "medio", "alto"},
+}
+
+func exportedTree(t *testing.T) (*SequentialDecisionTree, [][]float64) {
+	t.Helper()
+	data := harness.Multiclass(400, 3, 3, 0.1, 5)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 4})
+	tree.Train(data)
+	return tree, data
+}
+
+func TestExportJSONRoutesLikeTheTree(t *testing.T) {
+	tree, data := exportedTree(t)
+	var buf bytes.Buffer
+	if err := tree.Export(&buf, "json", exportNames); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		FeatureNames []string    `json:"feature_names"`
+		Trees        []*jsonNode `json:"trees"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Trees) != 1 || doc.Trees[0].Samples != len(data) {
+		t.Fatalf("exported %d trees, root with %d samples", len(doc.Trees), doc.Trees[0].Samples)
+	}
+
+	for _, row := range data {
+		node := doc.Trees[0]
+		for node.Left != nil {
+			if node.Feature != exportNames.FeatureNames[*node.FeatureIndex] || node.Operator != "<=" {
+				t.Fatalf("split on %q %s", node.Feature, node.Operator)
+			}
+			if row[*node.FeatureIndex] <= *node.Threshold {
+				node = node.Left
+			} else {
+				node = node.Right
+			}
+		}
+		sample := row[:len(row)-1]
+		if node.Class != exportNames.ClassNames[tree.PredictClass(sample)] {
+			t.Fatalf("exported leaf predicts %q, tree %d", node.Class, tree.PredictClass(sample))
+		}
+		if harness.MaxAbsDiff(node.Distribution, tree.PredictProba(sample)) != 0 {
+			t.Fatalf("exported distribution %v, tree %v", node.Distribution, tree.PredictProba(sample))
+		}
+	}
+}
+
+// matchingRules returns the rules whose conditions row satisfies.
+func matchingRules(t *testing.T, rules []string, names []string, row []float64) []string {
+	t.Helper()
+	var matched []string
+	for _, rule := range rules {
+		condition := strings.TrimPrefix(rule[:strings.Index(rule, " then ")], "if ")
+		holds := true
+		for _, clause := range strings.Split(condition, " and ") {
+			fields := strings.Fields(clause)
+			if len(fields) != 3 {
+				continue
+			}
+			feature := -1
+			for i, name := range names {
+				if name == fields[0] {
+					feature = i
+				}
+			}
+			threshold, err := strconv.ParseFloat(fields[2], 64)
+			if feature == -1 || err != nil {
+				t.Fatalf("cannot parse %q", clause)
+			}
+			value := row[feature]
+			switch fields[1] {
+			case "<=":
+				holds = holds && value <= threshold
+			case ">":
+				holds = holds && value > threshold
+			case "<":
+				holds = holds && value < threshold
+			case ">=":
+				holds = holds && value >= threshold
+			}
+		}
+		if holds {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+func TestExportRulesCoverEveryRowOnce(t *testing.T) {
+	tree, data := exportedTree(t)
+	var buf bytes.Buffer
+	if err := tree.Export(&buf, "rules", exportNames); err != nil {
+		t.Fatal(err)
+	}
+	rules := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rules) != leavesOf(tree.root) {
+		t.Fatalf("%d rules for %d leaves", len(rules), leavesOf(tree.root))
+	}
+
+	for _, row := range data {
+		matched := matchingRules(t, rules, exportNames.FeatureNames, row)
+		if len(matched) != 1 {
+			t.Fatalf("row %v matches %d rules", row, len(matched))
+		}
+		want := "then class = " + exportNames.ClassNames[tree.PredictClass(row[:len(row)-1])] + " ("
+		if !strings.Contains(matched[0], want) || !strings.Contains(matched[0], "samples = ") {
+			t.Fatalf("rule %q, want %q", matched[0], want)
+		}
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	tree, _ := exportedTree(t)
+	regression := NewConcurrentRegressionTree()
+	regression.Train(harness.Regression(200, 3, 0.1, 4))
+
+	var buf bytes.Buffer
+	if err := Export(&buf, "dot", exportNames, tree.Root(), regression.Root()); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+
+	nodes := countNodes(tree.root) + countNodes(regression.root)
+	if got := strings.Count(dot, " [label="); got != nodes+nodes-2 {
+		t.Errorf("%d labelled nodes and edges, want %d", got, 2*nodes-2)
+	}
+	if strings.Count(dot, "subgraph cluster_") != 2 {
+		t.Error("want a cluster per tree")
+	}
+	for _, want := range []string{"ingreso <= ", "samples = 400", "distribution = [bajo: ", "value = "} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output does not mention %q", want)
+		}
+	}
+
+	if err := Export(&buf, "svg", exportNames, tree.Root()); err == nil {
+		t.Error("unknown formats should fail")
+	}
+}
+
+func countNodes(node *Node) int {
+	if isLeaf(node) {
+		return 1
+	}
+	return 1 + countNodes(node.Left) + countNodes(node.Right)
+}
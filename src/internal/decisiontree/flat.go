@@ -0,0 +1,194 @@
+package decisiontree
+
+import "sync"
+
+// FlatTree is a trained tree laid out for inference. Its nodes are stored in
+// breadth-first order as parallel arrays, so walking a sample down the tree
+// reads a few small contiguous slices instead of chasing pointers between
+// Node structs. Siblings are adjacent: the right child of node i is
+// left[i]+1.
+type FlatTree struct {
+	// feature[i] is the feature node i splits on, or -1 for a leaf.
+	feature   []int32
+	threshold []float64
+	left      []int32
+	value     []float64
+	// categories[i] are the categories of a categorical split. It is nil
+	// when the tree has no categorical splits.
+	categories [][]float64
+	// strict trees send values below the threshold left, like
+	// ParallelDecisionTree.
+	strict bool
+}
+
+// Flatten lays out the tree rooted at root, which must not be nil. Strict
+// trees send values below the threshold left instead of values at or below
+// it.
+func Flatten(root *Node, strict bool) *FlatTree {
+	t := &FlatTree{strict: strict}
+	categorical := false
+	queue := []*Node{root}
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		t.value = append(t.value, node.Prediction)
+		t.categories = append(t.categories, node.Categories)
+		if isLeaf(node) {
+			t.feature = append(t.feature, -1)
+			t.threshold = append(t.threshold, 0)
+			t.left = append(t.left, 0)
+			continue
+		}
+		t.feature = append(t.feature, int32(node.Feature))
+		t.threshold = append(t.threshold, node.Threshold)
+		t.left = append(t.left, int32(len(queue)))
+		queue = append(queue, node.Left, node.Right)
+		categorical = categorical || node.Categories != nil
+	}
+	if !categorical {
+		t.categories = nil
+	}
+	return t
+}
+
+// Len returns the number of nodes of the tree.
+func (t *FlatTree) Len() int {
+	return len(t.feature)
+}
+
+// Predict returns the prediction of the leaf sample falls into, the same
+// value the tree it was flattened from returns.
+func (t *FlatTree) Predict(sample []float64) float64 {
+	i := int32(0)
+	for {
+		f := t.feature[i]
+		if f < 0 {
+			return t.value[i]
+		}
+		value := sample[f]
+		var left bool
+		switch {
+		case t.categories != nil && t.categories[i] != nil:
+			left = hasCategory(t.categories[i], value)
+		case t.strict:
+			left = value < t.threshold[i]
+		default:
+			left = value <= t.threshold[i]
+		}
+		i = t.left[i]
+		if !left {
+			i++
+		}
+	}
+}
+
+// PredictBatch writes the prediction of samples[i] to out[i] and returns out,
+// allocating it when it is shorter than samples.
+func (t *FlatTree) PredictBatch(samples [][]float64, out []float64) []float64 {
+	out = batchOutput(samples, out)
+	for i, sample := range samples {
+		out[i] = t.Predict(sample)
+	}
+	return out
+}
+
+// PredictBatchConcurrent is PredictBatch with samples split into one
+// contiguous chunk per worker.
+func (t *FlatTree) PredictBatchConcurrent(samples [][]float64, out []float64, workers int) []float64 {
+	out = batchOutput(samples, out)
+	inChunks(len(samples), workers, func(start, end int) {
+		t.PredictBatch(samples[start:end], out[start:end])
+	})
+	return out
+}
+
+// FlatForest averages the predictions of flattened trees, adding them up in
+// the order the forests do so its predictions match theirs exactly.
+type FlatForest struct {
+	trees []*FlatTree
+}
+
+// NewFlatForest returns the forest of trees.
+func NewFlatForest(trees ...*FlatTree) *FlatForest {
+	return &FlatForest{trees: trees}
+}
+
+// Trees returns the forest's trees.
+func (f *FlatForest) Trees() []*FlatTree {
+	return f.trees
+}
+
+// Predict returns the mean prediction of the trees.
+func (f *FlatForest) Predict(sample []float64) float64 {
+	sum := 0.0
+	for _, tree := range f.trees {
+		sum += tree.Predict(sample)
+	}
+	return sum / float64(len(f.trees))
+}
+
+// PredictBatch writes the prediction of samples[i] to out[i] and returns out,
+// allocating it when it is shorter than samples. It walks one tree over every
+// sample before moving to the next, so only one tree's arrays need to stay in
+// cache at a time.
+func (f *FlatForest) PredictBatch(samples [][]float64, out []float64) []float64 {
+	out = batchOutput(samples, out)
+	clear(out)
+	for _, tree := range f.trees {
+		for i, sample := range samples {
+			out[i] += tree.Predict(sample)
+		}
+	}
+	n := float64(len(f.trees))
+	for i := range out {
+		out[i] /= n
+	}
+	return out
+}
+
+// PredictBatchConcurrent is PredictBatch with samples split into one
+// contiguous chunk per worker.
+func (f *FlatForest) PredictBatchConcurrent(samples [][]float64, out []float64, workers int) []float64 {
+	out = batchOutput(samples, out)
+	inChunks(len(samples), workers, func(start, end int) {
+		f.PredictBatch(samples[start:end], out[start:end])
+	})
+	return out
+}
+
+// batchOutput returns out resliced to the length of samples, or a new slice
+// when out is too short.
+func batchOutput(samples [][]float64, out []float64) []float64 {
+	if len(out) < len(samples) {
+		return make([]float64, len(samples))
+	}
+	return out[:len(samples)]
+}
+
+// inChunks calls predict on one contiguous chunk of [0, n) per worker, each
+// in its own goroutine, and waits for all of them.
+func inChunks(n, workers int, predict func(start, end int)) {
+	if n == 0 {
+		return
+	}
+	workers = max(1, min(workers, n))
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			predict(start, end)
+		}(start, min(start+chunk, n))
+	}
+	wg.Wait()
+}
+
+// Flatten returns the trained tree laid out for inference.
+func (dt *SequentialDecisionTree) Flatten() *FlatTree {
+	return Flatten(dt.root, false)
+}
+
+// Flatten returns the trained tree laid out for inference.
+func (dt *ConcurrentDecisionTree) Flatten() *FlatTree {
+	return Flatten(dt.root, false)
+}
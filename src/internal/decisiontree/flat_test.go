@@ -0,0 +1,149 @@
+package decisiontree
+
+import (
+	"fmt"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// features drops the label of every row.
+func features(data [][]float64) [][]float64 {
+	samples := make([][]float64, len(data))
+	for i, row := range data {
+		samples[i] = row[:len(row)-1]
+	}
+	return samples
+}
+
+func TestFlatTreeMatchesTree(t *testing.T) {
+	categorical := categoricalData(2000, 12, 0.1, 6)
+	trees := map[string]struct {
+		opts Options
+		data [][]float64
+	}{
+		"classification": {Options{MaxDepth: 10}, harness.Classification(2000, 6, 0.1, 1)},
+		"regression":     {DefaultRegressionOptions(), harness.Regression(2000, 5, 0.1, 2)},
+		"categorical":    {Options{MaxDepth: 6, Categorical: []int{0}}, categorical},
+	}
+	for name, c := range trees {
+		t.Run(name, func(t *testing.T) {
+			tree := NewSequentialDecisionTreeWithOptions(c.opts)
+			tree.Train(c.data)
+			flat := tree.Flatten()
+
+			samples := features(c.data)
+			batch := flat.PredictBatch(samples, nil)
+			concurrent := flat.PredictBatchConcurrent(samples, make([]float64, len(samples)), 3)
+			for i, sample := range samples {
+				want := tree.Predict(sample)
+				if got := flat.Predict(sample); got != want {
+					t.Fatalf("sample %d: flat %v, tree %v", i, got, want)
+				}
+				if batch[i] != want || concurrent[i] != want {
+					t.Fatalf("sample %d: batch %v, concurrent batch %v, tree %v", i, batch[i], concurrent[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestFlattenIsBreadthFirst checks that nodes are laid out level by level
+// with the children of every split next to each other.
+func TestFlattenIsBreadthFirst(t *testing.T) {
+	data := harness.Classification(1500, 4, 0.2, 3)
+	tree := NewConcurrentDecisionTreeWithOptions(Options{MaxDepth: 8})
+	tree.Train(data)
+	flat := tree.Flatten()
+
+	nodes := 0
+	depth := make([]int, flat.Len())
+	var count func(node *Node)
+	count = func(node *Node) {
+		nodes++
+		if !isLeaf(node) {
+			count(node.Left)
+			count(node.Right)
+		}
+	}
+	count(tree.root)
+	if flat.Len() != nodes {
+		t.Fatalf("%d flat nodes, tree has %d", flat.Len(), nodes)
+	}
+	for i := 0; i < flat.Len(); i++ {
+		if i > 0 && depth[i] < depth[i-1] {
+			t.Fatalf("node %d at depth %d follows a node at depth %d", i, depth[i], depth[i-1])
+		}
+		if flat.feature[i] < 0 {
+			continue
+		}
+		left := flat.left[i]
+		if int(left) <= i || int(left)+1 >= flat.Len() {
+			t.Fatalf("node %d has children %d and %d", i, left, left+1)
+		}
+		depth[left], depth[left+1] = depth[i]+1, depth[i]+1
+	}
+}
+
+func TestFlatForestAveragesInOrder(t *testing.T) {
+	data := harness.Regression(800, 4, 0.1, 4)
+	var trees []*FlatTree
+	var roots []*SequentialDecisionTree
+	for seed := 0; seed < 5; seed++ {
+		tree := NewSequentialDecisionTreeWithOptions(DefaultRegressionOptions())
+		tree.Train(data[seed*100 : seed*100+400])
+		roots = append(roots, tree)
+		trees = append(trees, tree.Flatten())
+	}
+	forest := NewFlatForest(trees...)
+
+	samples := features(data)
+	batch := forest.PredictBatchConcurrent(samples, nil, 4)
+	for i, sample := range samples {
+		sum := 0.0
+		for _, tree := range roots {
+			sum += tree.Predict(sample)
+		}
+		want := sum / float64(len(roots))
+		if got := forest.Predict(sample); got != want || batch[i] != want {
+			t.Fatalf("sample %d: flat %v, batch %v, trees %v", i, got, batch[i], want)
+		}
+	}
+}
+
+// BenchmarkPredict compares walking the Node pointers with the flattened
+// layout, one sample at a time and in batches.
+func BenchmarkPredict(b *testing.B) {
+	for _, depth := range []int{6, 12, 20} {
+		data := harness.Classification(20000, 8, 0.2, 1)
+		tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: depth})
+		tree.Train(data)
+		flat := tree.Flatten()
+		samples := features(data)
+		out := make([]float64, len(samples))
+
+		report := func(b *testing.B) {
+			b.ReportMetric(float64(b.N*len(samples))/b.Elapsed().Seconds(), "rows/s")
+		}
+		b.Run(fmt.Sprintf("nodes/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j, sample := range samples {
+					out[j] = tree.Predict(sample)
+				}
+			}
+			report(b)
+		})
+		b.Run(fmt.Sprintf("flat/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				flat.PredictBatch(samples, out)
+			}
+			report(b)
+		})
+		b.Run(fmt.Sprintf("flat-concurrent/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				flat.PredictBatchConcurrent(samples, out, 4)
+			}
+			report(b)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package decisiontree
+
+import (
+	"container/heap"
+
+	"concurrente/internal/workpool"
+)
+
+// Split is a candidate partition of a node's rows.
+type Split[T any] struct {
+	Feature   int
+	Threshold float64
+	// Categories, when not nil, replaces Threshold: the split sends the rows
+	// whose value is one of these sorted categories left.
+	Categories []float64
+	// Impurity is the size-weighted impurity of the two children.
+	Impurity    float64
+	Left, Right [][]T
+	// LeftState and RightState are handed back to a StatefulSplitter when it
+	// searches the children, so it can reuse work done on the parent.
+	LeftState, RightState any
+}
+
+// Splitter supplies the data-dependent parts of growing a tree, so the same
+// stopping rules apply to every tree in the project regardless of how rows
+// are stored or how splits are searched.
+type Splitter[T any] interface {
+	// Impurity returns the impurity of a node holding data.
+	Impurity(data [][]T) float64
+	// LeafValue returns the prediction of a leaf holding data.
+	LeafValue(data [][]T) float64
+	// BestSplit returns the best split of data, or false when no split is
+	// allowed. It is responsible for honouring MinSamplesLeaf.
+	BestSplit(data [][]T) (Split[T], bool)
+}
+
+// ClassSplitter is a Splitter for classification trees, whose leaves also
+// record how their rows are spread over the classes.
+type ClassSplitter[T any] interface {
+	Splitter[T]
+	// LeafCounts returns the weighted number of rows of data in each class,
+	// or nil when the tree is not a classifier.
+	LeafCounts(data [][]T) []float64
+}
+
+// WeightedSplitter is a Splitter whose rows carry sample weights. Grow
+// weighs nodes by the total weight of their rows, as their impurities do;
+// other splitters weigh every row 1.
+type WeightedSplitter[T any] interface {
+	Splitter[T]
+	// Weight returns the total sample weight of the rows of data.
+	Weight(data [][]T) float64
+}
+
+// StatefulSplitter is a Splitter that carries state, such as a histogram, from
+// a node down to its children.
+type StatefulSplitter[T any] interface {
+	Splitter[T]
+	// BestSplitFrom is BestSplit for a node whose parent's split returned
+	// state for it. State is nil at the root.
+	BestSplitFrom(data [][]T, state any) (Split[T], bool)
+}
+
+// SubspaceSplitter is a Splitter that can restrict its search to some of
+// the features it considers. Grow asks it for a random subset at every node
+// when Options.MaxFeatures is set; other splitters always search them all.
+type SubspaceSplitter[T any] interface {
+	Splitter[T]
+	// NumCandidates returns how many features the splitter searches.
+	NumCandidates() int
+	// BestSplitAmong is BestSplit, or BestSplitFrom for a StatefulSplitter,
+	// searching only the candidates with the given increasing indices in
+	// [0, NumCandidates()).
+	BestSplitAmong(data [][]T, state any, candidates []int) (Split[T], bool)
+}
+
+// RandomSplitter is a SubspaceSplitter that can draw splits instead of
+// searching them. Grow uses it when Options.RandomThresholds is set; other
+// splitters keep searching.
+type RandomSplitter[T any] interface {
+	SubspaceSplitter[T]
+	// RandomSplitAmong draws one split of each of the candidates, in the
+	// same form as BestSplitAmong, and returns the one with the lowest
+	// impurity, or false when none honours MinSamplesLeaf. The draws for a
+	// candidate come from a generator seeded with seed and the candidate, so
+	// they do not depend on the order candidates are tried in.
+	RandomSplitAmong(data [][]T, candidates []int, seed uint64) (Split[T], bool)
+}
+
+// Grow builds a tree over data, asking splitter for splits until opts says
+// to stop.
+func Grow[T any](data [][]T, splitter Splitter[T], opts Options) *Node {
+	g := &grower[T]{splitter: splitter, opts: opts}
+	g.total = g.weight(data)
+	var root *Node
+	if opts.MaxLeafNodes > 0 {
+		root = g.growBestFirst(data)
+	} else {
+		root = g.growDepthFirst(data, nil, 0, 0)
+	}
+	numberNodes(root)
+	return root
+}
+
+// GrowParallel is Grow with the two subtrees of every node holding at least
+// minTaskSamples rows grown at the same time: the right subtree is forked as
+// a task on pool while the current worker grows the left one. Smaller
+// subtrees are grown sequentially by the task that reaches them, so they do
+// not pay for scheduling. Best-first growth, with MaxLeafNodes, expands
+// leaves in a global order and runs as in Grow. The tree is the one Grow
+// builds; splitter must be safe for concurrent use.
+func GrowParallel[T any](
+	data [][]T,
+	splitter Splitter[T],
+	opts Options,
+	pool *workpool.Pool,
+	minTaskSamples int,
+) *Node {
+	g := &grower[T]{splitter: splitter, opts: opts}
+	g.total = g.weight(data)
+	var root *Node
+	if opts.MaxLeafNodes > 0 {
+		root = g.growBestFirst(data)
+	} else {
+		pool.Run(func(w *workpool.Worker) {
+			root = g.growTasks(w, data, nil, 0, 0, minTaskSamples)
+		})
+	}
+	numberNodes(root)
+	return root
+}
+
+type grower[T any] struct {
+	splitter Splitter[T]
+	opts     Options
+	// total is the weight of the training rows.
+	total float64
+}
+
+// weight is the total sample weight of data, its number of rows unless the
+// splitter is a WeightedSplitter.
+func (g *grower[T]) weight(data [][]T) float64 {
+	if weighted, ok := g.splitter.(WeightedSplitter[T]); ok {
+		return weighted.Weight(data)
+	}
+	return float64(len(data))
+}
+
+// The key of a node identifies its position in the tree; see childKey.
+func (g *grower[T]) growDepthFirst(data [][]T, state any, depth int, key uint64) *Node {
+	node := g.node(data)
+	split, ok := g.split(data, state, depth, key)
+	if !ok {
+		return node
+	}
+
+	node.Feature = split.Feature
+	node.Threshold = split.Threshold
+	node.Categories = split.Categories
+	node.Left = g.growDepthFirst(split.Left, split.LeftState, depth+1, childKey(key, false))
+	node.Right = g.growDepthFirst(split.Right, split.RightState, depth+1, childKey(key, true))
+	return node
+}
+
+func (g *grower[T]) growTasks(
+	w *workpool.Worker,
+	data [][]T,
+	state any,
+	depth int,
+	key uint64,
+	minTaskSamples int,
+) *Node {
+	if len(data) < minTaskSamples {
+		return g.growDepthFirst(data, state, depth, key)
+	}
+
+	node := g.node(data)
+	split, ok := g.split(data, state, depth, key)
+	if !ok {
+		return node
+	}
+
+	node.Feature = split.Feature
+	node.Threshold = split.Threshold
+	node.Categories = split.Categories
+	right := w.Fork(func(w *workpool.Worker) {
+		node.Right = g.growTasks(w, split.Right, split.RightState, depth+1, childKey(key, true), minTaskSamples)
+	})
+	node.Left = g.growTasks(w, split.Left, split.LeftState, depth+1, childKey(key, false), minTaskSamples)
+	w.Join(right)
+	return node
+}
+
+// node returns a leaf for data. Splitting it later keeps its statistics, so
+// pruning can turn it back into a leaf.
+func (g *grower[T]) node(data [][]T) *Node {
+	node := &Node{
+		Prediction: g.splitter.LeafValue(data),
+		Samples:    len(data),
+		Weight:     g.weight(data),
+		Impurity:   g.splitter.Impurity(data),
+	}
+	if classes, ok := g.splitter.(ClassSplitter[T]); ok {
+		node.Counts = classes.LeafCounts(data)
+		node.Distribution = shares(node.Counts)
+	}
+	return node
+}
+
+// split applies the stopping rules and returns the split to make at the node
+// of data at depth with key, or false when the node must become a leaf.
+func (g *grower[T]) split(data [][]T, state any, depth int, key uint64) (Split[T], bool) {
+	if len(data) == 0 ||
+		(g.opts.MaxDepth > 0 && depth >= g.opts.MaxDepth) ||
+		len(data) < g.opts.MinSamplesSplit {
+		return Split[T]{}, false
+	}
+
+	split, ok := g.search(data, state, key)
+	if !ok {
+		return Split[T]{}, false
+	}
+	if g.opts.MinImpurityDecrease > 0 &&
+		g.decrease(data, split) < g.opts.MinImpurityDecrease {
+		return Split[T]{}, false
+	}
+	return split, true
+}
+
+// search asks the splitter for the best split of the node, among a random
+// subset of the features when MaxFeatures is set. When none of the subset
+// can be split it searches every feature, so a node only becomes a leaf
+// early because of the rows it holds.
+func (g *grower[T]) search(data [][]T, state any, key uint64) (Split[T], bool) {
+	if random, ok := g.splitter.(RandomSplitter[T]); ok && g.opts.RandomThresholds {
+		return g.randomSplit(random, data, key)
+	}
+	if subspace, ok := g.splitter.(SubspaceSplitter[T]); ok {
+		n := subspace.NumCandidates()
+		if k := g.opts.MaxFeatures.Count(n); k < n {
+			split, ok := subspace.BestSplitAmong(data, state, sampleFeatures(n, k, g.opts.Seed, key))
+			if ok {
+				return split, true
+			}
+		}
+	}
+	if stateful, ok := g.splitter.(StatefulSplitter[T]); ok {
+		return stateful.BestSplitFrom(data, state)
+	}
+	return g.splitter.BestSplit(data)
+}
+
+// randomSplit is search for a RandomSplitter: it draws a split of every
+// feature, or of a random subset when MaxFeatures is set, and falls back to
+// every feature when none of the subset can be split.
+func (g *grower[T]) randomSplit(random RandomSplitter[T], data [][]T, key uint64) (Split[T], bool) {
+	n := random.NumCandidates()
+	seed := splitSeed(g.opts.Seed, key)
+	if k := g.opts.MaxFeatures.Count(n); k < n {
+		split, ok := random.RandomSplitAmong(data, sampleFeatures(n, k, g.opts.Seed, key), seed)
+		if ok {
+			return split, true
+		}
+	}
+	return random.RandomSplitAmong(data, allFeatureIndices(n), seed)
+}
+
+// decrease is the impurity decrease of split weighted by the share of the
+// training weight that reaches the node.
+func (g *grower[T]) decrease(data [][]T, split Split[T]) float64 {
+	fraction := g.weight(data) / g.total
+	return fraction * (g.splitter.Impurity(data) - split.Impurity)
+}
+
+// growBestFirst expands leaves in order of decreasing impurity decrease
+// until the tree has MaxLeafNodes leaves or no leaf can be split.
+func (g *grower[T]) growBestFirst(data [][]T) *Node {
+	root := g.node(data)
+	queue := &frontier[T]{}
+	g.push(queue, root, data, nil, 0, 0)
+
+	for leaves := 1; leaves < g.opts.MaxLeafNodes && queue.Len() > 0; leaves++ {
+		candidate := heap.Pop(queue).(*candidate[T])
+		node, split := candidate.node, candidate.split
+
+		node.Feature = split.Feature
+		node.Threshold = split.Threshold
+		node.Categories = split.Categories
+		node.Left = g.node(split.Left)
+		node.Right = g.node(split.Right)
+
+		g.push(queue, node.Left, split.Left, split.LeftState, candidate.depth+1, childKey(candidate.key, false))
+		g.push(queue, node.Right, split.Right, split.RightState, candidate.depth+1, childKey(candidate.key, true))
+	}
+	return root
+}
+
+func (g *grower[T]) push(queue *frontier[T], node *Node, data [][]T, state any, depth int, key uint64) {
+	split, ok := g.split(data, state, depth, key)
+	if !ok {
+		return
+	}
+	heap.Push(queue, &candidate[T]{
+		node:     node,
+		split:    split,
+		depth:    depth,
+		key:      key,
+		decrease: g.decrease(data, split),
+		order:    queue.pushed,
+	})
+	queue.pushed++
+}
+
+// candidate is a leaf waiting to be split during best-first growth.
+type candidate[T any] struct {
+	node     *Node
+	split    Split[T]
+	depth    int
+	key      uint64
+	decrease float64
+	order    int
+}
+
+// frontier is a max-heap of candidates by impurity decrease; ties go to the
+// candidate found first so growth is deterministic.
+type frontier[T any] struct {
+	items  []*candidate[T]
+	pushed int
+}
+
+func (f *frontier[T]) Len() int { return len(f.items) }
+
+func (f *frontier[T]) Less(i, j int) bool {
+	if f.items[i].decrease != f.items[j].decrease {
+		return f.items[i].decrease > f.items[j].decrease
+	}
+	return f.items[i].order < f.items[j].order
+}
+
+func (f *frontier[T]) Swap(i, j int) { f.items[i], f.items[j] = f.items[j], f.items[i] }
+
+func (f *frontier[T]) Push(x any) { f.items = append(f.items, x.(*candidate[T])) }
+
+func (f *frontier[T]) Pop() any {
+	last := f.items[len(f.items)-1]
+	f.items = f.items[:len(f.items)-1]
+	return last
+}
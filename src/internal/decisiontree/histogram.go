@@ -0,0 +1,365 @@
+package decisiontree
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// HistogramConfig describes the rows a HistogramSplitter bins and how the
+// tree it grows routes them.
+type HistogramConfig[T any] struct {
+	// Features lists the columns that may be split on.
+	Features []int
+	// Value returns a row's value for feature, or false when it has none.
+	// Rows without a value are left out of that feature's histogram.
+	Value func(row []T, feature int) (float64, bool)
+	// Label returns a row's label, which is a class index for classification
+	// criteria.
+	Label func(row []T) float64
+	// Weight returns a row's sample weight. Nil weighs every row 1.
+	Weight func(row []T) float64
+	// Partition splits data at a threshold chosen by the splitter.
+	Partition func(data [][]T, feature int, threshold float64) ([][]T, [][]T)
+	// Strict trees send rows below the threshold to the left instead of rows
+	// at or below it.
+	Strict bool
+	// Categorical reports whether feature holds category codes. Such a
+	// feature gets one bin per category and is split on a subset of them.
+	// Nil means no feature is categorical.
+	Categorical func(feature int) bool
+	// PartitionCategories splits data into the rows whose value for feature
+	// is one of categories and the rest. Only categorical features need it.
+	PartitionCategories func(data [][]T, feature int, categories []float64) ([][]T, [][]T)
+
+	Criterion      Criterion
+	MaxBins        int
+	MinSamplesLeaf int
+	// Parallel builds and searches each feature's histogram in its own
+	// goroutine.
+	Parallel bool
+}
+
+// HistogramSplitter finds splits on binned features. Bin edges are computed
+// once from the rows it is created with; every node then counts its rows per
+// bin and tries each bin edge as a threshold, which costs O(rows + bins) per
+// feature instead of a sort. After a split only the smaller child's histogram
+// is built and the larger child's is the parent's minus it.
+type HistogramSplitter[T any] struct {
+	// The embedded splitter supplies impurities and leaf values.
+	Splitter[T]
+	cfg HistogramConfig[T]
+	// edges[i] are the increasing bin upper bounds of cfg.Features[i].
+	edges [][]float64
+}
+
+// histogram holds the label statistics of one node's bins, indexed like
+// HistogramSplitter.edges.
+type histogram [][]Stats
+
+// NewHistogramSplitter bins the features of data and returns a splitter for
+// trees grown from it. base provides Impurity and LeafValue.
+func NewHistogramSplitter[T any](
+	data [][]T,
+	base Splitter[T],
+	cfg HistogramConfig[T],
+) *HistogramSplitter[T] {
+	s := &HistogramSplitter[T]{
+		Splitter: base,
+		cfg:      cfg,
+		edges:    make([][]float64, len(cfg.Features)),
+	}
+	s.forEachFeature(nil, func(i int) {
+		if s.categorical(cfg.Features[i]) {
+			s.edges[i] = s.categories(data, cfg.Features[i])
+		} else {
+			s.edges[i] = s.binEdges(data, cfg.Features[i])
+		}
+	})
+	return s
+}
+
+// binSampleSize bounds how many rows are sorted to place bin edges.
+const binSampleSize = 200000
+
+// binEdges returns up to MaxBins upper bounds at evenly spaced quantiles of
+// feature, estimated from an evenly strided sample of at most binSampleSize
+// rows. With no more unique values than bins every value is its own bin, so
+// the splits found are the exact ones.
+func (s *HistogramSplitter[T]) binEdges(data [][]T, feature int) []float64 {
+	stride := max(1, (len(data)+binSampleSize-1)/binSampleSize)
+	values := make([]float64, 0, len(data)/stride)
+	for r := 0; r < len(data); r += stride {
+		if v, ok := s.cfg.Value(data[r], feature); ok {
+			values = append(values, v)
+		}
+	}
+	sort.Float64s(values)
+
+	var edges []float64
+	for i, v := range values {
+		if i+1 < len(values) && values[i+1] == v {
+			continue
+		}
+		edges = append(edges, v)
+	}
+	if len(edges) <= s.cfg.MaxBins {
+		return edges
+	}
+
+	edges = edges[:0]
+	for b := 1; b <= s.cfg.MaxBins; b++ {
+		edge := values[b*len(values)/s.cfg.MaxBins-1]
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// categories returns every category of feature in data in increasing order,
+// each of which is its own bin.
+func (s *HistogramSplitter[T]) categories(data [][]T, feature int) []float64 {
+	seen := make(map[float64]bool)
+	var categories []float64
+	for _, row := range data {
+		if v, ok := s.cfg.Value(row, feature); ok && !seen[v] {
+			seen[v] = true
+			categories = append(categories, v)
+		}
+	}
+	sort.Float64s(categories)
+	return categories
+}
+
+func (s *HistogramSplitter[T]) categorical(feature int) bool {
+	return s.cfg.Categorical != nil && s.cfg.Categorical(feature)
+}
+
+// LeafCounts forwards to the base splitter, or returns nil when it does not
+// grow a classifier.
+func (s *HistogramSplitter[T]) LeafCounts(data [][]T) []float64 {
+	if classes, ok := s.Splitter.(ClassSplitter[T]); ok {
+		return classes.LeafCounts(data)
+	}
+	return nil
+}
+
+// Weight forwards to the base splitter, or counts the rows of data when it
+// does not weigh them.
+func (s *HistogramSplitter[T]) Weight(data [][]T) float64 {
+	if weighted, ok := s.Splitter.(WeightedSplitter[T]); ok {
+		return weighted.Weight(data)
+	}
+	return float64(len(data))
+}
+
+func (s *HistogramSplitter[T]) BestSplit(data [][]T) (Split[T], bool) {
+	return s.BestSplitFrom(data, nil)
+}
+
+// BestSplitFrom searches the node's histogram, building it when the parent
+// did not pass one down.
+func (s *HistogramSplitter[T]) BestSplitFrom(data [][]T, state any) (Split[T], bool) {
+	return s.BestSplitAmong(data, state, nil)
+}
+
+// NumCandidates returns the number of features in the configuration.
+func (s *HistogramSplitter[T]) NumCandidates() int {
+	return len(s.cfg.Features)
+}
+
+// BestSplitAmong is BestSplitFrom searching only the features at the given
+// indices of the configuration's Features, or every feature when indices is
+// nil. The children's histograms still cover every feature.
+func (s *HistogramSplitter[T]) BestSplitAmong(data [][]T, state any, indices []int) (Split[T], bool) {
+	hist, ok := state.(histogram)
+	if !ok {
+		hist = s.build(data)
+	}
+
+	type candidate struct {
+		edge, impurity float64
+		categories     []float64
+	}
+	candidates := make([]candidate, len(s.cfg.Features))
+	for i := range candidates {
+		candidates[i].impurity = math.Inf(1)
+	}
+	s.forEachFeature(indices, func(i int) {
+		if s.categorical(s.cfg.Features[i]) {
+			candidates[i].categories, candidates[i].impurity = bestCategorySplit(
+				s.edges[i], hist[i], s.cfg.Criterion, s.cfg.MinSamplesLeaf)
+			return
+		}
+		candidates[i].edge, candidates[i].impurity = s.searchFeature(hist[i], s.edges[i])
+	})
+
+	best := -1
+	for i, c := range candidates {
+		if best == -1 || c.impurity < candidates[best].impurity {
+			best = i
+		}
+	}
+	if best == -1 || math.IsInf(candidates[best].impurity, 1) {
+		return Split[T]{}, false
+	}
+
+	feature := s.cfg.Features[best]
+	split := Split[T]{Feature: feature, Impurity: candidates[best].impurity}
+	if categories := candidates[best].categories; categories != nil {
+		split.Categories = categories
+		split.Left, split.Right = s.cfg.PartitionCategories(data, feature, categories)
+	} else {
+		split.Threshold = candidates[best].edge
+		if s.cfg.Strict {
+			split.Threshold = math.Nextafter(split.Threshold, math.Inf(1))
+		}
+		split.Left, split.Right = s.cfg.Partition(data, feature, split.Threshold)
+	}
+	split.LeftState, split.RightState = s.childHistograms(hist, data, split.Left, split.Right)
+	return split, true
+}
+
+// searchFeature returns the bin edge with the lowest weighted impurity, or
+// +Inf when none leaves MinSamplesLeaf rows on both sides. Empty bins are
+// skipped so every threshold tried moves at least one row.
+func (s *HistogramSplitter[T]) searchFeature(bins []Stats, edges []float64) (float64, float64) {
+	left, right := s.cfg.Criterion.NewStats(), s.cfg.Criterion.NewStats()
+	for _, b := range bins {
+		right.Merge(b)
+	}
+
+	bestEdge := 0.0
+	bestImpurity := math.Inf(1)
+	for i, b := range bins {
+		if b.Count() == 0 {
+			continue
+		}
+		left.Merge(b)
+		right.Subtract(b)
+
+		if left.Count() < s.cfg.MinSamplesLeaf || right.Count() < s.cfg.MinSamplesLeaf {
+			continue
+		}
+		impurity := weightedImpurity(left, right)
+		if impurity < bestImpurity {
+			bestImpurity = impurity
+			bestEdge = edges[i]
+		}
+	}
+	return bestEdge, bestImpurity
+}
+
+// childHistograms builds the smaller child's histogram and subtracts it from
+// the parent's for the other. When the partition dropped rows the parent no
+// longer equals the sum of its children, so both are built.
+func (s *HistogramSplitter[T]) childHistograms(
+	parent histogram,
+	data, left, right [][]T,
+) (histogram, histogram) {
+	if len(left)+len(right) != len(data) {
+		return s.build(left), s.build(right)
+	}
+	if len(left) <= len(right) {
+		small := s.build(left)
+		return small, s.subtract(parent, small)
+	}
+	small := s.build(right)
+	return s.subtract(parent, small), small
+}
+
+func (s *HistogramSplitter[T]) build(data [][]T) histogram {
+	hist := make(histogram, len(s.cfg.Features))
+	s.forEachFeature(nil, func(i int) {
+		feature, edges := s.cfg.Features[i], s.edges[i]
+		bins := make([]Stats, len(edges))
+		for b := range bins {
+			bins[b] = s.cfg.Criterion.NewStats()
+		}
+		for _, row := range data {
+			v, ok := s.cfg.Value(row, feature)
+			if !ok || len(edges) == 0 {
+				continue
+			}
+			// Values past the last edge, which only rows left out of the
+			// binning sample can have, fall into the last bin.
+			b := min(sort.SearchFloat64s(edges, v), len(edges)-1)
+			bins[b].AddWeighted(s.cfg.Label(row), s.weight(row))
+		}
+		hist[i] = bins
+	})
+	return hist
+}
+
+func (s *HistogramSplitter[T]) weight(row []T) float64 {
+	if s.cfg.Weight == nil {
+		return 1
+	}
+	return s.cfg.Weight(row)
+}
+
+func (s *HistogramSplitter[T]) subtract(parent, child histogram) histogram {
+	hist := make(histogram, len(parent))
+	for i := range parent {
+		hist[i] = make([]Stats, len(parent[i]))
+		for b := range parent[i] {
+			hist[i][b] = parent[i][b].Clone()
+			hist[i][b].Subtract(child[i][b])
+		}
+	}
+	return hist
+}
+
+// forEachFeature calls fn with each of indices, or the index of every
+// feature when indices is nil, concurrently when the splitter is parallel.
+// Each call must only write to its own index.
+func (s *HistogramSplitter[T]) forEachFeature(indices []int, fn func(i int)) {
+	if indices == nil {
+		indices = allFeatureIndices(len(s.cfg.Features))
+	}
+	if !s.cfg.Parallel {
+		for _, i := range indices {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range indices {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// histogramConfig is the configuration for this package's float64 trees,
+// whose label is the last column, preceded by the weight in weighted data,
+// and whose rows at or below a threshold go left.
+func histogramConfig(numFeatures int, opts Options, parallel, weighted bool) HistogramConfig[float64] {
+	features := make([]int, numFeatures)
+	for i := range features {
+		features[i] = i
+	}
+	cfg := HistogramConfig[float64]{
+		Features:       features,
+		Value:          func(row []float64, feature int) (float64, bool) { return row[feature], true },
+		Label:          func(row []float64) float64 { return row[len(row)-1] },
+		Partition:      splitData,
+		Criterion:      opts.criterion(),
+		MaxBins:        opts.MaxBins,
+		MinSamplesLeaf: opts.MinSamplesLeaf,
+		Parallel:       parallel,
+	}
+	if len(opts.Categorical) > 0 {
+		cfg.Categorical = opts.categorical
+		cfg.PartitionCategories = splitCategories
+	}
+	if weighted {
+		cfg.Weight = func(row []float64) float64 { return rowWeight(row, true) }
+	}
+	return cfg
+}
@@ -0,0 +1,122 @@
+package decisiontree
+
+import (
+	"fmt"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestHistogramWithEnoughBinsMatchesExactSearch(t *testing.T) {
+	data := harness.DiscreteClassification(600, 5, 6, 0.15, 8)
+
+	for name, opts := range map[string]Options{
+		"depth first": {MaxDepth: 6, MinSamplesLeaf: 2},
+		"best first":  {MaxDepth: 8, MaxLeafNodes: 12},
+	} {
+		binned := opts
+		binned.MaxBins = 6
+
+		trees := map[string][2]trainedTree{
+			"sequential": {NewSequentialDecisionTreeWithOptions(opts), NewSequentialDecisionTreeWithOptions(binned)},
+			"concurrent": {NewConcurrentDecisionTreeWithOptions(opts), NewConcurrentDecisionTreeWithOptions(binned)},
+		}
+		for tree, pair := range trees {
+			exact, histogram := pair[0], pair[1]
+			exact.Train(data)
+			histogram.Train(data)
+			if err := compareNodes(treeRoot(exact), treeRoot(histogram), "root"); err != nil {
+				t.Errorf("%s, %s: %v", name, tree, err)
+			}
+		}
+	}
+}
+
+func TestHistogramSubtractionMatchesDirectBuild(t *testing.T) {
+	data := harness.Classification(500, 4, 0.1, 9)
+	cfg := histogramConfig(4, Options{MaxBins: 16}, false, false)
+	s := NewHistogramSplitter[float64](data, sequentialSplitter{}, cfg)
+
+	split, ok := s.BestSplit(data)
+	if !ok {
+		t.Fatal("no split found")
+	}
+	for side, got := range map[string]any{"left": split.LeftState, "right": split.RightState} {
+		rows := split.Left
+		if side == "right" {
+			rows = split.Right
+		}
+		want := s.build(rows)
+		for i := range want {
+			for b := range want[i] {
+				w, g := want[i][b], got.(histogram)[i][b]
+				if g.Count() != w.Count() || g.Impurity() != w.Impurity() {
+					t.Fatalf("%s histogram, feature %d bin %d: %d rows with impurity %v, want %d with %v",
+						side, i, b, g.Count(), g.Impurity(), w.Count(), w.Impurity())
+				}
+			}
+		}
+	}
+}
+
+func TestHistogramThresholdsAreBinEdges(t *testing.T) {
+	data := harness.Classification(3000, 4, 0.05, 10)
+	train, test := data[:2000], data[2000:]
+
+	exact := NewSequentialDecisionTree()
+	exact.Train(train)
+	cfg := histogramConfig(4, Options{MaxBins: 16}, false, false)
+	edges := NewHistogramSplitter[float64](train, sequentialSplitter{}, cfg).edges
+
+	opts := DefaultOptions()
+	opts.MaxBins = 16
+	histogram := NewConcurrentDecisionTreeWithOptions(opts)
+	histogram.Train(train)
+
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		if node.Left == nil && node.Right == nil {
+			return
+		}
+		found := false
+		for _, edge := range edges[node.Feature] {
+			found = found || edge == node.Threshold
+		}
+		if !found {
+			t.Errorf("threshold %v of feature %d is not a bin edge", node.Threshold, node.Feature)
+		}
+		walk(node.Left)
+		walk(node.Right)
+	}
+	walk(histogram.root)
+
+	for i, e := range edges {
+		if len(e) > 16 {
+			t.Errorf("feature %d has %d bins", i, len(e))
+		}
+	}
+
+	exactAccuracy := harness.Accuracy(harness.Predictions(exact, test), test, 0.5)
+	histogramAccuracy := harness.Accuracy(harness.Predictions(histogram, test), test, 0.5)
+	if histogramAccuracy < exactAccuracy-0.05 {
+		t.Fatalf("histogram accuracy %.3f, exact %.3f", histogramAccuracy, exactAccuracy)
+	}
+}
+
+func BenchmarkHistogramTrain(b *testing.B) {
+	for _, rows := range []int{10000, 100000, 1000000} {
+		data := harness.Classification(rows, 8, 0.1, 1)
+		opts := DefaultOptions()
+		opts.MaxBins = 255
+		b.Run(fmt.Sprintf("sequential/rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewSequentialDecisionTreeWithOptions(opts).Train(data)
+			}
+		})
+		b.Run(fmt.Sprintf("concurrent/rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewConcurrentDecisionTreeWithOptions(opts).Train(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package decisiontree
+
+// ImpurityDecreases returns, for each of numFeatures features, the weighted
+// impurity decrease of the splits on it in the tree rooted at root. A split
+// of node t with children l and r decreases impurity by
+//
+//	W_t/W * (impurity_t - (W_l*impurity_l + W_r*impurity_r) / (W_l + W_r))
+//
+// where W_t is the Weight of node t and W the root's, the quantity
+// MinImpurityDecrease bounds. The children's own weights weigh them, so rows
+// a split could not route do not count as a decrease.
+func ImpurityDecreases(root *Node, numFeatures int) []float64 {
+	decreases := make([]float64, numFeatures)
+	if root == nil || root.Weight == 0 {
+		return decreases
+	}
+	total := root.Weight
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if isLeaf(node) {
+			return
+		}
+		left, right := node.Left, node.Right
+		if children := left.Weight + right.Weight; children > 0 {
+			weighted := (left.Weight*left.Impurity + right.Weight*right.Impurity) / children
+			if node.Feature < numFeatures {
+				decreases[node.Feature] += node.Weight / total * (node.Impurity - weighted)
+			}
+		}
+		visit(left)
+		visit(right)
+	}
+	visit(root)
+	return decreases
+}
+
+// FeatureImportances returns the impurity decreases of the tree rooted at
+// root normalized to sum to 1. A tree without splits gives every feature 0.
+func FeatureImportances(root *Node, numFeatures int) []float64 {
+	return normalize(ImpurityDecreases(root, numFeatures))
+}
+
+// MeanImportances averages the normalized importances of several trees and
+// normalizes the result, which is how forests rank features.
+func MeanImportances(importances [][]float64) []float64 {
+	if len(importances) == 0 {
+		return nil
+	}
+	mean := make([]float64, len(importances[0]))
+	for _, tree := range importances {
+		for i, value := range tree {
+			mean[i] += value / float64(len(importances))
+		}
+	}
+	return normalize(mean)
+}
+
+// normalize scales values in place to sum to 1 unless they sum to 0.
+func normalize(values []float64) []float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	if sum <= 0 {
+		return values
+	}
+	for i := range values {
+		values[i] /= sum
+	}
+	return values
+}
+
+// FeatureImportances returns the normalized impurity decrease of every
+// feature of the data the tree was trained on.
+func (dt *SequentialDecisionTree) FeatureImportances() []float64 {
+	return FeatureImportances(dt.root, dt.numFeatures)
+}
+
+// FeatureImportances returns the normalized impurity decrease of every
+// feature of the data the tree was trained on.
+func (dt *ConcurrentDecisionTree) FeatureImportances() []float64 {
+	return FeatureImportances(dt.root, dt.numFeatures)
+}
@@ -0,0 +1,89 @@
+package decisiontree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// informativeData returns rows whose label depends only on feature 0, with
+// features 1 and 2 random noise.
+func informativeData(rows int, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	data := make([][]float64, rows)
+	for i := range data {
+		x := rng.Float64()*2 - 1
+		label := 0.0
+		if x > 0.2 {
+			label = 1
+		}
+		data[i] = []float64{x, rng.Float64(), rng.Float64(), label}
+	}
+	return data
+}
+
+func TestFeatureImportancesRankTheInformativeFeature(t *testing.T) {
+	data := informativeData(600, 3)
+	sequential := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6})
+	sequential.Train(data)
+	concurrent := NewConcurrentDecisionTreeWithOptions(Options{MaxDepth: 6})
+	concurrent.Train(data)
+
+	importances := sequential.FeatureImportances()
+	if len(importances) != 3 {
+		t.Fatalf("%d importances for 3 features", len(importances))
+	}
+	sum := 0.0
+	for _, value := range importances {
+		sum += value
+	}
+	if !harness.WithinTolerance(sum, 1, 1e-12) {
+		t.Errorf("importances sum to %g", sum)
+	}
+	if importances[0] < 0.9 {
+		t.Errorf("informative feature has importance %g: %v", importances[0], importances)
+	}
+	if diff := harness.MaxAbsDiff(importances, concurrent.FeatureImportances()); diff > treeTolerance {
+		t.Errorf("sequential and concurrent importances differ by %g", diff)
+	}
+}
+
+func TestImpurityDecreasesOfAStump(t *testing.T) {
+	data := harness.Classification(400, 3, 0.1, 9)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1})
+	tree.Train(data)
+	root := tree.root
+
+	decreases := ImpurityDecreases(root, 3)
+	n, l, r := float64(root.Samples), float64(root.Left.Samples), float64(root.Right.Samples)
+	want := root.Impurity - (l*root.Left.Impurity+r*root.Right.Impurity)/n
+	for feature, got := range decreases {
+		switch {
+		case feature == root.Feature && math.Abs(got-want) > treeTolerance:
+			t.Errorf("split feature decreases impurity by %g, want %g", got, want)
+		case feature != root.Feature && got != 0:
+			t.Errorf("feature %d is not split on but decreases impurity by %g", feature, got)
+		}
+	}
+	if importances := tree.FeatureImportances(); importances[root.Feature] != 1 {
+		t.Errorf("a stump's split feature has importance %g", importances[root.Feature])
+	}
+
+	leaf := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 0, MinSamplesSplit: len(data) + 1})
+	leaf.Train(data)
+	for _, value := range leaf.FeatureImportances() {
+		if value != 0 {
+			t.Fatalf("a tree without splits has importances %v", leaf.FeatureImportances())
+		}
+	}
+}
+
+func TestMeanImportances(t *testing.T) {
+	got := MeanImportances([][]float64{{1, 0, 0}, {0.5, 0.5, 0}, {0, 0, 0}})
+	want := []float64{0.75, 0.25, 0}
+	if diff := harness.MaxAbsDiff(got, want); diff > treeTolerance {
+		t.Fatalf("MeanImportances = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package decisiontree
+
+// Options controls when a tree stops growing. Zero or negative values
+// disable the corresponding limit.
+type Options struct {
+	// MaxDepth is the deepest level a split may happen at.
+	MaxDepth int
+	// MinSamplesSplit is the fewest rows a node needs to be split.
+	MinSamplesSplit int
+	// MinSamplesLeaf is the fewest rows each side of a split must keep. With
+	// 0 a split may leave one side empty.
+	MinSamplesLeaf int
+	// MinImpurityDecrease is the least weighted impurity decrease a split
+	// must achieve, W_t/W * (impurity - weighted child impurity), where W_t
+	// is the node's Weight and W the root's.
+	MinImpurityDecrease float64
+	// MaxLeafNodes caps the number of leaves. When set, the tree grows best
+	// first, always expanding the leaf with the largest impurity decrease.
+	MaxLeafNodes int
+	// MaxBins switches split finding to histograms: each feature is
+	// quantized into at most MaxBins bins before growing and only bin edges
+	// are tried as thresholds. Zero searches every value exactly.
+	MaxBins int
+	// Criterion is the impurity splits minimize. Nil means Gini.
+	Criterion Criterion
+	// CCPAlpha prunes the grown tree with minimal cost-complexity pruning at
+	// this complexity parameter. Zero keeps the whole tree.
+	CCPAlpha float64
+	// Categorical lists the features that hold category codes instead of
+	// ordered values. They are split on subsets of their categories.
+	Categorical []int
+	// MaxFeatures limits each split to a random subset of the features,
+	// drawn anew at every node. The zero value searches them all.
+	MaxFeatures MaxFeatures
+	// RandomThresholds draws each feature's split at random instead of
+	// searching it, as extremely randomized trees do: a threshold uniform
+	// between the lowest and highest value of the node's rows, or a random
+	// subset of a categorical feature's categories. The feature whose drawn
+	// split has the lowest impurity wins. Histograms are not used, since
+	// drawing a split already costs one pass over the rows.
+	RandomThresholds bool
+	// Seed fixes the feature subsets MaxFeatures draws and the splits
+	// RandomThresholds draws. Each node draws from a stream of its own, so a
+	// tree grown concurrently makes the same draws as one grown sequentially.
+	Seed int64
+	// Smoothing adjusts the class probabilities PredictProba returns. It does
+	// not change how the tree grows.
+	Smoothing Smoothing
+}
+
+// DefaultRegressionOptions returns DefaultOptions with the MSE criterion, for
+// trees whose last column is a continuous target. Leaves predict the mean
+// target, or the median with MAE.
+func DefaultRegressionOptions() Options {
+	opts := DefaultOptions()
+	opts.Criterion = MSE
+	return opts
+}
+
+func (o Options) criterion() Criterion {
+	if o.Criterion == nil {
+		return Gini
+	}
+	return o.Criterion
+}
+
+// DefaultOptions returns the limits SequentialDecisionTree and
+// ConcurrentDecisionTree have always used.
+func DefaultOptions() Options {
+	return Options{MaxDepth: 5}
+}
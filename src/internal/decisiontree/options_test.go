@@ -0,0 +1,165 @@
+package decisiontree
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+type trainedTree interface {
+	Train(data [][]float64)
+	Predict(sample []float64) float64
+}
+
+func treeRoot(tree trainedTree) *Node {
+	switch t := tree.(type) {
+	case *SequentialDecisionTree:
+		return t.root
+	case *ConcurrentDecisionTree:
+		return t.root
+	}
+	return nil
+}
+
+func depthOf(node *Node) int {
+	if node.Left == nil && node.Right == nil {
+		return 0
+	}
+	return 1 + max(depthOf(node.Left), depthOf(node.Right))
+}
+
+func leavesOf(node *Node) int {
+	if node.Left == nil && node.Right == nil {
+		return 1
+	}
+	return leavesOf(node.Left) + leavesOf(node.Right)
+}
+
+// leafSizes counts the training rows that reach each leaf.
+func leafSizes(node *Node, data [][]float64) map[*Node]int {
+	sizes := make(map[*Node]int)
+	for _, row := range data {
+		n := node
+		for n.Left != nil || n.Right != nil {
+			if row[n.Feature] <= n.Threshold {
+				n = n.Left
+			} else {
+				n = n.Right
+			}
+		}
+		sizes[n]++
+	}
+	return sizes
+}
+
+func TestOptionsAreHonouredByBothTrees(t *testing.T) {
+	data := harness.Classification(600, 4, 0.2, 5)
+
+	tests := map[string]struct {
+		opts  Options
+		check func(t *testing.T, root *Node)
+	}{
+		"max depth": {
+			opts: Options{MaxDepth: 3},
+			check: func(t *testing.T, root *Node) {
+				if d := depthOf(root); d > 3 {
+					t.Errorf("depth %d exceeds 3", d)
+				}
+			},
+		},
+		"min samples leaf": {
+			opts: Options{MaxDepth: 8, MinSamplesLeaf: 25},
+			check: func(t *testing.T, root *Node) {
+				for leaf, size := range leafSizes(root, data) {
+					if size < 25 {
+						t.Errorf("leaf %p holds %d rows, want at least 25", leaf, size)
+					}
+				}
+			},
+		},
+		"min samples split": {
+			opts: Options{MaxDepth: 8, MinSamplesSplit: 200},
+			check: func(t *testing.T, root *Node) {
+				var walk func(node *Node, rows [][]float64)
+				walk = func(node *Node, rows [][]float64) {
+					if node.Left == nil && node.Right == nil {
+						return
+					}
+					if len(rows) < 200 {
+						t.Errorf("node with %d rows was split", len(rows))
+					}
+					left, right := splitData(rows, node.Feature, node.Threshold)
+					walk(node.Left, left)
+					walk(node.Right, right)
+				}
+				walk(root, data)
+			},
+		},
+		"min impurity decrease": {
+			opts: Options{MaxDepth: 8, MinImpurityDecrease: 0.01},
+			check: func(t *testing.T, root *Node) {
+				var walk func(node *Node, rows [][]float64)
+				walk = func(node *Node, rows [][]float64) {
+					if node.Left == nil && node.Right == nil {
+						return
+					}
+					left, right := splitData(rows, node.Feature, node.Threshold)
+					child := calculateImpurityIndex(rows, node.Feature, node.Threshold, 0, Gini)
+					decrease := float64(len(rows)) / float64(len(data)) * (nodeImpurity(rows, Gini, false) - child)
+					if decrease < 0.01 {
+						t.Errorf("split with weighted decrease %g was kept", decrease)
+					}
+					walk(node.Left, left)
+					walk(node.Right, right)
+				}
+				walk(root, data)
+			},
+		},
+		"max leaf nodes": {
+			opts: Options{MaxLeafNodes: 7},
+			check: func(t *testing.T, root *Node) {
+				if leaves := leavesOf(root); leaves != 7 {
+					t.Errorf("%d leaves, want 7", leaves)
+				}
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sequential := NewSequentialDecisionTreeWithOptions(tt.opts)
+			sequential.Train(data)
+			concurrent := NewConcurrentDecisionTreeWithOptions(tt.opts)
+			concurrent.Train(data)
+
+			for _, tree := range []trainedTree{sequential, concurrent} {
+				tt.check(t, treeRoot(tree))
+			}
+			if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDefaultOptionsKeepDepthFive(t *testing.T) {
+	data := harness.Classification(400, 6, 0.3, 9)
+	tree := NewSequentialDecisionTree()
+	tree.Train(data)
+	if d := depthOf(tree.root); d != 5 {
+		t.Fatalf("depth %d, want 5", d)
+	}
+}
+
+func TestMaxLeafNodesExpandsBestLeafFirst(t *testing.T) {
+	data := harness.Classification(500, 3, 0.05, 13)
+
+	stump := NewSequentialDecisionTreeWithOptions(Options{MaxLeafNodes: 2})
+	stump.Train(data)
+	depthFirst := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1})
+	depthFirst.Train(data)
+
+	if err := compareNodes(depthFirst.root, stump.root, "root"); err != nil {
+		t.Fatalf("a two-leaf tree should be the depth-one stump: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package decisiontree
+
+import "math"
+
+// PruningPath is the sequence of subtrees minimal cost-complexity pruning
+// goes through, from the whole tree to its root alone. Subtree i minimizes
+// R(T) + alpha*|leaves(T)| for alpha in [Alphas[i], Alphas[i+1]), where R(T)
+// is Impurities[i], the sum over the leaves of their impurity weighted by the
+// share of the training weight they hold.
+type PruningPath struct {
+	Alphas     []float64
+	Impurities []float64
+}
+
+// CostComplexityPath returns the pruning path of the tree rooted at root.
+// The first alpha is 0 and the last one collapses the tree to its root.
+func CostComplexityPath(root *Node) PruningPath {
+	tree := cloneNode(root)
+	path := PruningPath{Alphas: []float64{0}, Impurities: []float64{subtreeRisk(tree, tree.Weight)}}
+	for !isLeaf(tree) {
+		alpha := collapseWeakestLinks(tree, tree.Weight, math.Inf(1))
+		// Rounding can make a later weakest link look slightly weaker than
+		// the previous one; the path is nondecreasing by construction.
+		alpha = math.Max(alpha, path.Alphas[len(path.Alphas)-1])
+		path.Alphas = append(path.Alphas, alpha)
+		path.Impurities = append(path.Impurities, subtreeRisk(tree, tree.Weight))
+	}
+	return path
+}
+
+// PruneCostComplexity returns a copy of root pruned at alpha: the weakest
+// links, the internal nodes whose subtree lowers the weighted impurity least
+// per extra leaf, are collapsed into leaves while that gain is at most alpha.
+// The tree must have been grown with this package, so every node knows its
+// weight, impurity and prediction.
+func PruneCostComplexity(root *Node, alpha float64) *Node {
+	tree := cloneNode(root)
+	for !isLeaf(tree) {
+		if math.IsInf(collapseWeakestLinks(tree, tree.Weight, alpha), 1) {
+			break
+		}
+	}
+	numberNodes(tree)
+	return tree
+}
+
+// collapseWeakestLinks turns every internal node with the lowest effective
+// alpha into a leaf, provided that alpha is at most limit, and returns it.
+// It returns +Inf without changing the tree when the weakest link is above
+// limit.
+func collapseWeakestLinks(root *Node, total float64, limit float64) float64 {
+	weakest := math.Inf(1)
+	var links []*Node
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if isLeaf(node) {
+			return
+		}
+		alpha := effectiveAlpha(node, total)
+		if alpha < weakest {
+			weakest = alpha
+			links = links[:0]
+		}
+		if alpha == weakest {
+			links = append(links, node)
+		}
+		visit(node.Left)
+		visit(node.Right)
+	}
+	visit(root)
+
+	if weakest > limit {
+		return math.Inf(1)
+	}
+	for _, node := range links {
+		collapse(node)
+	}
+	return weakest
+}
+
+// effectiveAlpha is the alpha at which collapsing node costs as much as the
+// leaves its subtree adds: (R(node) - R(subtree)) / (leaves - 1).
+func effectiveAlpha(node *Node, total float64) float64 {
+	gain := nodeRisk(node, total) - subtreeRisk(node, total)
+	return math.Max(0, gain) / float64(countLeaves(node)-1)
+}
+
+func nodeRisk(node *Node, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return node.Weight / total * node.Impurity
+}
+
+func subtreeRisk(node *Node, total float64) float64 {
+	if isLeaf(node) {
+		return nodeRisk(node, total)
+	}
+	return subtreeRisk(node.Left, total) + subtreeRisk(node.Right, total)
+}
+
+// PruneReducedError returns a copy of root in which, bottom-up, every subtree
+// that does not make fewer errors on validation than a leaf in its place is
+// replaced by that leaf. partition must route rows the way node does and
+// label returns a row's label. Classification nodes count misclassified rows
+// and regression nodes add up squared errors.
+func PruneReducedError[T any](
+	root *Node,
+	validation [][]T,
+	partition func(data [][]T, node *Node) ([][]T, [][]T),
+	label func(row []T) float64,
+) *Node {
+	tree := cloneNode(root)
+	var prune func(node *Node, rows [][]T) float64
+	prune = func(node *Node, rows [][]T) float64 {
+		asLeaf := 0.0
+		for _, row := range rows {
+			asLeaf += leafError(node, label(row))
+		}
+		if isLeaf(node) {
+			return asLeaf
+		}
+
+		left, right := partition(rows, node)
+		subtree := prune(node.Left, left) + prune(node.Right, right)
+		if asLeaf <= subtree {
+			collapse(node)
+			return asLeaf
+		}
+		return subtree
+	}
+	prune(tree, validation)
+	numberNodes(tree)
+	return tree
+}
+
+func leafError(node *Node, label float64) float64 {
+	if node.Distribution != nil {
+		if Argmax(node.Distribution) != ClassOf(label) {
+			return 1
+		}
+		return 0
+	}
+	diff := node.Prediction - label
+	return diff * diff
+}
+
+func isLeaf(node *Node) bool {
+	return node.Left == nil && node.Right == nil
+}
+
+func countLeaves(node *Node) int {
+	if isLeaf(node) {
+		return 1
+	}
+	return countLeaves(node.Left) + countLeaves(node.Right)
+}
+
+func collapse(node *Node) {
+	node.Feature, node.Threshold, node.Categories = 0, 0, nil
+	node.Left, node.Right = nil, nil
+}
+
+func cloneNode(node *Node) *Node {
+	if node == nil {
+		return nil
+	}
+	clone := *node
+	clone.Left = cloneNode(node.Left)
+	clone.Right = cloneNode(node.Right)
+	return &clone
+}
+
+// CostComplexityPath returns the pruning path of the trained tree.
+func (dt *SequentialDecisionTree) CostComplexityPath() PruningPath {
+	return CostComplexityPath(dt.root)
+}
+
+// Prune replaces the tree with its cost-complexity pruning at alpha.
+func (dt *SequentialDecisionTree) Prune(alpha float64) {
+	dt.root = PruneCostComplexity(dt.root, alpha)
+}
+
+// PruneReducedError prunes the tree against validation, whose last column is
+// the label.
+func (dt *SequentialDecisionTree) PruneReducedError(validation [][]float64) {
+	dt.root = PruneReducedError(dt.root, validation, splitNode, lastColumn)
+}
+
+// CostComplexityPath returns the pruning path of the trained tree.
+func (dt *ConcurrentDecisionTree) CostComplexityPath() PruningPath {
+	return CostComplexityPath(dt.root)
+}
+
+// Prune replaces the tree with its cost-complexity pruning at alpha.
+func (dt *ConcurrentDecisionTree) Prune(alpha float64) {
+	dt.root = PruneCostComplexity(dt.root, alpha)
+}
+
+// PruneReducedError prunes the tree against validation, whose last column is
+// the label.
+func (dt *ConcurrentDecisionTree) PruneReducedError(validation [][]float64) {
+	dt.root = PruneReducedError(dt.root, validation, splitNode, lastColumn)
+}
+
+func lastColumn(row []float64) float64 {
+	return row[len(row)-1]
+}
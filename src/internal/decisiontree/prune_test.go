@@ -0,0 +1,159 @@
+package decisiontree
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// prunings returns every tree obtained by collapsing some internal nodes of
+// node into leaves.
+func prunings(node *Node) []*Node {
+	leaf := *node
+	leaf.Left, leaf.Right = nil, nil
+	if isLeaf(node) {
+		return []*Node{&leaf}
+	}
+	trees := []*Node{&leaf}
+	for _, left := range prunings(node.Left) {
+		for _, right := range prunings(node.Right) {
+			tree := *node
+			tree.Left, tree.Right = left, right
+			trees = append(trees, &tree)
+		}
+	}
+	return trees
+}
+
+func TestCostComplexityPathIsOptimal(t *testing.T) {
+	data := harness.Classification(300, 3, 0.2, 14)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 3})
+	tree.Train(data)
+	path := tree.CostComplexityPath()
+
+	if path.Alphas[0] != 0 || len(path.Alphas) != len(path.Impurities) {
+		t.Fatalf("path %+v", path)
+	}
+	for i := 1; i < len(path.Alphas); i++ {
+		if path.Alphas[i] < path.Alphas[i-1] || path.Impurities[i] < path.Impurities[i-1]-1e-12 {
+			t.Fatalf("path is not monotonic at %d: %+v", i, path)
+		}
+	}
+	if last := PruneCostComplexity(tree.root, path.Alphas[len(path.Alphas)-1]); !isLeaf(last) {
+		t.Fatal("the last alpha should leave only the root")
+	}
+
+	candidates := prunings(tree.root)
+	for i, alpha := range path.Alphas {
+		pruned := PruneCostComplexity(tree.root, alpha)
+		risk := subtreeRisk(pruned, tree.root.Weight)
+		if !harness.WithinTolerance(risk, path.Impurities[i], 1e-12) {
+			t.Errorf("alpha %g: impurity %g, path says %g", alpha, risk, path.Impurities[i])
+		}
+
+		cost := risk + alpha*float64(countLeaves(pruned))
+		for _, candidate := range candidates {
+			other := subtreeRisk(candidate, tree.root.Weight) + alpha*float64(countLeaves(candidate))
+			if other < cost-1e-12 {
+				t.Fatalf("alpha %g: a pruning costs %g, less than the chosen %g", alpha, other, cost)
+			}
+		}
+	}
+}
+
+func TestCCPAlphaOptionPrunesAfterGrowing(t *testing.T) {
+	data := harness.Classification(500, 4, 0.2, 6)
+	full := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 8})
+	full.Train(data)
+	path := full.CostComplexityPath()
+	alpha := path.Alphas[len(path.Alphas)/2]
+
+	sequential := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 8, CCPAlpha: alpha})
+	sequential.Train(data)
+	concurrent := NewConcurrentDecisionTreeWithOptions(Options{MaxDepth: 8, CCPAlpha: alpha})
+	concurrent.Train(data)
+
+	if leavesOf(sequential.root) >= leavesOf(full.root) {
+		t.Errorf("pruned tree has %d leaves, full tree %d", leavesOf(sequential.root), leavesOf(full.root))
+	}
+	full.Prune(alpha)
+	if err := compareNodes(full.root, sequential.root, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReducedErrorPruning(t *testing.T) {
+	data := harness.Classification(900, 4, 0.25, 19)
+	train, validation := data[:600], data[600:]
+
+	tree := NewConcurrentDecisionTreeWithOptions(Options{MaxDepth: 10})
+	tree.Train(train)
+	original := tree.root
+	originalLeaves := leavesOf(original)
+	tree.PruneReducedError(validation)
+
+	if leavesOf(original) != originalLeaves {
+		t.Fatal("pruning changed the original tree")
+	}
+	if leavesOf(tree.root) >= originalLeaves {
+		t.Errorf("pruned tree has %d leaves, full tree %d", leavesOf(tree.root), originalLeaves)
+	}
+
+	errorsOf := func(root *Node) float64 {
+		var total float64
+		for _, row := range validation {
+			total += leafError(leafOf(root, row[:len(row)-1]), row[len(row)-1])
+		}
+		return total
+	}
+	if errorsOf(tree.root) > errorsOf(original) {
+		t.Errorf("%g validation errors after pruning, %g before", errorsOf(tree.root), errorsOf(original))
+	}
+
+	// No remaining subtree can be collapsed without more validation errors.
+	var check func(node *Node, rows [][]float64)
+	check = func(node *Node, rows [][]float64) {
+		if isLeaf(node) {
+			return
+		}
+		left, right := splitData(rows, node.Feature, node.Threshold)
+		asLeaf, subtree := 0.0, 0.0
+		for _, row := range rows {
+			asLeaf += leafError(node, row[len(row)-1])
+			subtree += leafError(leafOf(node, row[:len(row)-1]), row[len(row)-1])
+		}
+		if asLeaf <= subtree {
+			t.Errorf("node with %d validation rows should have been pruned", len(rows))
+		}
+		check(node.Left, left)
+		check(node.Right, right)
+	}
+	check(tree.root, validation)
+}
+
+func TestReducedErrorPruningOfRegressionTrees(t *testing.T) {
+	data := harness.Regression(800, 3, 0.5, 2)
+	train, validation := data[:500], data[500:]
+
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 8, Criterion: MSE})
+	tree.Train(train)
+	before := tree.root
+	tree.PruneReducedError(validation)
+
+	sse := func(root *Node) float64 {
+		total := 0.0
+		for _, row := range validation {
+			total += leafError(leafOf(root, row[:len(row)-1]), row[len(row)-1])
+		}
+		return total
+	}
+	if leavesOf(tree.root) >= leavesOf(before) || sse(tree.root) > sse(before) {
+		t.Errorf(
+			"%d leaves with SSE %g after pruning, %d with SSE %g before",
+			leavesOf(tree.root), sse(tree.root), leavesOf(before), sse(before),
+		)
+	}
+}
@@ -0,0 +1,138 @@
+package decisiontree
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// splitSeed is the seed of the splits drawn at the node with key in a tree
+// grown with seed. It comes from a different stream than the node's feature
+// subset.
+func splitSeed(seed int64, key uint64) uint64 {
+	return rand.New(rand.NewPCG(^uint64(seed), key)).Uint64()
+}
+
+// drawThreshold returns a threshold drawn by rng uniformly in [low, high),
+// so low goes left and high right.
+func drawThreshold(rng *rand.Rand, low, high float64) float64 {
+	threshold := low + rng.Float64()*(high-low)
+	if threshold >= high {
+		threshold = math.Nextafter(high, low)
+	}
+	return threshold
+}
+
+// drawCategories returns a random subset of categories, which are sorted and
+// at least two, that is neither empty nor all of them.
+func drawCategories(rng *rand.Rand, categories []float64) []float64 {
+	for {
+		var chosen []float64
+		for _, category := range categories {
+			if rng.IntN(2) == 1 {
+				chosen = append(chosen, category)
+			}
+		}
+		if len(chosen) > 0 && len(chosen) < len(categories) {
+			return chosen
+		}
+	}
+}
+
+// randomSplitAmong draws a split of each of features and returns the one
+// with the lowest impurity, ties going to the lowest feature, or feature -1
+// when none can be split. The split's rows are not partitioned. With
+// parallel every feature is drawn and scored in its own goroutine.
+func randomSplitAmong(
+	data [][]float64,
+	features []int,
+	opts Options,
+	weighted bool,
+	seed uint64,
+	parallel bool,
+) Split[float64] {
+	splits := make([]Split[float64], len(features))
+	if parallel {
+		var wg sync.WaitGroup
+		for i, feature := range features {
+			wg.Add(1)
+			go func(i, feature int) {
+				defer wg.Done()
+				splits[i] = randomFeatureSplit(data, feature, opts, weighted, seed)
+			}(i, feature)
+		}
+		wg.Wait()
+	} else {
+		for i, feature := range features {
+			splits[i] = randomFeatureSplit(data, feature, opts, weighted, seed)
+		}
+	}
+
+	best := Split[float64]{Feature: -1, Impurity: math.Inf(1)}
+	for _, split := range splits {
+		if split.Impurity < best.Impurity {
+			best = split
+		}
+	}
+	return best
+}
+
+// randomFeatureSplit draws a split of data on feature, with impurity +Inf
+// when the feature holds a single value at the node or the split leaves
+// fewer than MinSamplesLeaf rows on a side.
+func randomFeatureSplit(data [][]float64, feature int, opts Options, weighted bool, seed uint64) Split[float64] {
+	split := Split[float64]{Feature: feature, Impurity: math.Inf(1)}
+	rng := rand.New(rand.NewPCG(seed, uint64(feature)))
+	if opts.categorical(feature) {
+		seen := make(map[float64]bool)
+		var categories []float64
+		for _, row := range data {
+			if !seen[row[feature]] {
+				seen[row[feature]] = true
+				categories = append(categories, row[feature])
+			}
+		}
+		if len(categories) < 2 {
+			return split
+		}
+		sort.Float64s(categories)
+		split.Categories = drawCategories(rng, categories)
+	} else {
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, row := range data {
+			low, high = min(low, row[feature]), max(high, row[feature])
+		}
+		if !(low < high) {
+			return split
+		}
+		split.Threshold = drawThreshold(rng, low, high)
+	}
+
+	node := &Node{Feature: feature, Threshold: split.Threshold, Categories: split.Categories}
+	left, right := opts.criterion().NewStats(), opts.criterion().NewStats()
+	for _, row := range data {
+		side := right
+		if node.goesLeft(row[feature]) {
+			side = left
+		}
+		side.AddWeighted(row[len(row)-1], rowWeight(row, weighted))
+	}
+	if left.Count() < max(1, opts.MinSamplesLeaf) || right.Count() < max(1, opts.MinSamplesLeaf) {
+		return split
+	}
+	split.Impurity = weightedImpurity(left, right)
+	return split
+}
+
+// RandomSplitAmong draws a split of each of features.
+func (s sequentialSplitter) RandomSplitAmong(data [][]float64, features []int, seed uint64) (Split[float64], bool) {
+	return partitionSplit(data, randomSplitAmong(data, features, s.dt.opts, s.dt.weighted, seed, false))
+}
+
+// RandomSplitAmong draws a split of each of features, in parallel when the
+// node is large enough to search its features in parallel.
+func (s concurrentSplitter) RandomSplitAmong(data [][]float64, features []int, seed uint64) (Split[float64], bool) {
+	parallel := len(data) >= s.dt.subtreeThreshold
+	return partitionSplit(data, randomSplitAmong(data, features, s.dt.opts, s.dt.weighted, seed, parallel))
+}
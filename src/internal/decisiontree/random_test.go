@@ -0,0 +1,101 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// TestRandomThresholdsTreesAgree checks that the drawn splits depend on the
+// seed and the node, not on how the tree is grown.
+func TestRandomThresholdsTreesAgree(t *testing.T) {
+	categorical := harness.DiscreteClassification(2000, 5, 6, 0.1, 8)
+	configs := map[string]struct {
+		data [][]float64
+		opts Options
+	}{
+		"classification": {harness.Classification(3000, 6, 0.1, 7), Options{MaxDepth: 8, RandomThresholds: true, Seed: 4}},
+		"subspace": {harness.Classification(3000, 6, 0.1, 7),
+			Options{MaxDepth: 8, MaxFeatures: SqrtFeatures, RandomThresholds: true, Seed: 4}},
+		"regression": {harness.Regression(3000, 4, 0.1, 9),
+			Options{MaxDepth: 8, Criterion: MSE, RandomThresholds: true, Seed: 4}},
+		"categorical": {categorical, Options{MaxDepth: 6, Categorical: []int{0, 1}, RandomThresholds: true, Seed: 4}},
+		"best first": {harness.Classification(3000, 6, 0.1, 7),
+			Options{MaxLeafNodes: 20, RandomThresholds: true, Seed: 4}},
+	}
+	for name, config := range configs {
+		sequential := NewSequentialDecisionTreeWithOptions(config.opts)
+		sequential.Train(config.data)
+		for _, threshold := range []int{0, 100, DefaultSubtreeThreshold} {
+			t.Run(fmt.Sprintf("%s/threshold=%d", name, threshold), func(t *testing.T) {
+				concurrent := NewConcurrentDecisionTreeWithOptions(config.opts)
+				concurrent.SetSubtreeThreshold(threshold)
+				concurrent.Train(config.data)
+				if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+// TestRandomThresholdsStayInRange checks that every split leaves rows on both
+// sides, at least MinSamplesLeaf of them, at a threshold within the range of
+// the node's rows.
+func TestRandomThresholdsStayInRange(t *testing.T) {
+	data := harness.Classification(2000, 4, 0.1, 10)
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 10, MinSamplesLeaf: 5, RandomThresholds: true, Seed: 1})
+	tree.Train(data)
+
+	var walk func(node *Node, rows [][]float64)
+	walk = func(node *Node, rows [][]float64) {
+		if node.Samples != len(rows) {
+			t.Fatalf("node %d holds %d rows, counted %d", node.ID, len(rows), node.Samples)
+		}
+		if isLeaf(node) {
+			if node.Samples < 5 {
+				t.Errorf("leaf %d holds %d rows", node.ID, node.Samples)
+			}
+			return
+		}
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, row := range rows {
+			low, high = min(low, row[node.Feature]), max(high, row[node.Feature])
+		}
+		if node.Threshold < low || node.Threshold >= high {
+			t.Errorf("node %d threshold %g outside [%g, %g)", node.ID, node.Threshold, low, high)
+		}
+		left, right := splitData(rows, node.Feature, node.Threshold)
+		walk(node.Left, left)
+		walk(node.Right, right)
+	}
+	walk(tree.root, data)
+}
+
+func TestRandomThresholdsVaryWithSeed(t *testing.T) {
+	data := harness.Classification(1000, 3, 0.1, 11)
+
+	thresholds := make(map[float64]bool)
+	for seed := int64(0); seed < 10; seed++ {
+		tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, RandomThresholds: true, Seed: seed})
+		tree.Train(data)
+		thresholds[tree.root.Threshold] = true
+	}
+	if len(thresholds) < 9 {
+		t.Errorf("10 seeds drew %d root thresholds", len(thresholds))
+	}
+}
+
+func TestDrawCategories(t *testing.T) {
+	tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, Categorical: []int{0}, RandomThresholds: true})
+	for seed := int64(0); seed < 20; seed++ {
+		tree.opts.Seed = seed
+		tree.Train([][]float64{{0, 0}, {1, 1}, {2, 0}, {0, 0}, {1, 1}})
+		categories := tree.root.Categories
+		if len(categories) == 0 || len(categories) == 3 {
+			t.Fatalf("seed %d sends categories %v left", seed, categories)
+		}
+	}
+}
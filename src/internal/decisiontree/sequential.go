@@ -8,6 +8,15 @@ import (
 
 type SequentialDecisionTree struct {
 	root *Node
+	opts Options
+	// numClasses is the number of classes seen by Train, or 0 for regression
+	// trees.
+	numClasses int
+	// numFeatures is the number of features of the data Train was given.
+	numFeatures int
+	// weighted says the rows being trained on carry a sample weight before
+	// the label.
+	weighted bool
 }
 
 type Node struct {
@@ -16,6 +25,26 @@ type Node struct {
 	Left       *Node
 	Right      *Node
 	Prediction float64
+	// Distribution is the share of a classification node's training rows in
+	// each class and Counts their weighted number. Both are nil in
+	// regression trees.
+	Distribution []float64
+	Counts       []float64
+	// Samples is the number of training rows that reached the node, Weight
+	// their total sample weight, which is Samples in unweighted trees, and
+	// Impurity their impurity. Internal nodes keep these and their
+	// Prediction and Distribution so pruning can turn them into leaves.
+	Samples  int
+	Weight   float64
+	Impurity float64
+	// Categories is set on splits of a categorical feature and holds, in
+	// increasing order, the categories sent to the left child. Every other
+	// value, including categories unseen in training, goes right.
+	Categories []float64
+	// ID numbers the nodes of a tree from 0 in depth-first order, each node
+	// before its left subtree and that before its right one. It is what
+	// PredictLeaf returns.
+	ID int
 }
 
 func (dt *SequentialDecisionTree) Predict(sample []float64) float64 {
@@ -27,133 +56,178 @@ func (dt *SequentialDecisionTree) predictNode(node *Node, sample []float64) floa
 		return node.Prediction
 	}
 
-	if sample[node.Feature] <= node.Threshold {
+	if node.goesLeft(sample[node.Feature]) {
 		return dt.predictNode(node.Left, sample)
 	}
 	return dt.predictNode(node.Right, sample)
 }
 
 func NewSequentialDecisionTree() *SequentialDecisionTree {
-	return &SequentialDecisionTree{}
+	return NewSequentialDecisionTreeWithOptions(DefaultOptions())
 }
 
-func (dt *SequentialDecisionTree) Train(data [][]float64) {
-	dt.root = dt.buildTree(data, 0, 5)
+// NewSequentialRegressionTree returns a tree that predicts a continuous
+// target by minimizing the MSE of its leaves.
+func NewSequentialRegressionTree() *SequentialDecisionTree {
+	return NewSequentialDecisionTreeWithOptions(DefaultRegressionOptions())
 }
 
-func (dt *SequentialDecisionTree) buildTree(data [][]float64, depth, maxDepth int) *Node {
-	if len(data) == 0 || depth >= maxDepth {
-		return &Node{Prediction: dt.calculatePrediction(data)}
-	}
-
-	bestFeature, bestThreshold := dt.findBestSplit(data)
+func NewSequentialDecisionTreeWithOptions(opts Options) *SequentialDecisionTree {
+	return &SequentialDecisionTree{opts: opts}
+}
 
-	if bestFeature == -1 {
-		return &Node{Prediction: dt.calculatePrediction(data)}
+// Train grows the tree on data, whose last column is the label. It panics
+// with the error TrainWeighted would return for labels that are not classes.
+func (dt *SequentialDecisionTree) Train(data [][]float64) {
+	if err := dt.TrainWeighted(data, nil); err != nil {
+		panic(err)
 	}
+}
 
-	leftData, rightData := dt.splitData(data, bestFeature, bestThreshold)
+// TrainWeighted is Train with weights[i], a positive number, weighing row i
+// in impurities, leaf predictions, class distributions, node weights and
+// the pruning and importances built on them as if it appeared weights[i]
+// times. The row-count stopping rules still count rows. Nil weights train
+// like Train. It returns an error, and leaves the tree as it was, unless there is
+// one positive, finite weight per row and, for classification trees, the
+// labels pass CheckLabels.
+func (dt *SequentialDecisionTree) TrainWeighted(data [][]float64, weights []float64) error {
+	if err := CheckLabels(dt.opts.criterion(), data); err != nil {
+		return err
+	}
+	if weights == nil {
+		dt.train(data, false)
+		return nil
+	}
+	if err := checkWeights(weights, len(data)); err != nil {
+		return err
+	}
+	dt.train(withWeights(data, weights), true)
+	return nil
+}
 
-	return &Node{
-		Feature:   bestFeature,
-		Threshold: bestThreshold,
-		Left:      dt.buildTree(leftData, depth+1, maxDepth),
-		Right:     dt.buildTree(rightData, depth+1, maxDepth),
+func (dt *SequentialDecisionTree) train(data [][]float64, weighted bool) {
+	dt.weighted = weighted
+	dt.numClasses = 0
+	dt.numFeatures = 0
+	if len(data) > 0 {
+		dt.numFeatures = featureCount(data[0], weighted)
+	}
+	if IsClassifier(dt.opts.criterion()) {
+		dt.numClasses = NumClasses(data)
+	}
+	var splitter Splitter[float64] = sequentialSplitter{dt}
+	if dt.opts.MaxBins > 0 && !dt.opts.RandomThresholds && len(data) > 0 {
+		cfg := histogramConfig(dt.numFeatures, dt.opts, false, weighted)
+		splitter = NewHistogramSplitter(data, splitter, cfg)
+	}
+	dt.root = Grow(data, splitter, dt.opts)
+	if dt.opts.CCPAlpha > 0 {
+		dt.root = PruneCostComplexity(dt.root, dt.opts.CCPAlpha)
 	}
 }
 
-func (dt *SequentialDecisionTree) findBestSplit(data [][]float64) (int, float64) {
-	bestFeature := -1
-	bestThreshold := 0.0
-	bestGini := math.Inf(1)
+// sequentialSplitter searches splits one feature after another.
+type sequentialSplitter struct {
+	dt *SequentialDecisionTree
+}
 
-	for feature := 0; feature < len(data[0])-1; feature++ {
-		thresholds := dt.getUniqueValues(data, feature)
+func (s sequentialSplitter) Impurity(data [][]float64) float64 {
+	return nodeImpurity(data, s.dt.opts.criterion(), s.dt.weighted)
+}
 
-		for _, threshold := range thresholds {
-			gini := dt.calculateGiniIndex(data, feature, threshold)
-			if gini < bestGini {
-				bestGini = gini
-				bestFeature = feature
-				bestThreshold = threshold
-			}
-		}
+func (s sequentialSplitter) LeafValue(data [][]float64) float64 {
+	if s.dt.opts.criterion() == MAE {
+		return medianLabel(data, s.dt.weighted)
 	}
-
-	return bestFeature, bestThreshold
+	return calculatePrediction(data, s.dt.weighted)
 }
 
-func (dt *SequentialDecisionTree) getUniqueValues(data [][]float64, feature int) []float64 {
-	uniqueMap := make(map[float64]bool)
-	for _, row := range data {
-		uniqueMap[row[feature]] = true
+// LeafCounts returns nil for regression trees.
+func (s sequentialSplitter) LeafCounts(data [][]float64) []float64 {
+	if s.dt.numClasses == 0 {
+		return nil
 	}
+	return classCounts(data, s.dt.numClasses, s.dt.weighted)
+}
 
-	unique := make([]float64, 0, len(uniqueMap))
-	for value := range uniqueMap {
-		unique = append(unique, value)
-	}
-	return unique
+func (s sequentialSplitter) Weight(data [][]float64) float64 {
+	return totalWeight(data, s.dt.weighted)
 }
 
-func (dt *SequentialDecisionTree) calculateGiniIndex(
-	data [][]float64,
-	feature int,
-	threshold float64,
-) float64 {
-	leftData, rightData := dt.splitData(data, feature, threshold)
+func (s sequentialSplitter) BestSplit(data [][]float64) (Split[float64], bool) {
+	return partitionSplit(data, s.dt.findBestSplit(data))
+}
 
-	leftGini := dt.calculateGini(leftData)
-	rightGini := dt.calculateGini(rightData)
+func (s sequentialSplitter) NumCandidates() int {
+	return s.dt.numFeatures
+}
 
-	totalSize := float64(len(data))
-	weightedGini := (float64(len(leftData))/totalSize)*leftGini + (float64(len(rightData))/totalSize)*rightGini
+// BestSplitAmong searches features in place of every feature.
+func (s sequentialSplitter) BestSplitAmong(data [][]float64, _ any, features []int) (Split[float64], bool) {
+	return partitionSplit(data, findBestSplitAmong(data, features, s.dt.opts, s.dt.weighted))
+}
 
-	return weightedGini
+func (dt *SequentialDecisionTree) findBestSplit(data [][]float64) Split[float64] {
+	return findBestSplit(data, dt.opts, dt.weighted)
 }
 
-func (dt *SequentialDecisionTree) calculateGini(data [][]float64) float64 {
-	if len(data) == 0 {
-		return 0
-	}
+// findBestSplit searches the features of data one after another and returns
+// feature -1 when none can be split. The split's rows are not partitioned.
+func findBestSplit(data [][]float64, opts Options, weighted bool) Split[float64] {
+	return findBestSplitAmong(data, nil, opts, weighted)
+}
 
-	positiveCount := 0
-	for _, row := range data {
-		if row[len(row)-1] == 1 {
-			positiveCount++
+// findBestSplitAmong is findBestSplit searching only features, in order, or
+// every feature when features is nil.
+func findBestSplitAmong(data [][]float64, features []int, opts Options, weighted bool) Split[float64] {
+	if features == nil {
+		features = allFeatureIndices(featureCount(data[0], weighted))
+	}
+	best := Split[float64]{Feature: -1, Impurity: math.Inf(1)}
+	for _, feature := range features {
+		split := findFeatureSplit(data, feature, opts, weighted)
+		if split.Impurity < best.Impurity {
+			best = split
 		}
 	}
-
-	p := float64(positiveCount) / float64(len(data))
-	return 2 * p * (1 - p)
+	return best
 }
 
-func (dt *SequentialDecisionTree) splitData(
-	data [][]float64,
-	feature int,
-	threshold float64,
-) ([][]float64, [][]float64) {
-	var left, right [][]float64
-	for _, row := range data {
-		if row[feature] <= threshold {
-			left = append(left, row)
-		} else {
-			right = append(right, row)
-		}
+// allFeatureIndices returns 0, 1, ..., n-1.
+func allFeatureIndices(n int) []int {
+	features := make([]int, n)
+	for i := range features {
+		features[i] = i
 	}
-	return left, right
+	return features
 }
 
-func (dt *SequentialDecisionTree) calculatePrediction(data [][]float64) float64 {
-	if len(data) == 0 {
-		return 0
+// findFeatureSplit returns the best split of data on feature, a threshold or,
+// for categorical features, a set of categories, with impurity +Inf when
+// feature cannot be split.
+func findFeatureSplit(data [][]float64, feature int, opts Options, weighted bool) Split[float64] {
+	split := Split[float64]{Feature: feature}
+	if opts.categorical(feature) {
+		split.Categories, split.Impurity = findBestCategories(data, feature, opts.MinSamplesLeaf, opts.criterion(), weighted)
+	} else {
+		split.Threshold, split.Impurity = findBestThreshold(data, feature, opts.MinSamplesLeaf, opts.criterion(), weighted)
 	}
+	return split
+}
 
-	sum := 0.0
-	for _, row := range data {
-		sum += row[len(row)-1]
+// partitionSplit fills in the rows of data on each side of split, or returns
+// false when split has no feature.
+func partitionSplit(data [][]float64, split Split[float64]) (Split[float64], bool) {
+	if split.Feature == -1 {
+		return Split[float64]{}, false
 	}
-	return sum / float64(len(data))
+	if split.Categories != nil {
+		split.Left, split.Right = splitCategories(data, split.Feature, split.Categories)
+	} else {
+		split.Left, split.Right = splitData(data, split.Feature, split.Threshold)
+	}
+	return split, true
 }
 
 func (dt *SequentialDecisionTree) PrintTree() {
@@ -169,7 +243,11 @@ func (dt *SequentialDecisionTree) printNode(node *Node, depth int) {
 	if node.Left == nil && node.Right == nil {
 		fmt.Printf("%sLeaf: Prediction = %.2f\n", indent, node.Prediction)
 	} else {
-		fmt.Printf("%sNode: Feature %d, Threshold %.2f\n", indent, node.Feature, node.Threshold)
+		if node.Categories != nil {
+			fmt.Printf("%sNode: Feature %d, Categories %v\n", indent, node.Feature, node.Categories)
+		} else {
+			fmt.Printf("%sNode: Feature %d, Threshold %.2f\n", indent, node.Feature, node.Threshold)
+		}
 		dt.printNode(node.Left, depth+1)
 		dt.printNode(node.Right, depth+1)
 	}
@@ -0,0 +1,61 @@
+package decisiontree
+
+import (
+	"math"
+	"sort"
+)
+
+// labeledValue is one row's value for the feature being searched together
+// with its label and weight.
+type labeledValue struct {
+	value, label, weight float64
+}
+
+// findBestThreshold returns the threshold on feature with the lowest weighted
+// impurity under criterion and that impurity, or +Inf when no threshold leaves
+// minLeaf rows on both sides. Rows are weighted by rowWeight.
+//
+// The rows are sorted by the feature once and scanned while moving their
+// labels from the right child's statistics to the left's, so each candidate
+// threshold costs O(1) instead of a pass over data. Candidates are the unique
+// values in increasing order and only a strictly lower impurity replaces the
+// best, which is the split the exhaustive search over every unique value
+// picks.
+func findBestThreshold(
+	data [][]float64,
+	feature, minLeaf int,
+	criterion Criterion,
+	weighted bool,
+) (float64, float64) {
+	values := make([]labeledValue, len(data))
+	left, right := criterion.NewStats(), criterion.NewStats()
+	for i, row := range data {
+		values[i] = labeledValue{row[feature], row[len(row)-1], rowWeight(row, weighted)}
+		right.AddWeighted(values[i].label, values[i].weight)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	bestThreshold := 0.0
+	bestImpurity := math.Inf(1)
+
+	for i, v := range values {
+		left.AddWeighted(v.label, v.weight)
+		right.RemoveWeighted(v.label, v.weight)
+		// Only the last occurrence of a value closes a candidate split, since
+		// rows equal to the threshold go left.
+		if i+1 < len(values) && values[i+1].value == v.value {
+			continue
+		}
+
+		if left.Count() < minLeaf || right.Count() < minLeaf {
+			continue
+		}
+		impurity := weightedImpurity(left, right)
+		if impurity < bestImpurity {
+			bestImpurity = impurity
+			bestThreshold = v.value
+		}
+	}
+
+	return bestThreshold, bestImpurity
+}
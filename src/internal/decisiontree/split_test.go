@@ -0,0 +1,201 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// exhaustiveThreshold is the original O(n²) search: it tries every unique
+// value of feature as a threshold and repartitions data for each one. It is
+// kept as the reference findBestThreshold must agree with.
+func exhaustiveThreshold(
+	data [][]float64,
+	feature, minLeaf int,
+	criterion Criterion,
+) (float64, float64) {
+	bestThreshold := 0.0
+	bestImpurity := math.Inf(1)
+	for _, threshold := range getUniqueValues(data, feature) {
+		impurity := calculateImpurityIndex(data, feature, threshold, minLeaf, criterion)
+		if impurity < bestImpurity {
+			bestImpurity = impurity
+			bestThreshold = threshold
+		}
+	}
+	return bestThreshold, bestImpurity
+}
+
+func getUniqueValues(data [][]float64, feature int) []float64 {
+	uniqueMap := make(map[float64]bool)
+	for _, row := range data {
+		uniqueMap[row[feature]] = true
+	}
+	unique := make([]float64, 0, len(uniqueMap))
+	for value := range uniqueMap {
+		unique = append(unique, value)
+	}
+	sort.Float64s(unique)
+	return unique
+}
+
+func calculateImpurityIndex(
+	data [][]float64,
+	feature int,
+	threshold float64,
+	minLeaf int,
+	criterion Criterion,
+) float64 {
+	leftData, rightData := splitData(data, feature, threshold)
+	if len(leftData) < minLeaf || len(rightData) < minLeaf {
+		return math.Inf(1)
+	}
+	leftImpurity := nodeImpurity(leftData, criterion, false)
+	rightImpurity := nodeImpurity(rightData, criterion, false)
+	totalSize := float64(len(data))
+	return (float64(len(leftData))/totalSize)*leftImpurity +
+		(float64(len(rightData))/totalSize)*rightImpurity
+}
+
+func TestSortedSearchMatchesExhaustiveSearch(t *testing.T) {
+	datasets := map[string][][]float64{
+		"continuous": harness.Classification(400, 4, 0.1, 1),
+		"discrete":   harness.DiscreteClassification(400, 4, 5, 0.1, 2),
+		"binary":     harness.DiscreteClassification(300, 3, 2, 0.3, 3),
+		"constant":   {{1, 0}, {1, 1}, {1, 1}, {1, 0}},
+		"single row": {{0.5, 1}},
+	}
+
+	// MSE is left out here: it updates a running mean, so it only matches the
+	// exhaustive search up to rounding and ties between 0/1 labels can go
+	// either way. TestSortedSearchMatchesExhaustiveMSE covers it.
+	for name, data := range datasets {
+		for _, criterion := range []Criterion{Gini, Entropy, LogLoss, MAE} {
+			for _, minLeaf := range []int{0, 1, 7, 1000} {
+				for feature := 0; feature < len(data[0])-1; feature++ {
+					wantThreshold, wantImpurity := exhaustiveThreshold(data, feature, minLeaf, criterion)
+					gotThreshold, gotImpurity := findBestThreshold(data, feature, minLeaf, criterion, false)
+					if gotThreshold != wantThreshold || gotImpurity != wantImpurity {
+						t.Errorf(
+							"%s, %s, minLeaf %d, feature %d: got (%v, %v), want (%v, %v)",
+							name, criterion, minLeaf, feature,
+							gotThreshold, gotImpurity, wantThreshold, wantImpurity,
+						)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSortedSearchMatchesExhaustiveMSE(t *testing.T) {
+	datasets := map[string][][]float64{
+		"continuous": harness.Regression(300, 3, 0.1, 4),
+		"discrete":   harness.DiscreteRegression(300, 3, 6, 0.1, 5),
+	}
+
+	for name, data := range datasets {
+		for _, minLeaf := range []int{0, 5} {
+			for feature := 0; feature < len(data[0])-1; feature++ {
+				wantThreshold, wantImpurity := exhaustiveThreshold(data, feature, minLeaf, MSE)
+				gotThreshold, gotImpurity := findBestThreshold(data, feature, minLeaf, MSE, false)
+				if gotThreshold != wantThreshold || !harness.WithinTolerance(gotImpurity, wantImpurity, 1e-12) {
+					t.Errorf(
+						"%s, minLeaf %d, feature %d: got (%v, %v), want (%v, %v)",
+						name, minLeaf, feature, gotThreshold, gotImpurity, wantThreshold, wantImpurity,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestSortedSearchBuildsTheSameTree(t *testing.T) {
+	data := harness.DiscreteClassification(500, 5, 4, 0.15, 4)
+	opts := Options{MaxDepth: 6, MinSamplesLeaf: 3}
+
+	tree := NewSequentialDecisionTreeWithOptions(opts)
+	tree.Train(data)
+	want := Grow(data, exhaustiveSplitter{opts.MinSamplesLeaf, Gini, NumClasses(data)}, opts)
+
+	if err := compareNodes(want, tree.root, "root"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exhaustiveSplitter grows trees with exhaustiveThreshold.
+type exhaustiveSplitter struct {
+	minLeaf    int
+	criterion  Criterion
+	numClasses int
+}
+
+func (s exhaustiveSplitter) Impurity(data [][]float64) float64 {
+	return nodeImpurity(data, s.criterion, false)
+}
+
+func (s exhaustiveSplitter) LeafValue(data [][]float64) float64 {
+	return calculatePrediction(data, false)
+}
+
+func (s exhaustiveSplitter) LeafCounts(data [][]float64) []float64 {
+	return classCounts(data, s.numClasses, false)
+}
+
+func (s exhaustiveSplitter) BestSplit(data [][]float64) (Split[float64], bool) {
+	best := Split[float64]{Feature: -1, Impurity: math.Inf(1)}
+	for feature := 0; feature < len(data[0])-1; feature++ {
+		threshold, impurity := exhaustiveThreshold(data, feature, s.minLeaf, s.criterion)
+		if impurity < best.Impurity {
+			best.Feature, best.Threshold, best.Impurity = feature, threshold, impurity
+		}
+	}
+	if best.Feature == -1 {
+		return best, false
+	}
+	best.Left, best.Right = splitData(data, best.Feature, best.Threshold)
+	return best, true
+}
+
+func BenchmarkSplitSearch(b *testing.B) {
+	searches := []struct {
+		name   string
+		search func(data [][]float64, feature, minLeaf int, criterion Criterion) (float64, float64)
+		sizes  []int
+	}{
+		{"exhaustive", exhaustiveThreshold, []int{500, 2000}},
+		{"sorted", func(data [][]float64, feature, minLeaf int, criterion Criterion) (float64, float64) {
+			return findBestThreshold(data, feature, minLeaf, criterion, false)
+		}, []int{500, 2000, 20000, 200000}},
+	}
+
+	for _, s := range searches {
+		for _, rows := range s.sizes {
+			data := harness.Classification(rows, 1, 0.1, 1)
+			b.Run(fmt.Sprintf("%s/rows=%d", s.name, rows), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					s.search(data, 0, 1, Gini)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkTrain(b *testing.B) {
+	for _, rows := range []int{1000, 10000, 100000} {
+		data := harness.Classification(rows, 8, 0.1, 1)
+		b.Run(fmt.Sprintf("sequential/rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewSequentialDecisionTree().Train(data)
+			}
+		})
+		b.Run(fmt.Sprintf("concurrent/rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewConcurrentDecisionTree().Train(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+// MaxFeatures is how many features each split searches, drawn at random for
+// every node as in a random forest. The zero value searches every feature.
+type MaxFeatures struct {
+	rule  featureRule
+	value float64
+}
+
+type featureRule int
+
+const (
+	allFeatures featureRule = iota
+	sqrtFeatures
+	log2Features
+	fractionFeatures
+	countFeatures
+)
+
+var (
+	// SqrtFeatures searches the square root of the number of features, the
+	// usual choice for classification forests.
+	SqrtFeatures = MaxFeatures{rule: sqrtFeatures}
+	// Log2Features searches the base-2 logarithm of the number of features.
+	Log2Features = MaxFeatures{rule: log2Features}
+)
+
+// FeatureFraction searches fraction of the features, a number in (0, 1].
+func FeatureFraction(fraction float64) MaxFeatures {
+	return MaxFeatures{rule: fractionFeatures, value: fraction}
+}
+
+// FeatureCount searches n features, or every feature when there are fewer.
+func FeatureCount(n int) MaxFeatures {
+	return MaxFeatures{rule: countFeatures, value: float64(n)}
+}
+
+// ParseMaxFeatures reads "sqrt", "log2", "all", a fraction such as "0.3" or
+// a count such as "5".
+func ParseMaxFeatures(s string) (MaxFeatures, error) {
+	switch s {
+	case "", "all":
+		return MaxFeatures{}, nil
+	case "sqrt":
+		return SqrtFeatures, nil
+	case "log2":
+		return Log2Features, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return FeatureCount(n), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && f > 0 && f <= 1 {
+		return FeatureFraction(f), nil
+	}
+	return MaxFeatures{}, fmt.Errorf(
+		"decisiontree: max features must be sqrt, log2, all, a fraction in (0, 1] or a positive count, got %q", s)
+}
+
+func (m MaxFeatures) String() string {
+	switch m.rule {
+	case sqrtFeatures:
+		return "sqrt"
+	case log2Features:
+		return "log2"
+	case fractionFeatures, countFeatures:
+		return strconv.FormatFloat(m.value, 'g', -1, 64)
+	}
+	return "all"
+}
+
+// Count returns how many of n features a split searches, at least one and
+// at most n.
+func (m MaxFeatures) Count(n int) int {
+	var k int
+	switch m.rule {
+	case sqrtFeatures:
+		k = int(math.Sqrt(float64(n)))
+	case log2Features:
+		k = int(math.Log2(float64(n)))
+	case fractionFeatures:
+		k = int(m.value * float64(n))
+	case countFeatures:
+		k = int(m.value)
+	default:
+		return n
+	}
+	return max(1, min(k, n))
+}
+
+// childKey derives a child's key from its parent's. The root's key is 0, so
+// a node's key, and with it the features it searches, depends only on where
+// the node is in the tree and not on the order the tree is grown in.
+func childKey(parent uint64, right bool) uint64 {
+	z := parent*2 + 1
+	if right {
+		z++
+	}
+	// The splitmix64 finalizer spreads the keys of nearby nodes.
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// sampleFeatures draws k of the n candidates [0, n) for the node with key in
+// a tree grown with seed and returns them in increasing order, so ties still
+// go to the lowest feature.
+func sampleFeatures(n, k int, seed int64, key uint64) []int {
+	rng := rand.New(rand.NewPCG(uint64(seed), key))
+	candidates := make([]int, n)
+	for i := range candidates {
+		candidates[i] = i
+	}
+	for i := 0; i < k; i++ {
+		j := i + rng.IntN(n-i)
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+	chosen := candidates[:k]
+	slices.Sort(chosen)
+	return chosen
+}
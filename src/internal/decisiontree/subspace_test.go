@@ -0,0 +1,136 @@
+package decisiontree
+
+import (
+	"fmt"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestMaxFeatures(t *testing.T) {
+	tests := []struct {
+		spec string
+		n    int
+		want int
+	}{
+		{"", 10, 10},
+		{"all", 10, 10},
+		{"sqrt", 10, 3},
+		{"sqrt", 1, 1},
+		{"log2", 10, 3},
+		{"log2", 1, 1},
+		{"0.5", 9, 4},
+		{"0.01", 9, 1},
+		{"1", 9, 1},
+		{"1.0", 9, 9},
+		{"4", 9, 4},
+		{"20", 9, 9},
+	}
+	for _, test := range tests {
+		m, err := ParseMaxFeatures(test.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", test.spec, err)
+		}
+		if got := m.Count(test.n); got != test.want {
+			t.Errorf("%q of %d features is %d, want %d", test.spec, test.n, got, test.want)
+		}
+	}
+	for _, spec := range []string{"0", "-2", "1.5", "half"} {
+		if _, err := ParseMaxFeatures(spec); err == nil {
+			t.Errorf("%q parses", spec)
+		}
+	}
+}
+
+func TestSampleFeatures(t *testing.T) {
+	seen := make(map[int]bool)
+	for key := uint64(0); key < 50; key++ {
+		features := sampleFeatures(10, 3, 1, key)
+		if len(features) != 3 || !(features[0] < features[1] && features[1] < features[2]) {
+			t.Fatalf("key %d samples %v", key, features)
+		}
+		for _, f := range features {
+			seen[f] = true
+		}
+		if again := sampleFeatures(10, 3, 1, key); fmt.Sprint(again) != fmt.Sprint(features) {
+			t.Fatalf("key %d samples %v, then %v", key, features, again)
+		}
+	}
+	if len(seen) != 10 {
+		t.Errorf("50 nodes only sampled features %v", seen)
+	}
+}
+
+// TestSubspaceTreesAgree checks that the feature subsets depend on the seed
+// and the node, not on how the tree is grown.
+func TestSubspaceTreesAgree(t *testing.T) {
+	data := harness.Classification(3000, 9, 0.1, 5)
+	configs := map[string]Options{
+		"depth first": {MaxDepth: 8, MaxFeatures: SqrtFeatures, Seed: 3},
+		"best first":  {MaxDepth: 8, MaxLeafNodes: 20, MaxFeatures: FeatureCount(2), Seed: 3},
+		"histogram":   {MaxDepth: 8, MaxBins: 32, MaxFeatures: FeatureFraction(0.4), Seed: 3},
+	}
+	for name, opts := range configs {
+		sequential := NewSequentialDecisionTreeWithOptions(opts)
+		sequential.Train(data)
+		for _, threshold := range []int{0, 100, DefaultSubtreeThreshold} {
+			t.Run(fmt.Sprintf("%s/threshold=%d", name, threshold), func(t *testing.T) {
+				concurrent := NewConcurrentDecisionTreeWithOptions(opts)
+				concurrent.SetSubtreeThreshold(threshold)
+				concurrent.Train(data)
+				if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+func TestMaxFeaturesVariesTheRootSplit(t *testing.T) {
+	data := harness.Classification(1000, 6, 0.1, 6)
+	full := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1})
+	full.Train(data)
+
+	features := make(map[int]bool)
+	for seed := int64(0); seed < 20; seed++ {
+		tree := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 1, MaxFeatures: FeatureCount(1), Seed: seed})
+		tree.Train(data)
+		if want := sampleFeatures(6, 1, seed, 0)[0]; tree.root.Feature != want {
+			t.Fatalf("seed %d splits feature %d, sampled %d", seed, tree.root.Feature, want)
+		}
+		if tree.root.Impurity < full.root.Impurity {
+			t.Fatalf("seed %d beats the search over every feature", seed)
+		}
+		features[tree.root.Feature] = true
+	}
+	if len(features) < 3 {
+		t.Errorf("20 seeds split the root on features %v", features)
+	}
+}
+
+// TestConstantSampleSearchesEveryFeature checks that a node whose sampled
+// features are constant still splits on one that varies. Splits must keep a
+// row on each side, or a constant feature would split off nothing.
+func TestConstantSampleSearchesEveryFeature(t *testing.T) {
+	data := make([][]float64, 200)
+	for i := range data {
+		x := float64(i % 20)
+		label := 0.0
+		if x >= 10 {
+			label = 1
+		}
+		data[i] = []float64{0, 0, 0, x, label}
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		tree := NewSequentialDecisionTreeWithOptions(Options{
+			MaxDepth:       1,
+			MinSamplesLeaf: 1,
+			MaxFeatures:    FeatureCount(1),
+			Seed:           seed,
+		})
+		tree.Train(data)
+		if tree.root.Feature != 3 {
+			t.Fatalf("seed %d: root splits feature %d", seed, tree.root.Feature)
+		}
+	}
+}
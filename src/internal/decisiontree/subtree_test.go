@@ -0,0 +1,132 @@
+package decisiontree
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"concurrente/internal/harness"
+	"concurrente/internal/workpool"
+)
+
+func TestSubtreeTasksBuildTheSequentialTree(t *testing.T) {
+	data := harness.Classification(3000, 6, 0.2, 8)
+	configs := map[string]Options{
+		"deep":       {MaxDepth: 12},
+		"min leaf":   {MaxDepth: 10, MinSamplesLeaf: 5},
+		"histogram":  {MaxDepth: 10, MaxBins: 32},
+		"regression": {MaxDepth: 8, Criterion: MSE},
+	}
+
+	for name, opts := range configs {
+		sequential := NewSequentialDecisionTreeWithOptions(opts)
+		sequential.Train(data)
+		for _, threshold := range []int{0, 1, 50, 500, DefaultSubtreeThreshold} {
+			t.Run(fmt.Sprintf("%s/threshold=%d", name, threshold), func(t *testing.T) {
+				concurrent := NewConcurrentDecisionTreeWithOptions(opts)
+				concurrent.SetSubtreeThreshold(threshold)
+				concurrent.Train(data)
+				if err := compareNodes(sequential.root, concurrent.root, "root"); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+func TestGrowParallelOnSeveralWorkers(t *testing.T) {
+	data := harness.Classification(2000, 4, 0.1, 5)
+	opts := Options{MaxDepth: 9}
+	sequential := NewSequentialDecisionTreeWithOptions(opts)
+	sequential.Train(data)
+
+	// More workers than CPUs still makes idle workers steal subtrees.
+	pool := workpool.New(4)
+	defer pool.Close()
+	root := GrowParallel(data, Splitter[float64](sequentialSplitter{sequential}), opts, pool, 20)
+	if err := compareNodes(sequential.root, root, "root"); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Steals() == 0 {
+		t.Error("no subtree was stolen")
+	}
+}
+
+// BenchmarkSubtreeTasks compares the concurrent tree searching features in
+// parallel at every node (threshold=0) with subtrees grown as tasks above
+// several node sizes.
+func TestTreesShareAPool(t *testing.T) {
+	data := harness.Classification(2000, 4, 0.1, 6)
+	opts := Options{MaxDepth: 9}
+	sequential := NewSequentialDecisionTreeWithOptions(opts)
+	sequential.Train(data)
+
+	pool := workpool.New(3)
+	defer pool.Close()
+	trees := make([]*ConcurrentDecisionTree, 6)
+	var wg sync.WaitGroup
+	wg.Add(len(trees))
+	for i := range trees {
+		go func() {
+			defer wg.Done()
+			trees[i] = NewConcurrentDecisionTreeWithOptions(opts)
+			trees[i].SetSubtreeThreshold(50)
+			trees[i].SetPool(pool)
+			trees[i].Train(data)
+		}()
+	}
+	wg.Wait()
+	for i, tree := range trees {
+		if err := compareNodes(sequential.root, tree.root, fmt.Sprintf("tree %d root", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSubtreeTasks(b *testing.B) {
+	for _, rows := range []int{10000, 100000} {
+		data := harness.Classification(rows, 8, 0.1, 1)
+		opts := Options{MaxDepth: 10}
+		b.Run(fmt.Sprintf("sequential/rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewSequentialDecisionTreeWithOptions(opts).Train(data)
+			}
+		})
+		for _, threshold := range []int{0, 256, DefaultSubtreeThreshold, 16384} {
+			b.Run(fmt.Sprintf("concurrent/rows=%d/threshold=%d", rows, threshold), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					tree := NewConcurrentDecisionTreeWithOptions(opts)
+					tree.SetSubtreeThreshold(threshold)
+					tree.Train(data)
+				}
+			})
+		}
+		// A forest trains its trees at the same time; with a pool each they
+		// start numTrees*GOMAXPROCS workers.
+		const numTrees = 16
+		for _, shared := range []bool{false, true} {
+			b.Run(fmt.Sprintf("forest/rows=%d/trees=%d/shared=%t", rows, numTrees, shared), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					var pool *workpool.Pool
+					if shared {
+						pool = workpool.New(0)
+					}
+					var wg sync.WaitGroup
+					wg.Add(numTrees)
+					for range numTrees {
+						go func() {
+							defer wg.Done()
+							tree := NewConcurrentDecisionTreeWithOptions(opts)
+							tree.SetPool(pool)
+							tree.Train(data)
+						}()
+					}
+					wg.Wait()
+					if shared {
+						pool.Close()
+					}
+				}
+			})
+		}
+	}
+}
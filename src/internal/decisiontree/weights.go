@@ -0,0 +1,67 @@
+package decisiontree
+
+import (
+	"fmt"
+	"math"
+)
+
+// Weighted training data keeps each row's weight in the column just before
+// the label, so rows carry their weight through every split and the label
+// stays in the last column. The features are the columns before the weight.
+
+// withWeights returns copies of the rows of data with weights[i] inserted
+// before the label of row i.
+func withWeights(data [][]float64, weights []float64) [][]float64 {
+	weighted := make([][]float64, len(data))
+	for i, row := range data {
+		features := len(row) - 1
+		weighted[i] = make([]float64, features+2)
+		copy(weighted[i], row[:features])
+		weighted[i][features] = weights[i]
+		weighted[i][features+1] = row[features]
+	}
+	return weighted
+}
+
+// checkWeights returns an error unless weights holds one positive, finite
+// weight for each of rows training rows. A node whose rows all weighed
+// nothing would have no impurity or prediction.
+func checkWeights(weights []float64, rows int) error {
+	if len(weights) != rows {
+		return fmt.Errorf("decisiontree: %d weights for %d rows", len(weights), rows)
+	}
+	for i, weight := range weights {
+		if !(weight > 0) || math.IsInf(weight, 1) {
+			return fmt.Errorf("decisiontree: weight %d is %g, want a positive number", i, weight)
+		}
+	}
+	return nil
+}
+
+// rowWeight is the weight of row, 1 unless the data is weighted.
+func rowWeight(row []float64, weighted bool) float64 {
+	if !weighted {
+		return 1
+	}
+	return row[len(row)-2]
+}
+
+// totalWeight is the sum of the weights of the rows of data.
+func totalWeight(data [][]float64, weighted bool) float64 {
+	if !weighted {
+		return float64(len(data))
+	}
+	total := 0.0
+	for _, row := range data {
+		total += rowWeight(row, weighted)
+	}
+	return total
+}
+
+// featureCount is the number of features in row.
+func featureCount(row []float64, weighted bool) int {
+	if weighted {
+		return len(row) - 2
+	}
+	return len(row) - 1
+}
@@ -0,0 +1,205 @@
+package decisiontree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// duplicated repeats row i of data weights[i] times.
+func duplicated(data [][]float64, weights []float64) [][]float64 {
+	var rows [][]float64
+	for i, row := range data {
+		for n := 0; n < int(weights[i]); n++ {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func integerWeights(rows int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	weights := make([]float64, rows)
+	for i := range weights {
+		weights[i] = float64(1 + rng.Intn(4))
+	}
+	return weights
+}
+
+func TestWeightedStatsMatchRepeatedLabels(t *testing.T) {
+	labels := []float64{0, 2, 1, 1, 0, 2, 2}
+	weights := []float64{3, 1, 2, 4, 1, 2, 5}
+	for _, criterion := range Criteria {
+		weighted, repeated := criterion.NewStats(), criterion.NewStats()
+		for i, label := range labels {
+			weighted.AddWeighted(label, weights[i])
+			for n := 0; n < int(weights[i]); n++ {
+				repeated.Add(label)
+			}
+		}
+		if weighted.Weight() != 18 || weighted.Count() != len(labels) {
+			t.Errorf("%s: weight %g and count %d", criterion, weighted.Weight(), weighted.Count())
+		}
+		if math.Abs(weighted.Impurity()-repeated.Impurity()) > 1e-12 {
+			t.Errorf("%s: weighted impurity %g, repeated %g", criterion, weighted.Impurity(), repeated.Impurity())
+		}
+
+		// Removing the first two labels leaves the rest.
+		rest := criterion.NewStats()
+		for i, label := range labels[2:] {
+			rest.AddWeighted(label, weights[i+2])
+		}
+		removed, subtracted := weighted.Clone(), weighted.Clone()
+		first := criterion.NewStats()
+		for i, label := range labels[:2] {
+			removed.RemoveWeighted(label, weights[i])
+			first.AddWeighted(label, weights[i])
+		}
+		subtracted.Subtract(first)
+		for name, got := range map[string]Stats{"removed": removed, "subtracted": subtracted} {
+			if got.Weight() != rest.Weight() || math.Abs(got.Impurity()-rest.Impurity()) > 1e-12 {
+				t.Errorf("%s %s: weight %g impurity %g, want %g and %g",
+					criterion, name, got.Weight(), got.Impurity(), rest.Weight(), rest.Impurity())
+			}
+		}
+	}
+}
+
+func TestUnitWeightsTrainTheUnweightedTree(t *testing.T) {
+	classification := harness.Multiclass(600, 4, 3, 0.1, 5)
+	regression := harness.Regression(400, 3, 0.3, 6)
+	tests := map[string]struct {
+		data [][]float64
+		opts Options
+	}{
+		"gini":      {classification, Options{MaxDepth: 6}},
+		"entropy":   {classification, Options{MaxDepth: 6, Criterion: Entropy}},
+		"histogram": {classification, Options{MaxDepth: 6, MaxBins: 16}},
+		"mse":       {regression, Options{MaxDepth: 6, Criterion: MSE}},
+		"mae":       {regression[:150], Options{MaxDepth: 4, Criterion: MAE}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ones := make([]float64, len(tt.data))
+			for i := range ones {
+				ones[i] = 1
+			}
+			want := NewSequentialDecisionTreeWithOptions(tt.opts)
+			want.Train(tt.data)
+			sequential := NewSequentialDecisionTreeWithOptions(tt.opts)
+			if err := sequential.TrainWeighted(tt.data, ones); err != nil {
+				t.Fatal(err)
+			}
+			concurrent := NewConcurrentDecisionTreeWithOptions(tt.opts)
+			if err := concurrent.TrainWeighted(tt.data, ones); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := compareNodes(want.root, sequential.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+			if err := compareNodes(want.root, concurrent.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestIntegerWeightsTrainLikeRepeatedRows(t *testing.T) {
+	classification := harness.Multiclass(400, 4, 3, 0.1, 7)
+	regression := harness.Regression(300, 3, 0.3, 8)
+	tests := map[string]struct {
+		data [][]float64
+		opts Options
+	}{
+		"gini": {classification, Options{MaxDepth: 5}},
+		// Bin edges are quantiles of the rows, so only with a bin per value do
+		// repeated rows bin like weighted ones.
+		"histogram": {classification, Options{MaxDepth: 5, MaxBins: 1024}},
+		// Deeper MSE trees meet near ties that weighted and repeated updates
+		// round differently.
+		"mse": {regression, Options{MaxDepth: 3, Criterion: MSE}},
+		"mae": {regression[:120], Options{MaxDepth: 3, Criterion: MAE}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			weights := integerWeights(len(tt.data), 9)
+			repeated := NewSequentialDecisionTreeWithOptions(tt.opts)
+			repeated.Train(duplicated(tt.data, weights))
+			weighted := NewConcurrentDecisionTreeWithOptions(tt.opts)
+			if err := weighted.TrainWeighted(tt.data, weights); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := compareNodes(repeated.root, weighted.root, "root"); err != nil {
+				t.Fatal(err)
+			}
+			if weighted.root.Samples != len(tt.data) {
+				t.Errorf("root counts %d rows, want %d", weighted.root.Samples, len(tt.data))
+			}
+			if weighted.numFeatures != len(tt.data[0])-1 {
+				t.Errorf("%d features, want %d", weighted.numFeatures, len(tt.data[0])-1)
+			}
+		})
+	}
+}
+
+func TestIntegerWeightsPruneAndRankLikeRepeatedRows(t *testing.T) {
+	data := harness.Multiclass(300, 4, 3, 0.15, 13)
+	weights := integerWeights(len(data), 14)
+	rows := duplicated(data, weights)
+
+	repeated := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6})
+	repeated.Train(rows)
+	weighted := NewSequentialDecisionTreeWithOptions(Options{MaxDepth: 6})
+	if err := weighted.TrainWeighted(data, weights); err != nil {
+		t.Fatal(err)
+	}
+	if weighted.root.Weight != float64(len(rows)) {
+		t.Fatalf("root weighs %g, want %d", weighted.root.Weight, len(rows))
+	}
+
+	want, got := repeated.CostComplexityPath(), weighted.CostComplexityPath()
+	if len(want.Alphas) != len(got.Alphas) ||
+		harness.MaxAbsDiff(want.Alphas, got.Alphas) > 1e-12 ||
+		harness.MaxAbsDiff(want.Impurities, got.Impurities) > 1e-12 {
+		t.Fatalf("alphas %v with weights, %v with repeated rows", got.Alphas, want.Alphas)
+	}
+	if diff := harness.MaxAbsDiff(repeated.FeatureImportances(), weighted.FeatureImportances()); diff > 1e-12 {
+		t.Errorf("importances differ by %g", diff)
+	}
+
+	// A threshold in the range of the alphas stops some splits, the same
+	// ones either way.
+	opts := Options{MaxDepth: 6, MinImpurityDecrease: want.Alphas[len(want.Alphas)/2]}
+	repeated = NewSequentialDecisionTreeWithOptions(opts)
+	repeated.Train(rows)
+	weighted = NewSequentialDecisionTreeWithOptions(opts)
+	if err := weighted.TrainWeighted(data, weights); err != nil {
+		t.Fatal(err)
+	}
+	if err := compareNodes(repeated.root, weighted.root, "root"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrainWeightedRejectsBadWeights(t *testing.T) {
+	data := harness.Classification(100, 3, 0.1, 10)
+	for name, weights := range map[string][]float64{
+		"short":    make([]float64, 99),
+		"zero":     append(make([]float64, 99), 1),
+		"negative": append([]float64{-1}, integerWeights(99, 11)...),
+		"nan":      append([]float64{math.NaN()}, integerWeights(99, 11)...),
+	} {
+		sequential := NewSequentialDecisionTree()
+		if err := sequential.TrainWeighted(data, weights); err == nil || sequential.root != nil {
+			t.Errorf("%s: sequential tree trained with error %v", name, err)
+		}
+		concurrent := NewConcurrentDecisionTree()
+		if err := concurrent.TrainWeighted(data, weights); err == nil || concurrent.root != nil {
+			t.Errorf("%s: concurrent tree trained with error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package experiments records every training run in an append-only JSONL
+// store so that parameters, metrics and timings survive the terminal session.
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Run is one recorded experiment.
+type Run struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Time      time.Time `json:"time"`
+	Command   string    `json:"command"`
+	Algorithm string    `json:"algorithm"`
+
+	Params map[string]float64 `json:"params"`
+
+	Dataset     string `json:"dataset"`
+	DatasetHash string `json:"dataset_hash"`
+	DatasetRows int    `json:"dataset_rows"`
+	Seed        int64  `json:"seed"`
+
+	GoVersion  string `json:"go_version"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"num_cpu"`
+
+	Metrics map[string]float64 `json:"metrics"`
+	// Timings are stored in milliseconds, keyed by phase.
+	Timings map[string]float64 `json:"timings_ms"`
+}
+
+// NewRun returns a run stamped with a fresh ID, the current time and the
+// runtime environment. Callers fill in the rest before appending it.
+func NewRun(command, algorithm string) Run {
+	now := time.Now()
+	return Run{
+		ID:         fmt.Sprintf("%s-%04x", now.Format("20060102-150405"), rand.Intn(1<<16)),
+		Time:       now,
+		Command:    command,
+		Algorithm:  algorithm,
+		Params:     make(map[string]float64),
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		Metrics:    make(map[string]float64),
+		Timings:    make(map[string]float64),
+	}
+}
+
+// SetTiming records d under name in milliseconds.
+func (r *Run) SetTiming(name string, d time.Duration) {
+	r.Timings[name] = float64(d.Microseconds()) / 1000
+}
+
+// HashRecords returns a SHA-256 digest of the records, so two runs can be
+// checked for having used exactly the same rows in the same order.
+func HashRecords(records [][]string) string {
+	h := sha256.New()
+	for _, record := range records {
+		h.Write([]byte(strings.Join(record, "\x1f")))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Difference is one field that differs between two runs.
+type Difference struct {
+	Field string
+	A, B  string
+}
+
+// Diff lists the fields whose values differ between a and b, skipping the
+// ID and timestamp that always do.
+func Diff(a, b Run) []Difference {
+	var diffs []Difference
+	add := func(field, x, y string) {
+		if x != y {
+			diffs = append(diffs, Difference{Field: field, A: x, B: y})
+		}
+	}
+
+	add("name", a.Name, b.Name)
+	add("command", a.Command, b.Command)
+	add("algorithm", a.Algorithm, b.Algorithm)
+	add("dataset", a.Dataset, b.Dataset)
+	add("dataset_hash", a.DatasetHash, b.DatasetHash)
+	add("dataset_rows", fmt.Sprint(a.DatasetRows), fmt.Sprint(b.DatasetRows))
+	add("seed", fmt.Sprint(a.Seed), fmt.Sprint(b.Seed))
+	add("go_version", a.GoVersion, b.GoVersion)
+	add("gomaxprocs", fmt.Sprint(a.GOMAXPROCS), fmt.Sprint(b.GOMAXPROCS))
+	add("num_cpu", fmt.Sprint(a.NumCPU), fmt.Sprint(b.NumCPU))
+	diffMaps("params.", a.Params, b.Params, add)
+	diffMaps("metrics.", a.Metrics, b.Metrics, add)
+	diffMaps("timings_ms.", a.Timings, b.Timings, add)
+	return diffs
+}
+
+func diffMaps(prefix string, a, b map[string]float64, add func(field, x, y string)) {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		add(prefix+k, formatValue(a, k), formatValue(b, k))
+	}
+}
+
+func formatValue(m map[string]float64, key string) string {
+	v, ok := m[key]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%g", v)
+}
@@ -0,0 +1,143 @@
+package experiments
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is a JSONL file holding one Run per line.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append adds run to the end of the store, creating the file if needed.
+func (s *Store) Append(run Run) error {
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load returns every run in the store in the order they were recorded. A
+// missing file is an empty store.
+func (s *Store) Load() ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, lineNumber, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+// Find returns the run whose ID equals or starts with prefix. A prefix that
+// matches more than one run is an error.
+func (s *Store) Find(prefix string) (Run, error) {
+	runs, err := s.Load()
+	if err != nil {
+		return Run{}, err
+	}
+
+	var match *Run
+	for i := range runs {
+		if runs[i].ID == prefix {
+			return runs[i], nil
+		}
+		if strings.HasPrefix(runs[i].ID, prefix) {
+			if match != nil {
+				return Run{}, fmt.Errorf("run ID %q is ambiguous", prefix)
+			}
+			match = &runs[i]
+		}
+	}
+	if match == nil {
+		return Run{}, fmt.Errorf("no run with ID %q", prefix)
+	}
+	return *match, nil
+}
+
+// Filter selects runs. Zero-valued fields match everything.
+type Filter struct {
+	Command   string
+	Algorithm string
+	Dataset   string
+	Since     time.Time
+	// Params requires the run to have every listed parameter with exactly
+	// that value.
+	Params map[string]float64
+}
+
+func (f Filter) Match(run Run) bool {
+	if f.Command != "" && run.Command != f.Command {
+		return false
+	}
+	if f.Algorithm != "" && run.Algorithm != f.Algorithm {
+		return false
+	}
+	if f.Dataset != "" && run.Dataset != f.Dataset {
+		return false
+	}
+	if !f.Since.IsZero() && run.Time.Before(f.Since) {
+		return false
+	}
+	for name, value := range f.Params {
+		if v, ok := run.Params[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the runs that match f, in the order they were recorded.
+func Select(runs []Run, f Filter) []Run {
+	var selected []Run
+	for _, run := range runs {
+		if f.Match(run) {
+			selected = append(selected, run)
+		}
+	}
+	return selected
+}
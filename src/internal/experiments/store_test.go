@@ -0,0 +1,75 @@
+package experiments
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreRoundTripAndFilter(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "runs.jsonl"))
+
+	runs, err := store.Load()
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("empty store: got %d runs, err %v", len(runs), err)
+	}
+
+	first := NewRun("simulation", "parallel-forest")
+	first.ID = "run-a"
+	first.Params["numTrees"] = 10
+	first.Metrics["accuracy"] = 0.8
+	first.SetTiming("train", 1500*time.Microsecond)
+
+	second := NewRun("compare", "parallel-forest")
+	second.ID = "run-b"
+	second.Params["numTrees"] = 50
+	second.Metrics["accuracy"] = 0.85
+
+	for _, run := range []Run{first, second} {
+		if err := store.Append(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != "run-a" || runs[1].ID != "run-b" {
+		t.Fatalf("loaded %+v", runs)
+	}
+	if runs[0].Timings["train"] != 1.5 {
+		t.Fatalf("train timing %v ms, want 1.5", runs[0].Timings["train"])
+	}
+
+	selected := Select(runs, Filter{Params: map[string]float64{"numTrees": 50}})
+	if len(selected) != 1 || selected[0].ID != "run-b" {
+		t.Fatalf("param filter selected %+v", selected)
+	}
+	if selected := Select(runs, Filter{Command: "simulation"}); len(selected) != 1 {
+		t.Fatalf("command filter selected %d runs", len(selected))
+	}
+
+	if _, err := store.Find("run-"); err == nil {
+		t.Fatal("ambiguous prefix should fail")
+	}
+	found, err := store.Find("run-b")
+	if err != nil || found.Params["numTrees"] != 50 {
+		t.Fatalf("Find: %+v, %v", found, err)
+	}
+
+	diffs := Diff(runs[0], runs[1])
+	fields := map[string]Difference{}
+	for _, d := range diffs {
+		fields[d.Field] = d
+	}
+	if d := fields["params.numTrees"]; d.A != "10" || d.B != "50" {
+		t.Fatalf("numTrees diff %+v", d)
+	}
+	if d := fields["timings_ms.train"]; d.A != "1.5" || d.B != "-" {
+		t.Fatalf("train timing diff %+v", d)
+	}
+	if _, ok := fields["go_version"]; ok {
+		t.Fatal("identical fields should not be reported")
+	}
+}
@@ -0,0 +1,87 @@
+package harness
+
+import (
+	"math"
+	"strconv"
+)
+
+// Predictor is satisfied by every model in the project that scores a single
+// feature row.
+type Predictor interface {
+	Predict(sample []float64) float64
+}
+
+// Predictions runs model over the features of every row in data.
+func Predictions(model Predictor, data [][]float64) []float64 {
+	predictions := make([]float64, len(data))
+	for i, row := range data {
+		predictions[i] = model.Predict(row[:len(row)-1])
+	}
+	return predictions
+}
+
+// Accuracy returns the fraction of rows whose thresholded prediction matches
+// the label. Predictions at or above threshold count as the positive class.
+func Accuracy(predictions []float64, data [][]float64, threshold float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	correct := 0
+	for i, row := range data {
+		positive := row[len(row)-1] > 0
+		if (predictions[i] >= threshold) == positive {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(data))
+}
+
+// Agreement returns the fraction of positions where a and b fall on the same
+// side of threshold.
+func Agreement(a, b []float64, threshold float64) float64 {
+	if len(a) == 0 {
+		return 1
+	}
+	same := 0
+	for i := range a {
+		if (a[i] >= threshold) == (b[i] >= threshold) {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// MaxAbsDiff returns the largest element-wise difference between a and b.
+func MaxAbsDiff(a, b []float64) float64 {
+	maxDiff := 0.0
+	for i := range a {
+		maxDiff = math.Max(maxDiff, math.Abs(a[i]-b[i]))
+	}
+	return maxDiff
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, which
+// compares linear models regardless of the scale their weights converge to.
+func CosineSimilarity(a, b []float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// WithinTolerance reports whether a and b differ by at most tol, either
+// absolutely or relative to the larger magnitude.
+func WithinTolerance(a, b, tol float64) bool {
+	diff := math.Abs(a - b)
+	return diff <= tol || diff <= tol*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,208 @@
+// Package harness provides seeded synthetic datasets and tolerance-based
+// comparisons used to check that the concurrent implementations learn the
+// same models as their sequential counterparts.
+//
+// The equivalence tests that use it live next to each algorithm and are meant
+// to be run with the race detector:
+//
+//	go test -race ./...
+package harness
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Classification returns rows of numFeatures values in [-1, 1] followed by a
+// 0/1 label in the last column. The label is 1 when a fixed random hyperplane
+// scores the row positively, with noise flipping roughly noise*100% of them.
+func Classification(rows, numFeatures int, noise float64, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+
+	weights := make([]float64, numFeatures)
+	for i := range weights {
+		weights[i] = rng.Float64()*2 - 1
+	}
+
+	data := make([][]float64, rows)
+	for r := range data {
+		row := make([]float64, numFeatures+1)
+		score := 0.0
+		for f := 0; f < numFeatures; f++ {
+			row[f] = rng.Float64()*2 - 1
+			score += weights[f] * row[f]
+		}
+		if score > 0 {
+			row[numFeatures] = 1
+		}
+		if rng.Float64() < noise {
+			row[numFeatures] = 1 - row[numFeatures]
+		}
+		data[r] = row
+	}
+	return data
+}
+
+// Multiclass returns rows of numFeatures values in [-1, 1] followed by a class
+// label in 0, 1, ..., classes-1 in the last column. Each class has a fixed
+// random linear score and a row belongs to the class scoring it highest,
+// with noise replacing roughly noise*100% of the labels by a random class.
+func Multiclass(rows, numFeatures, classes int, noise float64, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+
+	weights := make([][]float64, classes)
+	for c := range weights {
+		weights[c] = make([]float64, numFeatures)
+		for f := range weights[c] {
+			weights[c][f] = rng.Float64()*2 - 1
+		}
+	}
+
+	data := make([][]float64, rows)
+	for r := range data {
+		row := make([]float64, numFeatures+1)
+		for f := 0; f < numFeatures; f++ {
+			row[f] = rng.Float64()*2 - 1
+		}
+		best, bestScore := 0, math.Inf(-1)
+		for c := range weights {
+			score := 0.0
+			for f, w := range weights[c] {
+				score += w * row[f]
+			}
+			if score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+		row[numFeatures] = float64(best)
+		if rng.Float64() < noise {
+			row[numFeatures] = float64(rng.Intn(classes))
+		}
+		data[r] = row
+	}
+	return data
+}
+
+// DiscreteClassification is like Classification but rounds every feature to
+// one of levels evenly spaced values, so trees see repeated thresholds and
+// tied splits.
+func DiscreteClassification(rows, numFeatures, levels int, noise float64, seed int64) [][]float64 {
+	return discretize(Classification(rows, numFeatures, noise, seed), levels)
+}
+
+// Regression returns rows of numFeatures values in [-1, 1] followed by a
+// continuous target: a fixed random linear function of the features plus a
+// sine of the first one, with Gaussian noise of standard deviation noise.
+func Regression(rows, numFeatures int, noise float64, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+
+	weights := make([]float64, numFeatures)
+	for i := range weights {
+		weights[i] = rng.Float64()*2 - 1
+	}
+
+	data := make([][]float64, rows)
+	for r := range data {
+		row := make([]float64, numFeatures+1)
+		target := 0.0
+		for f := 0; f < numFeatures; f++ {
+			row[f] = rng.Float64()*2 - 1
+			target += weights[f] * row[f]
+		}
+		target += math.Sin(3 * row[0])
+		row[numFeatures] = target + rng.NormFloat64()*noise
+		data[r] = row
+	}
+	return data
+}
+
+// DiscreteRegression is Regression with every feature rounded to one of
+// levels evenly spaced values.
+func DiscreteRegression(rows, numFeatures, levels int, noise float64, seed int64) [][]float64 {
+	return discretize(Regression(rows, numFeatures, noise, seed), levels)
+}
+
+func discretize(data [][]float64, levels int) [][]float64 {
+	step := 2 / float64(levels-1)
+	for _, row := range data {
+		for f := 0; f < len(row)-1; f++ {
+			row[f] = math.Round((row[f]+1)/step)*step - 1
+		}
+	}
+	return data
+}
+
+// SignedLabels returns a copy of data with 0/1 labels mapped to -1/+1, the
+// encoding expected by the hinge loss in the svm package.
+func SignedLabels(data [][]float64) [][]float64 {
+	signed := make([][]float64, len(data))
+	for i, row := range data {
+		signed[i] = make([]float64, len(row))
+		copy(signed[i], row)
+		if row[len(row)-1] == 1 {
+			signed[i][len(row)-1] = 1
+		} else {
+			signed[i][len(row)-1] = -1
+		}
+	}
+	return signed
+}
+
+// StringRecords converts float rows into the string records consumed by
+// randomforest.ParallelRandomForest, with 1/0 labels written as "SI"/"NO".
+func StringRecords(data [][]float64) [][]string {
+	return LabeledRecords(data, []string{"NO", "SI"})
+}
+
+// LabeledRecords converts float rows into string records whose last column
+// is labels[class] for the class index in the row's last column.
+func LabeledRecords(data [][]float64, labels []string) [][]string {
+	records := make([][]string, len(data))
+	for i, row := range data {
+		record := make([]string, len(row))
+		for j, value := range row[:len(row)-1] {
+			record[j] = formatFloat(value)
+		}
+		record[len(row)-1] = labels[int(row[len(row)-1])]
+		records[i] = record
+	}
+	return records
+}
+
+// Ratings returns a numUsers x numItems matrix generated from random
+// low-rank factors and scaled to the 1-5 range. Each cell is observed with
+// probability density; unobserved cells are 0, as in
+// collaborativefiltering.ConvertToMatrix.
+func Ratings(numUsers, numItems, rank int, density float64, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+
+	userFactors := randomMatrix(rng, numUsers, rank)
+	itemFactors := randomMatrix(rng, numItems, rank)
+
+	ratings := make([][]float64, numUsers)
+	for u := range ratings {
+		ratings[u] = make([]float64, numItems)
+		for i := range ratings[u] {
+			if rng.Float64() >= density {
+				continue
+			}
+			dot := 0.0
+			for f := 0; f < rank; f++ {
+				dot += userFactors[u][f] * itemFactors[i][f]
+			}
+			ratings[u][i] = math.Max(1, math.Min(5, 1+4*dot/float64(rank)))
+		}
+	}
+	return ratings
+}
+
+func randomMatrix(rng *rand.Rand, rows, cols int) [][]float64 {
+	matrix := make([][]float64, rows)
+	for i := range matrix {
+		matrix[i] = make([]float64, cols)
+		for j := range matrix[i] {
+			matrix[i][j] = rng.Float64()
+		}
+	}
+	return matrix
+}
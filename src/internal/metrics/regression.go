@@ -0,0 +1,59 @@
+// Package metrics scores model predictions against their targets.
+package metrics
+
+import "math"
+
+// Regression summarizes how well predictions fit continuous targets.
+type Regression struct {
+	// R2 is the coefficient of determination: 1 for a perfect fit, 0 for
+	// always predicting the mean target and negative for anything worse.
+	R2 float64
+	// RMSE is the root mean squared error, in the units of the target.
+	RMSE float64
+}
+
+// EvaluateRegression compares predictions with targets of the same length.
+func EvaluateRegression(predictions, targets []float64) Regression {
+	return Regression{R2: R2(predictions, targets), RMSE: RMSE(predictions, targets)}
+}
+
+// RMSE is the root mean squared error of predictions, or 0 when there are
+// none.
+func RMSE(predictions, targets []float64) float64 {
+	if len(targets) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i, target := range targets {
+		diff := predictions[i] - target
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(targets)))
+}
+
+// R2 is the coefficient of determination of predictions. Constant targets
+// have no variance to explain, so R2 is 1 when they are predicted exactly
+// and 0 otherwise.
+func R2(predictions, targets []float64) float64 {
+	if len(targets) == 0 {
+		return 0
+	}
+	mean := 0.0
+	for _, target := range targets {
+		mean += target
+	}
+	mean /= float64(len(targets))
+
+	residual, total := 0.0, 0.0
+	for i, target := range targets {
+		residual += (target - predictions[i]) * (target - predictions[i])
+		total += (target - mean) * (target - mean)
+	}
+	if total == 0 {
+		if residual == 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1 - residual/total
+}
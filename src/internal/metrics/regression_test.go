@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluateRegression(t *testing.T) {
+	targets := []float64{1, 2, 3, 4}
+
+	tests := map[string]struct {
+		predictions []float64
+		want        Regression
+	}{
+		"perfect":    {[]float64{1, 2, 3, 4}, Regression{R2: 1, RMSE: 0}},
+		"mean":       {[]float64{2.5, 2.5, 2.5, 2.5}, Regression{R2: 0, RMSE: math.Sqrt(1.25)}},
+		"off by one": {[]float64{2, 3, 4, 5}, Regression{R2: 0.2, RMSE: 1}},
+		"reversed":   {[]float64{4, 3, 2, 1}, Regression{R2: -3, RMSE: math.Sqrt(5)}},
+	}
+
+	for name, tt := range tests {
+		got := EvaluateRegression(tt.predictions, targets)
+		if math.Abs(got.R2-tt.want.R2) > 1e-12 || math.Abs(got.RMSE-tt.want.RMSE) > 1e-12 {
+			t.Errorf("%s: got %+v, want %+v", name, got, tt.want)
+		}
+	}
+}
+
+func TestR2ConstantTargets(t *testing.T) {
+	targets := []float64{3, 3, 3}
+	if got := R2([]float64{3, 3, 3}, targets); got != 1 {
+		t.Errorf("exact prediction of constant targets: R2 = %v, want 1", got)
+	}
+	if got := R2([]float64{3, 4, 3}, targets); got != 0 {
+		t.Errorf("wrong prediction of constant targets: R2 = %v, want 0", got)
+	}
+}
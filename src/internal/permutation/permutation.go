@@ -0,0 +1,212 @@
+// Package permutation measures how much a model relies on each feature by
+// shuffling that feature's column in held-out data and recording how much
+// the model's score drops. It only needs the model's predictions, so it
+// works with every model in the project and, unlike impurity importances,
+// does not favour features with many distinct values.
+package permutation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"concurrente/internal/metrics"
+)
+
+// Scorer returns how well a model does on data, higher being better. It is
+// called concurrently on different copies of the data.
+type Scorer[T any] func(data [][]T) float64
+
+// Predictor is satisfied by every model in the project that predicts from a
+// row of float64 features.
+type Predictor interface {
+	Predict(sample []float64) float64
+}
+
+// ClassPredictor is satisfied by the tree models, which predict one of
+// several classes.
+type ClassPredictor interface {
+	PredictClass(sample []float64) int
+}
+
+// Accuracy scores a binary classifier on rows whose last column is the
+// label: the fraction of rows whose prediction falls on the same side of
+// threshold as the label. Binary classifiers in the project predict 0 or 1,
+// or a probability of 1, so a threshold of 0.5 suits both 0/1 labels and the
+// -1/+1 labels SVMs are trained on. Classifiers of more than two classes
+// need ClassAccuracy.
+func Accuracy(model Predictor, threshold float64) Scorer[float64] {
+	return func(data [][]float64) float64 {
+		correct := 0
+		for _, row := range data {
+			positive := model.Predict(row[:len(row)-1]) >= threshold
+			if positive == (row[len(row)-1] >= threshold) {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(data))
+	}
+}
+
+// ClassAccuracy scores a classifier on rows whose last column is a class
+// label 0, 1, ..., K-1: the fraction of rows whose predicted class is the
+// label.
+func ClassAccuracy(model ClassPredictor) Scorer[float64] {
+	return func(data [][]float64) float64 {
+		correct := 0
+		for _, row := range data {
+			if float64(model.PredictClass(row[:len(row)-1])) == row[len(row)-1] {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(data))
+	}
+}
+
+// R2 scores a regressor by the R² of its predictions of the last column.
+func R2(model Predictor) Scorer[float64] {
+	return func(data [][]float64) float64 {
+		predictions := make([]float64, len(data))
+		targets := make([]float64, len(data))
+		for i, row := range data {
+			predictions[i] = model.Predict(row[:len(row)-1])
+			targets[i] = row[len(row)-1]
+		}
+		return metrics.R2(predictions, targets)
+	}
+}
+
+// Config controls a permutation importance run.
+type Config struct {
+	// Features lists the columns to shuffle. Nil shuffles every column but
+	// the last, which holds the label.
+	Features []int
+	// Repeats is how many times each feature is shuffled. Defaults to 5.
+	Repeats int
+	// Seed fixes the shuffles: repeat r shuffles every feature with the same
+	// permutation of the rows, drawn from Seed+r.
+	Seed int64
+	// Workers is how many shuffled copies are scored at the same time.
+	// Defaults to GOMAXPROCS.
+	Workers int
+}
+
+// Importance is the score drop of one feature.
+type Importance struct {
+	Feature int
+	// Mean and Std summarize Drops; Std is the population standard
+	// deviation.
+	Mean, Std float64
+	// Drops[r] is the baseline score minus the score with the feature
+	// shuffled in repeat r.
+	Drops []float64
+}
+
+// Result is the outcome of Compute.
+type Result struct {
+	// Baseline is the score on the data as given.
+	Baseline float64
+	// Importances follow the order of Config.Features.
+	Importances []Importance
+}
+
+// Compute scores the model on data, then on copies of data with one
+// feature's column shuffled, every feature Repeats times, scoring up to
+// Workers copies in parallel. The result does not depend on Workers.
+func Compute[T any](data [][]T, score Scorer[T], cfg Config) (Result, error) {
+	if len(data) == 0 {
+		return Result{}, fmt.Errorf("permutation: no data")
+	}
+	features := cfg.Features
+	if features == nil {
+		for f := 0; f < len(data[0])-1; f++ {
+			features = append(features, f)
+		}
+	}
+	// The last column is the label, which is not a feature to shuffle.
+	for _, f := range features {
+		if f < 0 || f >= len(data[0])-1 {
+			return Result{}, fmt.Errorf("permutation: feature %d is not a feature column of the data", f)
+		}
+	}
+	repeats := cfg.Repeats
+	if repeats <= 0 {
+		repeats = 5
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	permutations := make([][]int, repeats)
+	for r := range permutations {
+		permutations[r] = rand.New(rand.NewSource(cfg.Seed + int64(r))).Perm(len(data))
+	}
+
+	result := Result{
+		Baseline:    score(data),
+		Importances: make([]Importance, len(features)),
+	}
+	type task struct{ index, repeat int }
+	tasks := make(chan task, len(features)*repeats)
+	for i, f := range features {
+		result.Importances[i] = Importance{Feature: f, Drops: make([]float64, repeats)}
+		for r := 0; r < repeats; r++ {
+			tasks <- task{i, r}
+		}
+	}
+	close(tasks)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(tasks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				shuffled := shuffleColumn(data, features[t.index], permutations[t.repeat])
+				result.Importances[t.index].Drops[t.repeat] = result.Baseline - score(shuffled)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range result.Importances {
+		result.Importances[i].Mean, result.Importances[i].Std = meanStd(result.Importances[i].Drops)
+	}
+	return result, nil
+}
+
+// Ranked returns the importances from the largest mean drop to the smallest,
+// ties in feature order.
+func (r Result) Ranked() []Importance {
+	ranked := append([]Importance(nil), r.Importances...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Mean > ranked[j].Mean })
+	return ranked
+}
+
+// shuffleColumn returns a copy of data in which row i holds row
+// permutation[i]'s value of feature.
+func shuffleColumn[T any](data [][]T, feature int, permutation []int) [][]T {
+	shuffled := make([][]T, len(data))
+	for i, row := range data {
+		shuffled[i] = append([]T(nil), row...)
+		shuffled[i][feature] = data[permutation[i]][feature]
+	}
+	return shuffled
+}
+
+func meanStd(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
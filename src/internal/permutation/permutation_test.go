@@ -0,0 +1,192 @@
+package permutation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+	"concurrente/internal/randomforest"
+	"concurrente/internal/svm"
+)
+
+// withNoise returns harness.Classification rows of two informative features
+// followed by two columns of noise and the label.
+func withNoise(rows int, seed int64) [][]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	data := harness.Classification(rows, 2, 0.05, seed)
+	for i, row := range data {
+		data[i] = []float64{row[0], row[1], rng.Float64(), rng.Float64(), row[2]}
+	}
+	return data
+}
+
+func TestInformativeFeaturesRankFirst(t *testing.T) {
+	data := withNoise(3000, 1)
+	train, test := data[:2000], data[2000:]
+	rf := randomforest.NewSequentialRandomForest(10, 0.8)
+	rf.SetSeed(1)
+	rf.Train(train)
+
+	result, err := Compute(test, Accuracy(rf, 0.5), Config{Repeats: 4, Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Baseline < 0.85 {
+		t.Fatalf("baseline accuracy %.3f", result.Baseline)
+	}
+	ranked := result.Ranked()
+	for _, importance := range ranked[:2] {
+		if importance.Feature > 1 || importance.Mean < 0.05 {
+			t.Errorf("top features %+v", ranked[:2])
+		}
+	}
+	for _, importance := range ranked[2:] {
+		if math.Abs(importance.Mean) > 0.02 {
+			t.Errorf("noise feature %d drops accuracy by %.3f", importance.Feature, importance.Mean)
+		}
+	}
+	for _, importance := range result.Importances {
+		if len(importance.Drops) != 4 || importance.Std < 0 {
+			t.Fatalf("feature %d: drops %v, std %g", importance.Feature, importance.Drops, importance.Std)
+		}
+	}
+}
+
+func TestResultIndependentOfWorkers(t *testing.T) {
+	data := harness.Regression(600, 4, 0.1, 3)
+	tree := decisiontree.NewSequentialRegressionTree()
+	tree.Train(data[:400])
+
+	var results []Result
+	for _, workers := range []int{1, 3, 8} {
+		result, err := Compute(data[400:], R2(tree), Config{Repeats: 3, Seed: 4, Workers: workers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+	for _, result := range results[1:] {
+		for i, importance := range result.Importances {
+			want := results[0].Importances[i]
+			if harness.MaxAbsDiff(importance.Drops, want.Drops) != 0 {
+				t.Fatalf("feature %d drops %v, with one worker %v", i, importance.Drops, want.Drops)
+			}
+		}
+	}
+}
+
+func TestSVMWithSignedLabels(t *testing.T) {
+	data := harness.SignedLabels(withNoise(1000, 5))
+	model := svm.NewSequentialSVM(4, 0.01, 0.01, 20)
+	model.Train(data[:700])
+
+	result, err := Compute(data[700:], Accuracy(model, 0.5), Config{Features: []int{0, 3}, Seed: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Importances) != 2 || result.Importances[1].Feature != 3 {
+		t.Fatalf("importances %+v", result.Importances)
+	}
+	if result.Importances[0].Mean < 0.1 {
+		t.Errorf("shuffling feature 0 drops accuracy by %.3f", result.Importances[0].Mean)
+	}
+}
+
+func TestUnreadFeaturesDoNotMatter(t *testing.T) {
+	data := withNoise(800, 7)
+	stump := decisiontree.NewSequentialDecisionTreeWithOptions(decisiontree.Options{MaxDepth: 1})
+	stump.Train(data)
+
+	result, err := Compute(data, Accuracy(stump, 0.5), Config{Seed: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := stump.Root().Feature
+	for _, importance := range result.Importances {
+		if importance.Feature == read {
+			if importance.Mean <= 0 {
+				t.Errorf("shuffling the root feature %d drops accuracy by %v", read, importance.Drops)
+			}
+			continue
+		}
+		if importance.Mean != 0 || importance.Std != 0 {
+			t.Errorf("feature %d, which the stump never reads, drops accuracy by %v", importance.Feature, importance.Drops)
+		}
+	}
+}
+
+func TestStringRecords(t *testing.T) {
+	records := harness.StringRecords(withNoise(1200, 9))
+	train, test := records[:800], records[800:]
+	rf := randomforest.NewParallelRandomForest(8, 0.8)
+	rf.SetSeed(10)
+	rf.Train(train)
+
+	accuracy := func(data [][]string) float64 {
+		correct := 0
+		for _, record := range data {
+			if rf.PredictClass(record[:len(record)-1]) == record[len(record)-1] {
+				correct++
+			}
+		}
+		return float64(correct) / float64(len(data))
+	}
+	result, err := Compute(test, accuracy, Config{Seed: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The parallel trees never split on column 0.
+	if column0 := result.Importances[0]; column0.Mean != 0 {
+		t.Errorf("column 0 drops accuracy by %v", column0.Drops)
+	}
+	if top := result.Ranked()[0].Feature; top != 1 {
+		t.Errorf("most important column %d, want 1", top)
+	}
+}
+
+func TestComputeRejectsBadInput(t *testing.T) {
+	score := func([][]float64) float64 { return 1 }
+	if _, err := Compute(nil, score, Config{}); err == nil {
+		t.Error("no error without data")
+	}
+	if _, err := Compute([][]float64{{1, 2, 0}}, score, Config{Features: []int{3}}); err == nil {
+		t.Error("no error for a feature past the last column")
+	}
+	if _, err := Compute([][]float64{{1, 2, 0}}, score, Config{Features: []int{2}}); err == nil {
+		t.Error("no error for shuffling the label")
+	}
+}
+
+func TestClassAccuracy(t *testing.T) {
+	data := harness.Multiclass(1500, 4, 3, 0.05, 9)
+	train, test := data[:1000], data[1000:]
+	rf := randomforest.NewSequentialRandomForest(8, 0.8)
+	rf.SetSeed(3)
+	rf.Train(train)
+
+	score := ClassAccuracy(rf)
+	correct := 0
+	for _, row := range test {
+		if rf.PredictClass(row[:4]) == int(row[4]) {
+			correct++
+		}
+	}
+	if want := float64(correct) / float64(len(test)); score(test) != want || want < 0.7 {
+		t.Fatalf("class accuracy %.3f, forest gets %.3f right", score(test), want)
+	}
+	// Thresholding the mean of class indices at 0.5 counts classes 1 and 2
+	// as one.
+	if binary := Accuracy(rf, 0.5)(test); binary == score(test) {
+		t.Errorf("binary accuracy %.3f equals class accuracy on three classes", binary)
+	}
+
+	result, err := Compute(test, score, Config{Repeats: 2, Seed: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranked := result.Ranked(); ranked[0].Mean < 0.05 {
+		t.Errorf("no feature matters to the multiclass forest: %+v", ranked)
+	}
+}
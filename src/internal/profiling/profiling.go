@@ -0,0 +1,260 @@
+// Package profiling captures pprof profiles, execution traces and runtime
+// statistics around a benchmark run.
+package profiling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Options selects which profiles a Session writes. With every field false
+// the session only collects Stats.
+type Options struct {
+	CPU   bool
+	Heap  bool
+	Mutex bool
+	Block bool
+	Trace bool
+	// MutexFraction is passed to runtime.SetMutexProfileFraction. Zero
+	// means 1, every contention event.
+	MutexFraction int
+	// BlockRate is passed to runtime.SetBlockProfileRate. Zero means 1,
+	// every blocking event.
+	BlockRate int
+}
+
+// All enables every profile.
+func All() Options {
+	return Options{CPU: true, Heap: true, Mutex: true, Block: true, Trace: true}
+}
+
+func (o Options) any() bool {
+	return o.CPU || o.Heap || o.Mutex || o.Block || o.Trace
+}
+
+// Stats describes the runtime behaviour of the profiled section.
+type Stats struct {
+	Goroutines     int           `json:"goroutines"`
+	PeakGoroutines int           `json:"peak_goroutines"`
+	NumGC          uint32        `json:"num_gc"`
+	GCPauseTotal   time.Duration `json:"gc_pause_total_ns"`
+	GCPauseMax     time.Duration `json:"gc_pause_max_ns"`
+	// PeakRSS is the peak resident set size of the whole process in bytes,
+	// or 0 where the platform does not report it.
+	PeakRSS int64         `json:"peak_rss_bytes"`
+	Wall    time.Duration `json:"wall_ns"`
+	Files   []string      `json:"files,omitempty"`
+}
+
+// Session is an active capture started by Start.
+type Session struct {
+	dir     string
+	opts    Options
+	start   time.Time
+	memory  runtime.MemStats
+	files   []string
+	closers []func() error
+
+	prevMutexFraction int
+
+	stop           chan struct{}
+	sampler        sync.WaitGroup
+	peakGoroutines int
+}
+
+// Start begins capturing into dir, which is created if needed. dir may be
+// empty when opts enables no profiles.
+func Start(dir string, opts Options) (*Session, error) {
+	s := &Session{dir: dir, opts: opts, stop: make(chan struct{})}
+
+	if opts.any() {
+		if dir == "" {
+			return nil, errors.New("profiling: a directory is required to write profiles")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Mutex {
+		fraction := opts.MutexFraction
+		if fraction <= 0 {
+			fraction = 1
+		}
+		s.prevMutexFraction = runtime.SetMutexProfileFraction(fraction)
+	}
+	if opts.Block {
+		rate := opts.BlockRate
+		if rate <= 0 {
+			rate = 1
+		}
+		runtime.SetBlockProfileRate(rate)
+	}
+
+	if opts.CPU {
+		file, err := s.create("cpu.pprof")
+		if err != nil {
+			s.abort()
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			s.abort()
+			return nil, fmt.Errorf("profiling: %w", err)
+		}
+		s.closers = append(s.closers, func() error {
+			pprof.StopCPUProfile()
+			return file.Close()
+		})
+	}
+	if opts.Trace {
+		file, err := s.create("trace.out")
+		if err != nil {
+			s.abort()
+			return nil, err
+		}
+		if err := trace.Start(file); err != nil {
+			s.abort()
+			return nil, fmt.Errorf("profiling: %w", err)
+		}
+		s.closers = append(s.closers, func() error {
+			trace.Stop()
+			return file.Close()
+		})
+	}
+
+	runtime.ReadMemStats(&s.memory)
+	s.peakGoroutines = runtime.NumGoroutine()
+	s.sampler.Add(1)
+	go s.sampleGoroutines()
+	s.start = time.Now()
+	return s, nil
+}
+
+// sampleGoroutines tracks the peak goroutine count until Stop, not counting
+// itself.
+func (s *Session) sampleGoroutines() {
+	defer s.sampler.Done()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if n := runtime.NumGoroutine() - 1; n > s.peakGoroutines {
+				s.peakGoroutines = n
+			}
+		}
+	}
+}
+
+// Stop ends the capture, writes the remaining profiles plus a stats.json
+// summary and returns the statistics.
+func (s *Session) Stop() (Stats, error) {
+	wall := time.Since(s.start)
+	close(s.stop)
+	s.sampler.Wait()
+
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	stats := Stats{
+		Goroutines:     runtime.NumGoroutine(),
+		PeakGoroutines: s.peakGoroutines,
+		NumGC:          memory.NumGC - s.memory.NumGC,
+		GCPauseTotal:   time.Duration(memory.PauseTotalNs - s.memory.PauseTotalNs),
+		GCPauseMax:     maxPause(&memory, s.memory.NumGC),
+		Wall:           wall,
+	}
+	if rss, ok := peakRSS(); ok {
+		stats.PeakRSS = rss
+	}
+
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i]())
+	}
+	s.closers = nil
+
+	if s.opts.Heap {
+		errs = append(errs, s.writeProfile("heap", "heap.pprof"))
+	}
+	if s.opts.Mutex {
+		errs = append(errs, s.writeProfile("mutex", "mutex.pprof"))
+		runtime.SetMutexProfileFraction(s.prevMutexFraction)
+	}
+	if s.opts.Block {
+		errs = append(errs, s.writeProfile("block", "block.pprof"))
+		runtime.SetBlockProfileRate(0)
+	}
+
+	if s.opts.any() {
+		stats.Files = append(stats.Files, s.files...)
+		errs = append(errs, s.writeStats(stats))
+	}
+	return stats, errors.Join(errs...)
+}
+
+// abort undoes a partially started session.
+func (s *Session) abort() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+	if s.opts.Mutex {
+		runtime.SetMutexProfileFraction(s.prevMutexFraction)
+	}
+	if s.opts.Block {
+		runtime.SetBlockProfileRate(0)
+	}
+}
+
+func (s *Session) create(name string) (*os.File, error) {
+	path := filepath.Join(s.dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, path)
+	return file, nil
+}
+
+func (s *Session) writeProfile(profile, name string) error {
+	file, err := s.create(name)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(profile).WriteTo(file, 0); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (s *Session) writeStats(stats Stats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, "stats.json"), append(data, '\n'), 0o644)
+}
+
+// maxPause returns the longest pause among the collections after sinceGC.
+// MemStats only keeps the last 256 pauses, so older ones are not seen.
+func maxPause(memory *runtime.MemStats, sinceGC uint32) time.Duration {
+	var longest uint64
+	for gc := memory.NumGC; gc > sinceGC && memory.NumGC-gc < uint32(len(memory.PauseNs)); gc-- {
+		pause := memory.PauseNs[(gc+uint32(len(memory.PauseNs))-1)%uint32(len(memory.PauseNs))]
+		if pause > longest {
+			longest = pause
+		}
+	}
+	return time.Duration(longest)
+}
@@ -0,0 +1,73 @@
+package profiling
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionWritesProfilesAndStats(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	session, err := Start(dir, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	release := make(chan struct{})
+	sink := make([][]byte, 0, 64)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+			mu.Lock()
+			sink = append(sink, make([]byte, 1<<16))
+			mu.Unlock()
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	stats, err := session.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PeakGoroutines < 32 {
+		t.Errorf("peak goroutines %d, want at least 32", stats.PeakGoroutines)
+	}
+	if stats.Wall < 20*time.Millisecond {
+		t.Errorf("wall time %v shorter than the workload", stats.Wall)
+	}
+
+	for _, name := range []string{
+		"cpu.pprof", "trace.out", "heap.pprof", "mutex.pprof", "block.pprof", "stats.json",
+	} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+}
+
+func TestSessionWithoutProfilesOnlyCollectsStats(t *testing.T) {
+	session, err := Start("", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := session.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Files) != 0 || stats.Goroutines == 0 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
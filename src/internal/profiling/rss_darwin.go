@@ -0,0 +1,11 @@
+package profiling
+
+import "syscall"
+
+func peakRSS() (int64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return usage.Maxrss, true // macOS reports bytes
+}
@@ -0,0 +1,11 @@
+package profiling
+
+import "syscall"
+
+func peakRSS() (int64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return int64(usage.Maxrss) * 1024, true // Linux reports kilobytes
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package profiling
+
+func peakRSS() (int64, bool) {
+	return 0, false
+}
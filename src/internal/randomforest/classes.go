@@ -0,0 +1,173 @@
+package randomforest
+
+import (
+	"sync"
+
+	"concurrente/internal/decisiontree"
+)
+
+// averageDistributions is soft voting: the mean of the trees' class
+// distributions. A tree whose bootstrap sample missed the highest classes
+// returns a shorter distribution, which counts as zero for those classes.
+func averageDistributions(distributions [][]float64, numClasses int) []float64 {
+	average := make([]float64, numClasses)
+	for _, distribution := range distributions {
+		for class, p := range distribution {
+			average[class] += p
+		}
+	}
+	for class := range average {
+		average[class] /= float64(len(distributions))
+	}
+	return average
+}
+
+// voteShares is hard voting: the fraction of trees whose leaf favours each
+// class.
+func voteShares(distributions [][]float64, numClasses int) []float64 {
+	shares := make([]float64, numClasses)
+	for _, distribution := range distributions {
+		shares[decisiontree.Argmax(distribution)]++
+	}
+	for class := range shares {
+		shares[class] /= float64(len(distributions))
+	}
+	return shares
+}
+
+// PredictProba returns the class probabilities of sample averaged over the
+// trees, with one entry per class in the training data. It returns nil for
+// regression forests.
+func (rf *SequentialRandomForest) PredictProba(sample []float64) []float64 {
+	if rf.numClasses == 0 {
+		return nil
+	}
+	return averageDistributions(rf.distributions(sample), rf.numClasses)
+}
+
+// PredictVotes returns the fraction of trees that vote for each class.
+func (rf *SequentialRandomForest) PredictVotes(sample []float64) []float64 {
+	if rf.numClasses == 0 {
+		return nil
+	}
+	return voteShares(rf.distributions(sample), rf.numClasses)
+}
+
+// PredictClass returns the class with the highest averaged probability.
+func (rf *SequentialRandomForest) PredictClass(sample []float64) int {
+	return decisiontree.Argmax(rf.PredictProba(sample))
+}
+
+func (rf *SequentialRandomForest) distributions(sample []float64) [][]float64 {
+	distributions := make([][]float64, len(rf.trees))
+	for i, tree := range rf.trees {
+		distributions[i] = tree.PredictProba(sample)
+	}
+	return distributions
+}
+
+// PredictProba returns the class probabilities of sample averaged over the
+// trees, with one entry per class in the training data. It returns nil for
+// regression forests.
+func (rf *ConcurrentRandomForest) PredictProba(sample []float64) []float64 {
+	if rf.numClasses == 0 {
+		return nil
+	}
+	return averageDistributions(rf.distributions(sample), rf.numClasses)
+}
+
+// PredictVotes returns the fraction of trees that vote for each class.
+func (rf *ConcurrentRandomForest) PredictVotes(sample []float64) []float64 {
+	if rf.numClasses == 0 {
+		return nil
+	}
+	return voteShares(rf.distributions(sample), rf.numClasses)
+}
+
+// PredictClass returns the class with the highest averaged probability.
+func (rf *ConcurrentRandomForest) PredictClass(sample []float64) int {
+	return decisiontree.Argmax(rf.PredictProba(sample))
+}
+
+// distributions queries every tree in its own goroutine.
+func (rf *ConcurrentRandomForest) distributions(sample []float64) [][]float64 {
+	distributions := make([][]float64, len(rf.trees))
+	var wg sync.WaitGroup
+	wg.Add(len(rf.trees))
+	for i := range rf.trees {
+		go func(index int) {
+			defer wg.Done()
+			distributions[index] = rf.trees[index].PredictProba(sample)
+		}(i)
+	}
+	wg.Wait()
+	return distributions
+}
+
+// PredictProba returns the class probabilities of sample averaged over the
+// trees, indexed like Classes.
+func (rf *ParallelRandomForest) PredictProba(sample []string) []float64 {
+	distributions := make([][]float64, len(rf.trees))
+	var wg sync.WaitGroup
+	wg.Add(len(rf.trees))
+	for i := range rf.trees {
+		go func(index int) {
+			defer wg.Done()
+			distributions[index] = rf.trees[index].PredictProba(sample)
+		}(i)
+	}
+	wg.Wait()
+	return averageDistributions(distributions, len(rf.classes))
+}
+
+// PredictClass returns the label with the highest averaged probability.
+func (rf *ParallelRandomForest) PredictClass(sample []string) string {
+	return rf.classes[decisiontree.Argmax(rf.PredictProba(sample))]
+}
+
+// Classes returns the labels the forest predicts, in the order of
+// PredictProba.
+func (rf *ParallelRandomForest) Classes() []string {
+	return rf.classes
+}
+
+// PredictLeaf returns, for every tree, the ID of the leaf sample falls into.
+// Together they encode sample as the leaves it shares with other samples.
+func (rf *SequentialRandomForest) PredictLeaf(sample []float64) []int {
+	leaves := make([]int, len(rf.trees))
+	for i, tree := range rf.trees {
+		leaves[i] = tree.PredictLeaf(sample)
+	}
+	return leaves
+}
+
+// PredictLeaf returns, for every tree, the ID of the leaf sample falls into,
+// querying every tree in its own goroutine.
+func (rf *ConcurrentRandomForest) PredictLeaf(sample []float64) []int {
+	leaves := make([]int, len(rf.trees))
+	var wg sync.WaitGroup
+	wg.Add(len(rf.trees))
+	for i := range rf.trees {
+		go func(index int) {
+			defer wg.Done()
+			leaves[index] = rf.trees[index].PredictLeaf(sample)
+		}(i)
+	}
+	wg.Wait()
+	return leaves
+}
+
+// PredictLeaf returns, for every tree, the ID of the node sample stops at.
+func (rf *ParallelRandomForest) PredictLeaf(sample []string) []int {
+	leaves := make([]int, len(rf.trees))
+	var wg sync.WaitGroup
+	wg.Add(len(rf.trees))
+	for i := range rf.trees {
+		go func(index int) {
+			defer wg.Done()
+			leaves[index] = rf.trees[index].PredictLeaf(sample)
+		}(i)
+	}
+	wg.Wait()
+	return leaves
+}
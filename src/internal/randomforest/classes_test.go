@@ -0,0 +1,157 @@
+package randomforest
+
+import (
+	"slices"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+)
+
+func TestForestsVoteOverClasses(t *testing.T) {
+	data := harness.Multiclass(900, 4, 3, 0.05, 12)
+	train, test := data[:600], data[600:]
+
+	sequential := NewSequentialRandomForest(10, 0.8)
+	sequential.SetSeed(4)
+	sequential.Train(train)
+	concurrent := NewConcurrentRandomForest(10, 0.8)
+	concurrent.SetSeed(4)
+	concurrent.Train(train)
+
+	correct := 0
+	for i, row := range test {
+		sample := row[:len(row)-1]
+		want, got := sequential.PredictProba(sample), concurrent.PredictProba(sample)
+		if len(want) != 3 || harness.MaxAbsDiff(want, got) > 1e-12 {
+			t.Fatalf("row %d: sequential %v, concurrent %v", i, want, got)
+		}
+		votes := sequential.PredictVotes(sample)
+		if !harness.WithinTolerance(votes[0]+votes[1]+votes[2], 1, 1e-12) {
+			t.Fatalf("row %d: vote shares %v", i, votes)
+		}
+		if concurrent.PredictClass(sample) == int(row[len(row)-1]) {
+			correct++
+		}
+	}
+	if accuracy := float64(correct) / float64(len(test)); accuracy < 0.75 {
+		t.Errorf("accuracy %.3f on three classes", accuracy)
+	}
+}
+
+func TestParallelForestClasses(t *testing.T) {
+	labels := []string{"alto", "bajo", "medio"}
+	data := harness.Multiclass(900, 4, 3, 0.05, 22)
+	train, test := harness.LabeledRecords(data[:600], labels), harness.LabeledRecords(data[600:], labels)
+
+	sorted := NewParallelRandomForest(8, 0.8)
+	sorted.SetSeed(3)
+	sorted.Train(train)
+	if !slices.Equal(sorted.Classes(), labels) {
+		t.Fatalf("classes %v, want %v", sorted.Classes(), labels)
+	}
+
+	reversed := NewParallelRandomForest(8, 0.8)
+	reversed.SetSeed(3)
+	reversed.SetClasses([]string{"medio", "bajo"})
+	reversed.Train(train)
+	if want := []string{"medio", "bajo", "alto"}; !slices.Equal(reversed.Classes(), want) {
+		t.Fatalf("classes %v, want %v", reversed.Classes(), want)
+	}
+
+	correct := map[*ParallelRandomForest]int{}
+	for _, record := range test {
+		sample := record[:len(record)-1]
+		for _, rf := range []*ParallelRandomForest{sorted, reversed} {
+			proba := rf.PredictProba(sample)
+			if len(proba) != 3 || !harness.WithinTolerance(proba[0]+proba[1]+proba[2], 1, 1e-12) {
+				t.Fatalf("probabilities %v", proba)
+			}
+			if rf.PredictClass(sample) == record[len(record)-1] {
+				correct[rf]++
+			}
+		}
+	}
+	// The median splits of the parallel trees are much coarser than the
+	// threshold search, so only a clear improvement over chance is expected
+	// whichever way the classes are numbered.
+	for rf, n := range correct {
+		if accuracy := float64(n) / float64(len(test)); accuracy < 0.5 {
+			t.Errorf("classes %v: accuracy %.3f on three classes", rf.Classes(), accuracy)
+		}
+	}
+}
+
+func TestParallelForestKeepsBinaryEncoding(t *testing.T) {
+	data := harness.Classification(300, 4, 0.1, 9)
+	rf := NewParallelRandomForest(4, 0.8)
+	rf.SetTreeOptions(decisiontree.Options{MaxDepth: 4})
+	rf.Train(harness.StringRecords(data))
+
+	if !slices.Equal(rf.Classes(), []string{"NO", "SI"}) {
+		t.Fatalf("classes %v", rf.Classes())
+	}
+	for _, record := range harness.StringRecords(data[:50]) {
+		sample := record[:len(record)-1]
+		if p := rf.PredictProba(sample)[1]; !harness.WithinTolerance(p, rf.Predict(sample), 1e-12) {
+			t.Fatalf("P(SI) = %g, Predict = %g", p, rf.Predict(sample))
+		}
+	}
+
+	// Sorted labels would make "SI" class 0 of data without "NO" and class 2
+	// after an empty label; SetClasses keeps it class 1.
+	var onlySI [][]string
+	for _, record := range harness.StringRecords(data) {
+		if record[len(record)-1] == "SI" {
+			onlySI = append(onlySI, record)
+		}
+	}
+	onlySI[0][len(onlySI[0])-1] = ""
+	fixed := NewParallelRandomForest(4, 0.8)
+	fixed.SetClasses([]string{"NO", "SI"})
+	fixed.Train(onlySI)
+	if !slices.Equal(fixed.Classes(), []string{"NO", "SI", ""}) {
+		t.Fatalf("classes %v", fixed.Classes())
+	}
+	if class := fixed.PredictClass(onlySI[1][:len(onlySI[1])-1]); class != "SI" {
+		t.Fatalf("predicted %q for data that is all \"SI\"", class)
+	}
+}
+
+func TestForestLeavesAndSmoothing(t *testing.T) {
+	data := harness.Multiclass(600, 4, 3, 0.1, 5)
+	opts := decisiontree.Options{MaxDepth: 6, Smoothing: decisiontree.Smoothing{Laplace: 1}}
+	sequential := NewSequentialRandomForest(8, 0.8)
+	sequential.SetSeed(2)
+	sequential.SetTreeOptions(opts)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(8, 0.8)
+	concurrent.SetSeed(2)
+	concurrent.SetTreeOptions(opts)
+	concurrent.Train(data)
+
+	for i, row := range data {
+		sample := row[:len(row)-1]
+		leaves := sequential.PredictLeaf(sample)
+		if len(leaves) != 8 || !slices.Equal(leaves, concurrent.PredictLeaf(sample)) {
+			t.Fatalf("row %d: sequential leaves %v, concurrent %v", i, leaves, concurrent.PredictLeaf(sample))
+		}
+		proba := concurrent.PredictProba(sample)
+		if !harness.WithinTolerance(proba[0]+proba[1]+proba[2], 1, 1e-12) || slices.Min(proba) <= 0 {
+			t.Fatalf("row %d: smoothed probabilities %v", i, proba)
+		}
+	}
+
+	labels := []string{"alto", "bajo", "medio"}
+	parallel := NewParallelRandomForest(5, 0.8)
+	parallel.SetSeed(2)
+	parallel.Train(harness.LabeledRecords(data, labels))
+	for _, record := range harness.LabeledRecords(data[:50], labels) {
+		leaves := parallel.PredictLeaf(record)
+		for i, tree := range parallel.trees {
+			if leaves[i] != tree.PredictLeaf(record) {
+				t.Fatalf("tree %d: forest leaf %d, tree leaf %d", i, leaves[i], tree.PredictLeaf(record))
+			}
+		}
+	}
+}
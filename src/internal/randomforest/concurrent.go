@@ -5,33 +5,93 @@ import (
 	"sync"
 
 	"concurrente/internal/decisiontree"
+	"concurrente/internal/workpool"
 )
 
 type ConcurrentRandomForest struct {
 	trees       []*decisiontree.ConcurrentDecisionTree
 	numTrees    int
 	subsetRatio float64
+	seed        int64
+	treeOpts    decisiontree.Options
+	// inBag[i] are the training rows tree i's bootstrap sample drew.
+	inBag [][]int
+	// numClasses is the number of classes in the data Train was given, or 0
+	// when the trees are regression trees.
+	numClasses int
 }
 
 func NewConcurrentRandomForest(numTrees int, subsetRatio float64) *ConcurrentRandomForest {
 	return &ConcurrentRandomForest{
-		trees:       make([]*decisiontree.ConcurrentDecisionTree, numTrees),
 		numTrees:    numTrees,
 		subsetRatio: subsetRatio,
+		seed:        rand.Int63(),
+		treeOpts:    decisiontree.DefaultOptions(),
 	}
 }
 
+// SetTreeOptions sets the stopping criteria of the trees grown by Train.
+func (rf *ConcurrentRandomForest) SetTreeOptions(opts decisiontree.Options) {
+	rf.treeOpts = opts
+}
+
+// SetSeed fixes the seed the bootstrap samples are drawn from. Tree i uses
+// seed+i regardless of which goroutine builds it.
+func (rf *ConcurrentRandomForest) SetSeed(seed int64) {
+	rf.seed = seed
+}
+
+// Train grows the forest on data, whose last column is the label. It panics
+// with the error TrainWeighted would return for labels that are not classes.
 func (rf *ConcurrentRandomForest) Train(data [][]float64) {
+	if err := rf.TrainWeighted(data, nil); err != nil {
+		panic(err)
+	}
+}
+
+// TrainWeighted is Train with bootstrap samples that draw row i with
+// probability proportional to weights[i]. The trees then train on their
+// samples unweighted, since a heavier row already appears more often. Nil
+// weights train like Train. It returns an error, and leaves the forest as it
+// was, unless there is one non-negative, finite weight per row and they add
+// up to more than 0, or the labels fail decisiontree.CheckLabels.
+func (rf *ConcurrentRandomForest) TrainWeighted(data [][]float64, weights []float64) error {
+	if err := decisiontree.CheckLabels(rf.treeOpts.Criterion, data); err != nil {
+		return err
+	}
+	cumulative, err := cumulativeWeights(weights, len(data))
+	if err != nil {
+		return err
+	}
+	rf.train(data, cumulative)
+	return nil
+}
+
+func (rf *ConcurrentRandomForest) train(data [][]float64, cumulative []float64) {
+	rf.trees = make([]*decisiontree.ConcurrentDecisionTree, rf.numTrees)
+	rf.inBag = make([][]int, rf.numTrees)
+	rf.numClasses = grownClasses(rf.treeOpts, 0, data)
+	rf.growTrees(data, cumulative, 0)
+}
+
+// growTrees trains the trees from first on bootstrap samples of data, each
+// in its own goroutine. The trees share one pool for their subtree tasks.
+func (rf *ConcurrentRandomForest) growTrees(data [][]float64, cumulative []float64, first int) {
+	pool := workpool.New(0)
+	defer pool.Close()
 	var wg sync.WaitGroup
-	wg.Add(rf.numTrees)
+	wg.Add(rf.numTrees - first)
 
-	for i := 0; i < rf.numTrees; i++ {
+	for i := first; i < rf.numTrees; i++ {
 		go func(index int) {
 			defer wg.Done()
-			bootstrapSample := rf.createBootstrapSample(data)
-			tree := decisiontree.NewConcurrentDecisionTree()
+			rng := rand.New(rand.NewSource(rf.seed + int64(index)))
+			bootstrapSample, indices := rf.createBootstrapSample(data, cumulative, rng)
+			tree := decisiontree.NewConcurrentDecisionTreeWithOptions(treeOptions(rf.treeOpts, rng))
+			tree.SetPool(pool)
 			tree.Train(bootstrapSample)
 			rf.trees[index] = tree
+			rf.inBag[index] = indices
 		}(i)
 	}
 
@@ -39,9 +99,9 @@ func (rf *ConcurrentRandomForest) Train(data [][]float64) {
 }
 
 func (rf *ConcurrentRandomForest) Predict(sample []float64) float64 {
-	predictions := make([]float64, rf.numTrees)
+	predictions := make([]float64, len(rf.trees))
 	var wg sync.WaitGroup
-	wg.Add(rf.numTrees)
+	wg.Add(len(rf.trees))
 
 	for i := range rf.trees {
 		go func(index int) {
@@ -54,14 +114,19 @@ func (rf *ConcurrentRandomForest) Predict(sample []float64) float64 {
 	return rf.majorityVote(predictions)
 }
 
-func (rf *ConcurrentRandomForest) createBootstrapSample(data [][]float64) [][]float64 {
+func (rf *ConcurrentRandomForest) createBootstrapSample(
+	data [][]float64,
+	cumulative []float64,
+	rng *rand.Rand,
+) ([][]float64, []int) {
 	sampleSize := int(float64(len(data)) * rf.subsetRatio)
 	sample := make([][]float64, sampleSize)
+	indices := make([]int, sampleSize)
 	for i := 0; i < sampleSize; i++ {
-		randomIndex := rand.Intn(len(data))
-		sample[i] = data[randomIndex]
+		indices[i] = drawIndex(rng, len(data), cumulative)
+		sample[i] = data[indices[i]]
 	}
-	return sample
+	return sample, indices
 }
 
 func (rf *ConcurrentRandomForest) majorityVote(predictions []float64) float64 {
@@ -0,0 +1,83 @@
+package randomforest
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestConcurrentForestMatchesSequential(t *testing.T) {
+	data := harness.Classification(500, 5, 0.1, 11)
+	train, test := data[:300], data[300:]
+
+	for _, seed := range []int64{1, 2, 3} {
+		sequential := NewSequentialRandomForest(6, 0.8)
+		sequential.SetSeed(seed)
+		sequential.Train(train)
+
+		concurrent := NewConcurrentRandomForest(6, 0.8)
+		concurrent.SetSeed(seed)
+		concurrent.Train(train)
+
+		want := harness.Predictions(sequential, test)
+		got := harness.Predictions(concurrent, test)
+		if diff := harness.MaxAbsDiff(want, got); diff > 1e-12 {
+			t.Fatalf("seed %d: predictions differ by up to %g", seed, diff)
+		}
+	}
+}
+
+func TestParallelForestIndependentOfWorkerCount(t *testing.T) {
+	data := harness.StringRecords(harness.Classification(500, 5, 0.1, 21))
+	train, test := data[:300], data[300:]
+
+	single := NewParallelRandomForest(8, 0.8)
+	single.SetSeed(7)
+	single.numWorkers = 1
+	single.Train(train)
+
+	parallel := NewParallelRandomForest(8, 0.8)
+	parallel.SetSeed(7)
+	parallel.numWorkers = 4
+	parallel.Train(train)
+
+	for i, record := range test {
+		features := record[:len(record)-1]
+		want, got := single.Predict(features), parallel.Predict(features)
+		if !harness.WithinTolerance(want, got, 1e-12) {
+			t.Fatalf("row %d: 1 worker predicts %g, 4 workers predict %g", i, want, got)
+		}
+	}
+}
+
+func TestParallelForestLearnsSameTaskAsSequential(t *testing.T) {
+	data := harness.Classification(700, 4, 0.05, 31)
+	train, test := data[:400], data[400:]
+
+	sequential := NewSequentialRandomForest(8, 0.8)
+	sequential.SetSeed(5)
+	sequential.Train(train)
+	sequentialAccuracy := harness.Accuracy(harness.Predictions(sequential, test), test, 0.5)
+
+	parallel := NewParallelRandomForest(8, 0.8)
+	parallel.SetSeed(5)
+	parallel.Train(harness.StringRecords(train))
+	correct := 0
+	for i, record := range harness.StringRecords(test) {
+		prediction := parallel.Predict(record[:len(record)-1])
+		if (prediction >= 0.5) == (test[i][len(test[i])-1] == 1) {
+			correct++
+		}
+	}
+	parallelAccuracy := float64(correct) / float64(len(test))
+
+	// The parallel forest splits on medians instead of searching thresholds,
+	// so it is only expected to land in the same accuracy range.
+	if parallelAccuracy < sequentialAccuracy-0.15 {
+		t.Fatalf(
+			"parallel forest accuracy %.3f, sequential %.3f",
+			parallelAccuracy,
+			sequentialAccuracy,
+		)
+	}
+}
@@ -0,0 +1,64 @@
+package randomforest
+
+import (
+	"io"
+
+	"concurrente/internal/decisiontree"
+)
+
+// Export writes every tree of the forest in format, one of
+// decisiontree.ExportFormats.
+func (rf *SequentialRandomForest) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	roots := make([]*decisiontree.Node, len(rf.trees))
+	for i, tree := range rf.trees {
+		roots[i] = tree.Root()
+	}
+	return decisiontree.Export(w, format, opts, roots...)
+}
+
+// Export writes every tree of the forest in format, one of
+// decisiontree.ExportFormats.
+func (rf *ConcurrentRandomForest) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	roots := make([]*decisiontree.Node, len(rf.trees))
+	for i, tree := range rf.trees {
+		roots[i] = tree.Root()
+	}
+	return decisiontree.Export(w, format, opts, roots...)
+}
+
+// Export writes the tree in format, one of decisiontree.ExportFormats.
+// Feature i is column i of the records and classes default to the tree's
+// labels.
+func (tree *ParallelDecisionTree) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	return decisiontree.Export(w, format, parallelExportOptions(opts, tree.classes), tree.root)
+}
+
+// Export writes every tree of the forest like ParallelDecisionTree.Export.
+func (rf *ParallelRandomForest) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	roots := make([]*decisiontree.Node, len(rf.trees))
+	for i, tree := range rf.trees {
+		roots[i] = tree.root
+	}
+	return decisiontree.Export(w, format, parallelExportOptions(opts, rf.classes), roots...)
+}
+
+// parallelExportOptions names the classes after the labels unless opts
+// already does, and marks the trees as strict.
+func parallelExportOptions(opts decisiontree.ExportOptions, classes []string) decisiontree.ExportOptions {
+	if len(opts.ClassNames) == 0 {
+		opts.ClassNames = classes
+	}
+	opts.Strict = true
+	return opts
+}
+
+// Roots returns the roots of the forest's trees, which route samples like
+// ParallelDecisionTree: column Feature of a record goes left when it is
+// below Threshold.
+func (rf *ParallelRandomForest) Roots() []*decisiontree.Node {
+	roots := make([]*decisiontree.Node, len(rf.trees))
+	for i, tree := range rf.trees {
+		roots[i] = tree.root
+	}
+	return roots
+}
@@ -0,0 +1,105 @@
+package randomforest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+)
+
+// exportedNode mirrors the nodes decisiontree.Export writes as JSON.
+type exportedNode struct {
+	FeatureIndex *int          `json:"feature_index"`
+	Operator     string        `json:"operator"`
+	Threshold    *float64      `json:"threshold"`
+	Samples      int           `json:"samples"`
+	Class        string        `json:"class"`
+	Left         *exportedNode `json:"left"`
+	Right        *exportedNode `json:"right"`
+}
+
+func TestForestsExportEveryTree(t *testing.T) {
+	data := harness.Classification(300, 4, 0.1, 8)
+	opts := decisiontree.ExportOptions{FeatureNames: []string{"a", "b", "c", "d"}}
+
+	sequential := NewSequentialRandomForest(3, 0.8)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(4, 0.8)
+	concurrent.Train(data)
+	parallel := NewParallelRandomForest(5, 0.8)
+	parallel.Train(harness.StringRecords(data))
+
+	for name, tt := range map[string]struct {
+		export func(*bytes.Buffer, string) error
+		trees  int
+	}{
+		"sequential": {func(b *bytes.Buffer, f string) error { return sequential.Export(b, f, opts) }, 3},
+		"concurrent": {func(b *bytes.Buffer, f string) error { return concurrent.Export(b, f, opts) }, 4},
+		"parallel":   {func(b *bytes.Buffer, f string) error { return parallel.Export(b, f, opts) }, 5},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.export(&buf, "json"); err != nil {
+				t.Fatal(err)
+			}
+			var doc struct{ Trees []exportedNode }
+			if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if len(doc.Trees) != tt.trees {
+				t.Fatalf("%d trees exported, want %d", len(doc.Trees), tt.trees)
+			}
+
+			buf.Reset()
+			if err := tt.export(&buf, "rules"); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Count(buf.String(), "# tree "); got != tt.trees {
+				t.Fatalf("%d rule sections, want %d", got, tt.trees)
+			}
+		})
+	}
+}
+
+func TestParallelTreeExportRoutesLikePredict(t *testing.T) {
+	records := harness.LabeledRecords(harness.Multiclass(400, 4, 3, 0.1, 6), []string{"x", "y", "z"})
+	tree := NewParallelDecisionTree(decisiontree.Options{MaxDepth: 5})
+	tree.Train(records)
+
+	var buf bytes.Buffer
+	if err := tree.Export(&buf, "json", decisiontree.ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		ClassNames []string `json:"class_names"`
+		Trees      []*exportedNode
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(doc.ClassNames, ",") != "x,y,z" {
+		t.Fatalf("class names %v", doc.ClassNames)
+	}
+
+	for _, record := range records {
+		node := doc.Trees[0]
+		for node.Left != nil {
+			if node.Operator != "<" {
+				t.Fatalf("parallel trees split with <, exported %q", node.Operator)
+			}
+			value, _ := strconv.ParseFloat(record[*node.FeatureIndex], 64)
+			if value < *node.Threshold {
+				node = node.Left
+			} else {
+				node = node.Right
+			}
+		}
+		if want := tree.PredictClass(record[:len(record)-1]); node.Class != want {
+			t.Fatalf("exported leaf predicts %q, tree %q", node.Class, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package randomforest
+
+import (
+	"io"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"concurrente/internal/decisiontree"
+)
+
+// SequentialExtraTrees is an ensemble of extremely randomized trees grown one
+// after another. Unlike a random forest every tree sees every training row;
+// the trees differ because each split is drawn at random instead of
+// searched, see decisiontree.Options.RandomThresholds. Drawing a split is a
+// single pass over the node's rows, which makes the trees cheaper to grow,
+// and averaging many of them lowers the variance of the ensemble.
+type SequentialExtraTrees struct {
+	trees    []*decisiontree.SequentialDecisionTree
+	numTrees int
+	seed     int64
+	treeOpts decisiontree.Options
+	// numClasses is the number of classes in the data Train was given, or 0
+	// when the trees are regression trees.
+	numClasses int
+}
+
+// DefaultExtraTreesOptions returns the options ExtraTrees grow their trees
+// with: deep trees, as averaging makes up for their variance, drawing a
+// split of the square root of the features at every node.
+func DefaultExtraTreesOptions() decisiontree.Options {
+	return decisiontree.Options{
+		MaxDepth:         10,
+		MinSamplesSplit:  2,
+		MaxFeatures:      decisiontree.SqrtFeatures,
+		RandomThresholds: true,
+	}
+}
+
+func NewSequentialExtraTrees(numTrees int) *SequentialExtraTrees {
+	return &SequentialExtraTrees{
+		numTrees: numTrees,
+		seed:     rand.Int63(),
+		treeOpts: DefaultExtraTreesOptions(),
+	}
+}
+
+// SetTreeOptions sets the options of the trees grown by Train, which always
+// draw their splits whatever opts.RandomThresholds says.
+func (et *SequentialExtraTrees) SetTreeOptions(opts decisiontree.Options) {
+	et.treeOpts = opts
+}
+
+// SetSeed fixes the seed the splits are drawn from. Tree i draws from
+// seed+i, so two ensembles with the same seed grow the same trees.
+func (et *SequentialExtraTrees) SetSeed(seed int64) {
+	et.seed = seed
+}
+
+func (et *SequentialExtraTrees) Train(data [][]float64) {
+	et.startTraining(data)
+	et.growTrees(data, 0)
+}
+
+// startTraining discards the trees and records the classes of data before
+// new ones are grown.
+func (et *SequentialExtraTrees) startTraining(data [][]float64) {
+	et.trees = make([]*decisiontree.SequentialDecisionTree, et.numTrees)
+	et.numClasses = grownClasses(et.treeOpts, 0, data)
+}
+
+// growTrees grows the trees from first on data.
+func (et *SequentialExtraTrees) growTrees(data [][]float64, first int) {
+	for i := first; i < et.numTrees; i++ {
+		et.trees[i] = et.growTree(data, i)
+	}
+}
+
+// growTree grows tree i on all of data.
+func (et *SequentialExtraTrees) growTree(data [][]float64, i int) *decisiontree.SequentialDecisionTree {
+	rng := rand.New(rand.NewSource(et.seed + int64(i)))
+	opts := treeOptions(et.treeOpts, rng)
+	opts.RandomThresholds = true
+	tree := decisiontree.NewSequentialDecisionTreeWithOptions(opts)
+	tree.Train(data)
+	return tree
+}
+
+// Predict returns the mean prediction of the trees.
+func (et *SequentialExtraTrees) Predict(sample []float64) float64 {
+	sum := 0.0
+	for _, tree := range et.trees {
+		sum += tree.Predict(sample)
+	}
+	return sum / float64(len(et.trees))
+}
+
+// PredictProba returns the class probabilities of sample averaged over the
+// trees, or nil for regression trees.
+func (et *SequentialExtraTrees) PredictProba(sample []float64) []float64 {
+	if et.numClasses == 0 {
+		return nil
+	}
+	distributions := make([][]float64, len(et.trees))
+	for i, tree := range et.trees {
+		distributions[i] = tree.PredictProba(sample)
+	}
+	return averageDistributions(distributions, et.numClasses)
+}
+
+// PredictClass returns the class with the highest averaged probability.
+func (et *SequentialExtraTrees) PredictClass(sample []float64) int {
+	return decisiontree.Argmax(et.PredictProba(sample))
+}
+
+// FeatureImportances returns the mean of the trees' normalized impurity
+// decreases, normalized to sum to 1.
+func (et *SequentialExtraTrees) FeatureImportances() []float64 {
+	importances := make([][]float64, len(et.trees))
+	for i, tree := range et.trees {
+		importances[i] = tree.FeatureImportances()
+	}
+	return decisiontree.MeanImportances(importances)
+}
+
+// Flatten returns the trained ensemble laid out for inference.
+func (et *SequentialExtraTrees) Flatten() *decisiontree.FlatForest {
+	trees := make([]*decisiontree.FlatTree, len(et.trees))
+	for i, tree := range et.trees {
+		trees[i] = tree.Flatten()
+	}
+	return decisiontree.NewFlatForest(trees...)
+}
+
+// Export writes every tree of the ensemble in format, one of
+// decisiontree.ExportFormats.
+func (et *SequentialExtraTrees) Export(w io.Writer, format string, opts decisiontree.ExportOptions) error {
+	roots := make([]*decisiontree.Node, len(et.trees))
+	for i, tree := range et.trees {
+		roots[i] = tree.Root()
+	}
+	return decisiontree.Export(w, format, opts, roots...)
+}
+
+// ParallelExtraTrees is SequentialExtraTrees with the trees grown by a pool of
+// workers. It grows the same trees and predicts the same values.
+type ParallelExtraTrees struct {
+	*SequentialExtraTrees
+	numWorkers int
+}
+
+func NewParallelExtraTrees(numTrees int) *ParallelExtraTrees {
+	return &ParallelExtraTrees{
+		SequentialExtraTrees: NewSequentialExtraTrees(numTrees),
+		numWorkers:           runtime.GOMAXPROCS(0),
+	}
+}
+
+// SetNumWorkers sets how many goroutines grow trees, which defaults to
+// GOMAXPROCS.
+func (et *ParallelExtraTrees) SetNumWorkers(numWorkers int) {
+	if numWorkers > 0 {
+		et.numWorkers = numWorkers
+	}
+}
+
+func (et *ParallelExtraTrees) Train(data [][]float64) {
+	et.startTraining(data)
+	et.growTrees(data, 0)
+}
+
+// growTrees grows the trees from first on data, shared out among the
+// workers.
+func (et *ParallelExtraTrees) growTrees(data [][]float64, first int) {
+	treeChan := make(chan int, et.numTrees-first)
+	for i := first; i < et.numTrees; i++ {
+		treeChan <- i
+	}
+	close(treeChan)
+
+	var wg sync.WaitGroup
+	for w := 0; w < et.numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for treeIndex := range treeChan {
+				et.trees[treeIndex] = et.growTree(data, treeIndex)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,125 @@
+package randomforest
+
+import (
+	"fmt"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+	"concurrente/internal/metrics"
+)
+
+func TestParallelExtraTreesMatchSequential(t *testing.T) {
+	data := harness.Multiclass(900, 5, 3, 0.1, 61)
+	train, test := data[:600], data[600:]
+
+	sequential := NewSequentialExtraTrees(8)
+	sequential.SetSeed(3)
+	sequential.Train(train)
+	for _, workers := range []int{1, 3} {
+		parallel := NewParallelExtraTrees(8)
+		parallel.SetSeed(3)
+		parallel.SetNumWorkers(workers)
+		parallel.Train(train)
+		for i, row := range test {
+			sample := row[:len(row)-1]
+			want, got := sequential.PredictProba(sample), parallel.PredictProba(sample)
+			if harness.MaxAbsDiff(want, got) != 0 || sequential.Predict(sample) != parallel.Predict(sample) {
+				t.Fatalf("%d workers, row %d: probabilities %v, sequential %v", workers, i, got, want)
+			}
+		}
+	}
+}
+
+func TestExtraTreesLearnSameTaskAsForest(t *testing.T) {
+	data := harness.Classification(2000, 6, 0.05, 62)
+	train, test := data[:1400], data[1400:]
+
+	forest := NewSequentialRandomForest(20, 0.8)
+	forest.SetSeed(5)
+	forest.SetTreeOptions(DefaultExtraTreesOptions())
+	forest.Train(train)
+	forestAccuracy := harness.Accuracy(harness.Predictions(forest, test), test, 0.5)
+
+	extra := NewParallelExtraTrees(20)
+	extra.SetSeed(5)
+	extra.Train(train)
+	extraAccuracy := harness.Accuracy(harness.Predictions(extra, test), test, 0.5)
+
+	// The drawn splits are worse one by one, but averaged over the trees the
+	// ensemble is expected to be about as accurate.
+	if extraAccuracy < forestAccuracy-0.05 {
+		t.Fatalf("extra trees accuracy %.3f, forest %.3f", extraAccuracy, forestAccuracy)
+	}
+	for i, row := range test[:50] {
+		if got, want := extra.PredictClass(row[:len(row)-1]), decisiontree.Argmax(extra.PredictProba(row[:len(row)-1])); got != want {
+			t.Fatalf("row %d: class %d, highest probability %d", i, got, want)
+		}
+	}
+}
+
+func TestExtraTreesRegression(t *testing.T) {
+	data := harness.Regression(1500, 4, 0.1, 63)
+	train, test := data[:1000], data[1000:]
+
+	opts := DefaultExtraTreesOptions()
+	opts.Criterion = decisiontree.MSE
+	opts.MaxFeatures = decisiontree.MaxFeatures{}
+	extra := NewSequentialExtraTrees(10)
+	extra.SetSeed(6)
+	extra.SetTreeOptions(opts)
+	extra.Train(train)
+
+	if extra.PredictProba(test[0][:4]) != nil {
+		t.Error("a regression ensemble returns class probabilities")
+	}
+	if r2 := metrics.R2(harness.Predictions(extra, test), targets(test)); r2 < 0.8 {
+		t.Fatalf("R² %.3f", r2)
+	}
+	flat := extra.Flatten()
+	for i, row := range test {
+		if want := extra.Predict(row[:4]); flat.Predict(row[:4]) != want {
+			t.Fatalf("row %d: flat %v, ensemble %v", i, flat.Predict(row[:4]), want)
+		}
+	}
+}
+
+func targets(data [][]float64) []float64 {
+	values := make([]float64, len(data))
+	for i, row := range data {
+		values[i] = row[len(row)-1]
+	}
+	return values
+}
+
+// BenchmarkForestTrain compares growing bagged forests, whose trees search
+// every threshold, with growing extremely randomized trees.
+func BenchmarkForestTrain(b *testing.B) {
+	ensembles := []struct {
+		name  string
+		train func(data [][]float64)
+	}{
+		{"forest/sequential", func(data [][]float64) {
+			rf := NewSequentialRandomForest(10, 1)
+			rf.SetTreeOptions(DefaultExtraTreesOptions())
+			rf.Train(data)
+		}},
+		{"forest/concurrent", func(data [][]float64) {
+			rf := NewConcurrentRandomForest(10, 1)
+			rf.SetTreeOptions(DefaultExtraTreesOptions())
+			rf.Train(data)
+		}},
+		{"extratrees/sequential", func(data [][]float64) { NewSequentialExtraTrees(10).Train(data) }},
+		{"extratrees/parallel", func(data [][]float64) { NewParallelExtraTrees(10).Train(data) }},
+	}
+	for _, rows := range []int{1000, 10000} {
+		data := harness.Classification(rows, 8, 0.1, 1)
+		for _, ensemble := range ensembles {
+			b.Run(fmt.Sprintf("%s/rows=%d", ensemble.name, rows), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					ensemble.train(data)
+				}
+			})
+		}
+	}
+}
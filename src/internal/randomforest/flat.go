@@ -0,0 +1,67 @@
+package randomforest
+
+import (
+	"fmt"
+	"strconv"
+
+	"concurrente/internal/decisiontree"
+)
+
+// Flatten returns the trained forest laid out for inference.
+func (rf *SequentialRandomForest) Flatten() *decisiontree.FlatForest {
+	trees := make([]*decisiontree.FlatTree, len(rf.trees))
+	for i, tree := range rf.trees {
+		trees[i] = tree.Flatten()
+	}
+	return decisiontree.NewFlatForest(trees...)
+}
+
+// Flatten returns the trained forest laid out for inference.
+func (rf *ConcurrentRandomForest) Flatten() *decisiontree.FlatForest {
+	trees := make([]*decisiontree.FlatTree, len(rf.trees))
+	for i, tree := range rf.trees {
+		trees[i] = tree.Flatten()
+	}
+	return decisiontree.NewFlatForest(trees...)
+}
+
+// Flatten returns the trained tree laid out for inference. It predicts on
+// samples parsed once with ParseRecords instead of parsing a string at every
+// split, and sends values below the threshold left like the tree does.
+func (tree *ParallelDecisionTree) Flatten() *decisiontree.FlatTree {
+	return decisiontree.Flatten(tree.root, true)
+}
+
+// Flatten returns the trained forest laid out for inference, predicting on
+// samples parsed with ParseRecords.
+func (rf *ParallelRandomForest) Flatten() *decisiontree.FlatForest {
+	trees := make([]*decisiontree.FlatTree, len(rf.trees))
+	for i, tree := range rf.trees {
+		trees[i] = tree.Flatten()
+	}
+	return decisiontree.NewFlatForest(trees...)
+}
+
+// ParseRecords parses the first numFeatures columns of every record, the
+// features the parallel trees split on. Unlike ParallelDecisionTree.Predict,
+// which stops at a split whose value does not parse, it reports such values
+// as an error.
+func ParseRecords(records [][]string, numFeatures int) ([][]float64, error) {
+	samples := make([][]float64, len(records))
+	values := make([]float64, len(records)*numFeatures)
+	for i, record := range records {
+		if len(record) < numFeatures {
+			return nil, fmt.Errorf("record %d has %d columns, want %d", i, len(record), numFeatures)
+		}
+		sample := values[i*numFeatures : (i+1)*numFeatures : (i+1)*numFeatures]
+		for j := range sample {
+			value, err := strconv.ParseFloat(record[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("record %d, column %d: %w", i, j, err)
+			}
+			sample[j] = value
+		}
+		samples[i] = sample
+	}
+	return samples, nil
+}
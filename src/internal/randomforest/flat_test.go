@@ -0,0 +1,112 @@
+package randomforest
+
+import (
+	"fmt"
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+)
+
+func TestFlatForestsMatchForests(t *testing.T) {
+	data := harness.Classification(1200, 5, 0.1, 8)
+	samples := make([][]float64, len(data))
+	for i, row := range data {
+		samples[i] = row[:len(row)-1]
+	}
+
+	sequential := NewSequentialRandomForest(8, 0.8)
+	sequential.SetSeed(2)
+	sequential.Train(data)
+	regressor := NewConcurrentRandomForestRegressor(8, 0.8)
+	regressor.SetSeed(2)
+	regressor.Train(harness.Regression(1200, 5, 0.1, 8))
+
+	forests := []struct {
+		name    string
+		predict func([]float64) float64
+		flat    *decisiontree.FlatForest
+	}{
+		{"sequential", sequential.Predict, sequential.Flatten()},
+		{"concurrent regressor", regressor.Predict, regressor.Flatten()},
+	}
+	for _, forest := range forests {
+		batch := forest.flat.PredictBatch(samples, nil)
+		for i, sample := range samples {
+			if want := forest.predict(sample); batch[i] != want || forest.flat.Predict(sample) != want {
+				t.Fatalf("%s, sample %d: flat %v, forest %v", forest.name, i, batch[i], want)
+			}
+		}
+	}
+}
+
+func TestFlatParallelForestMatchesRecords(t *testing.T) {
+	records := harness.StringRecords(harness.Classification(1000, 4, 0.1, 9))
+	rf := NewParallelRandomForest(6, 0.8)
+	rf.SetSeed(3)
+	rf.Train(records)
+
+	samples, err := ParseRecords(records, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat := rf.Flatten()
+	tree := rf.trees[0].Flatten()
+	batch := flat.PredictBatchConcurrent(samples, nil, 3)
+	for i, record := range records {
+		sample := record[:len(record)-1]
+		if want := rf.Predict(sample); batch[i] != want {
+			t.Fatalf("record %d: flat %v, forest %v", i, batch[i], want)
+		}
+		if want := rf.trees[0].Predict(sample); tree.Predict(samples[i]) != want {
+			t.Fatalf("record %d: flat tree %v, tree %v", i, tree.Predict(samples[i]), want)
+		}
+	}
+}
+
+func TestParseRecordsReportsBadValues(t *testing.T) {
+	for name, records := range map[string][][]string{
+		"not a number":  {{"1", "2", "a"}, {"1", "x", "b"}},
+		"short records": {{"1", "a"}},
+	} {
+		if _, err := ParseRecords(records, 2); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// BenchmarkParallelForestPredict compares the forest parsing the records at
+// every split with parsing them once and predicting the batch on the
+// flattened trees.
+func BenchmarkParallelForestPredict(b *testing.B) {
+	records := harness.StringRecords(harness.Classification(5000, 8, 0.2, 1))
+	for _, numTrees := range []int{10, 50} {
+		rf := NewParallelRandomForest(numTrees, 0.8)
+		rf.SetSeed(1)
+		rf.Train(records)
+		flat := rf.Flatten()
+		out := make([]float64, len(records))
+
+		report := func(b *testing.B) {
+			b.ReportMetric(float64(b.N*len(records))/b.Elapsed().Seconds(), "rows/s")
+		}
+		b.Run(fmt.Sprintf("records/trees=%d", numTrees), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j, record := range records {
+					out[j] = rf.Predict(record[:len(record)-1])
+				}
+			}
+			report(b)
+		})
+		b.Run(fmt.Sprintf("flat/trees=%d", numTrees), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				samples, err := ParseRecords(records, 8)
+				if err != nil {
+					b.Fatal(err)
+				}
+				flat.PredictBatch(samples, out)
+			}
+			report(b)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package randomforest
+
+import (
+	"runtime"
+	"sync"
+
+	"concurrente/internal/decisiontree"
+)
+
+// Grow adds n trees trained on bootstrap samples of data to the forest,
+// keeping the trees it has. Tree i still draws from seed+i, so growing a
+// forest of 10 trees by 40 on the data it was trained on gives the forest
+// Train grows with 50. A forest that has not been trained grows from no
+// trees, and n of 0 or less leaves the forest as it is.
+//
+// data may hold new rows. InBag then indexes the data each tree was trained
+// on, and OOB only makes sense if every tree was trained on the same rows.
+func (rf *SequentialRandomForest) Grow(data [][]float64, n int) {
+	if n <= 0 {
+		return
+	}
+	first := len(rf.trees)
+	rf.numTrees = first + n
+	rf.trees = append(rf.trees, make([]*decisiontree.SequentialDecisionTree, n)...)
+	rf.inBag = append(rf.inBag, make([][]int, n)...)
+	rf.numClasses = grownClasses(rf.treeOpts, rf.numClasses, data)
+	rf.growTrees(data, nil, first)
+}
+
+// Grow is SequentialRandomForest.Grow with the new trees trained in parallel.
+func (rf *ConcurrentRandomForest) Grow(data [][]float64, n int) {
+	if n <= 0 {
+		return
+	}
+	first := len(rf.trees)
+	rf.numTrees = first + n
+	rf.trees = append(rf.trees, make([]*decisiontree.ConcurrentDecisionTree, n)...)
+	rf.inBag = append(rf.inBag, make([][]int, n)...)
+	rf.numClasses = grownClasses(rf.treeOpts, rf.numClasses, data)
+	rf.growTrees(data, nil, first)
+}
+
+// Grow is SequentialRandomForest.Grow for records. Labels the forest has not
+// seen become new classes after the ones it has, so the existing trees keep
+// their class numbers.
+func (rf *ParallelRandomForest) Grow(data [][]string, n int) {
+	if n <= 0 {
+		return
+	}
+	first := len(rf.trees)
+	rf.numTrees = first + n
+	rf.trees = append(rf.trees, make([]*ParallelDecisionTree, n)...)
+	rf.inBag = append(rf.inBag, make([][]int, n)...)
+	rf.classes = classesOf(data, rf.classes)
+	rf.growTrees(data, nil, first)
+}
+
+// Grow adds n trees grown on data to the ensemble, keeping the trees it has.
+// Tree i still draws from seed+i, so growing 10 trees by 40 on the same data
+// gives the ensemble Train grows with 50. n of 0 or less leaves the ensemble
+// as it is.
+func (et *SequentialExtraTrees) Grow(data [][]float64, n int) {
+	if n <= 0 {
+		return
+	}
+	first := et.addTrees(data, n)
+	et.growTrees(data, first)
+}
+
+// Grow is SequentialExtraTrees.Grow with the new trees shared out among the
+// workers.
+func (et *ParallelExtraTrees) Grow(data [][]float64, n int) {
+	if n <= 0 {
+		return
+	}
+	first := et.addTrees(data, n)
+	et.growTrees(data, first)
+}
+
+// addTrees makes room for n trees grown on data and returns the index of the
+// first.
+func (et *SequentialExtraTrees) addTrees(data [][]float64, n int) int {
+	first := len(et.trees)
+	et.numTrees = first + n
+	et.trees = append(et.trees, make([]*decisiontree.SequentialDecisionTree, n)...)
+	et.numClasses = grownClasses(et.treeOpts, et.numClasses, data)
+	return first
+}
+
+// grownClasses is the number of classes of a forest with numClasses classes
+// after it grows trees with opts on data, or 0 for regression trees.
+func grownClasses(opts decisiontree.Options, numClasses int, data [][]float64) int {
+	if !decisiontree.IsClassifier(opts.Criterion) {
+		return 0
+	}
+	return max(numClasses, decisiontree.NumClasses(data))
+}
+
+// StagedPredict returns at index k-1 the prediction of sample by the first k
+// trees, which is what Predict returns once Train or Grow have left the
+// forest with k trees.
+func (rf *SequentialRandomForest) StagedPredict(sample []float64) []float64 {
+	return staged(len(rf.trees), func(tree int) float64 { return rf.trees[tree].Predict(sample) })
+}
+
+// StagedPredict returns at index k-1 the prediction of sample by the first k
+// trees, which is what Predict returns once Train or Grow have left the
+// forest with k trees.
+func (rf *ConcurrentRandomForest) StagedPredict(sample []float64) []float64 {
+	return staged(len(rf.trees), func(tree int) float64 { return rf.trees[tree].Predict(sample) })
+}
+
+// StagedPredict returns at index k-1 the prediction of sample by the first k
+// trees, which is what Predict returns once Train or Grow have left the
+// forest with k trees.
+func (rf *ParallelRandomForest) StagedPredict(sample []string) []float64 {
+	return staged(len(rf.trees), func(tree int) float64 { return rf.trees[tree].Predict(sample) })
+}
+
+// StagedPredict returns at index k-1 the prediction of sample by the first k
+// trees, which is what Predict returns once Train or Grow have left the
+// ensemble with k trees.
+func (et *SequentialExtraTrees) StagedPredict(sample []float64) []float64 {
+	return staged(len(et.trees), func(tree int) float64 { return et.trees[tree].Predict(sample) })
+}
+
+// staged returns the running means of the predictions of n trees, added up
+// in order like the forests' Predict.
+func staged(n int, predict func(tree int) float64) []float64 {
+	means := make([]float64, n)
+	sum := 0.0
+	for tree := range means {
+		sum += predict(tree)
+		means[tree] = sum / float64(tree+1)
+	}
+	return means
+}
+
+// Staged is a forest that predicts with each prefix of its trees.
+type Staged[T any] interface {
+	StagedPredict(sample []T) []float64
+}
+
+// GrowthCurve returns how a forest's score on data changes as trees are
+// added: curve[k-1] is the score of the predictions of the first k trees, one
+// per row of data, whose last column is the label. The rows are predicted
+// in one chunk per CPU. Plotting curve against k shows how many trees the
+// forest needs without training one forest per size.
+func GrowthCurve[T any](forest Staged[T], data [][]T, score func(predictions []float64) float64) []float64 {
+	if len(data) == 0 {
+		return nil
+	}
+	stagedPredictions := make([][]float64, len(data))
+	workers := max(1, min(runtime.GOMAXPROCS(0), len(data)))
+	chunk := (len(data) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(data); start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for row := start; row < end; row++ {
+				stagedPredictions[row] = forest.StagedPredict(data[row][:len(data[row])-1])
+			}
+		}(start, min(start+chunk, len(data)))
+	}
+	wg.Wait()
+
+	curve := make([]float64, len(stagedPredictions[0]))
+	predictions := make([]float64, len(data))
+	for k := range curve {
+		for row, staged := range stagedPredictions {
+			predictions[row] = staged[k]
+		}
+		curve[k] = score(predictions)
+	}
+	return curve
+}
@@ -0,0 +1,140 @@
+package randomforest
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+// grower is a forest of float64 rows that can be grown.
+type grower interface {
+	harness.Predictor
+	Train(data [][]float64)
+	Grow(data [][]float64, n int)
+	SetSeed(seed int64)
+	StagedPredict(sample []float64) []float64
+}
+
+func TestGrowMatchesTraining(t *testing.T) {
+	data := harness.Classification(800, 5, 0.1, 71)
+	train, test := data[:500], data[500:]
+	forests := map[string]func(numTrees int) grower{
+		"sequential":            func(n int) grower { return NewSequentialRandomForest(n, 0.8) },
+		"concurrent":            func(n int) grower { return NewConcurrentRandomForest(n, 0.8) },
+		"sequential extratrees": func(n int) grower { return NewSequentialExtraTrees(n) },
+		"parallel extratrees":   func(n int) grower { return NewParallelExtraTrees(n) },
+	}
+	for name, newForest := range forests {
+		whole := newForest(10)
+		whole.SetSeed(4)
+		whole.Train(train)
+
+		grown := newForest(3)
+		grown.SetSeed(4)
+		grown.Train(train)
+		grown.Grow(train, 2)
+		grown.Grow(train, 5)
+
+		if diff := harness.MaxAbsDiff(harness.Predictions(whole, test), harness.Predictions(grown, test)); diff != 0 {
+			t.Errorf("%s: grown forest predictions differ by up to %g", name, diff)
+		}
+		for i, row := range test[:20] {
+			staged := whole.StagedPredict(row[:len(row)-1])
+			if len(staged) != 10 || staged[9] != whole.Predict(row[:len(row)-1]) {
+				t.Fatalf("%s, row %d: staged predictions %v", name, i, staged)
+			}
+		}
+
+		untrained := newForest(7)
+		untrained.SetSeed(4)
+		untrained.Grow(train, 10)
+		if diff := harness.MaxAbsDiff(harness.Predictions(whole, test), harness.Predictions(untrained, test)); diff != 0 {
+			t.Errorf("%s: forest grown untrained differs by up to %g", name, diff)
+		}
+	}
+}
+
+func TestGrowOnNewData(t *testing.T) {
+	data := harness.Multiclass(900, 4, 3, 0.1, 72)
+	var binary, all [][]float64
+	for _, row := range data {
+		if row[len(row)-1] < 2 {
+			binary = append(binary, row)
+		}
+		all = append(all, row)
+	}
+
+	rf := NewSequentialRandomForest(5, 0.8)
+	rf.SetSeed(1)
+	rf.Train(binary)
+	if n := len(rf.PredictProba(all[0][:4])); n != 2 {
+		t.Fatalf("%d classes before growing", n)
+	}
+	rf.Grow(all, 0)
+	rf.Grow(all, -1)
+	if n := len(rf.PredictProba(all[0][:4])); n != 2 || len(rf.InBag()) != 5 {
+		t.Fatalf("growing by no trees left %d trees of %d classes", len(rf.InBag()), n)
+	}
+	rf.Grow(all, 5)
+	if n := len(rf.PredictProba(all[0][:4])); n != 3 {
+		t.Fatalf("%d classes after growing on three", n)
+	}
+	if len(rf.InBag()) != 10 || len(rf.InBag()[9]) != int(0.8*float64(len(all))) {
+		t.Fatalf("in-bag rows of %d trees, the last drawing %d", len(rf.InBag()), len(rf.InBag()[9]))
+	}
+
+	records := harness.LabeledRecords(data, []string{"bajo", "medio", "alto"})
+	parallel := NewParallelRandomForest(4, 0.8)
+	parallel.SetSeed(2)
+	parallel.SetClasses([]string{"bajo", "medio"})
+	parallel.Train(records[:300])
+	parallel.Grow(records, 4)
+	if classes := parallel.Classes(); len(classes) != 3 || classes[0] != "bajo" || classes[1] != "medio" {
+		t.Fatalf("classes %v after growing", classes)
+	}
+	if n := len(parallel.PredictProba(records[0][:4])); n != 3 {
+		t.Fatalf("%d class probabilities", n)
+	}
+}
+
+func TestGrowthCurve(t *testing.T) {
+	data := harness.Classification(900, 5, 0.1, 73)
+	train, test := data[:600], data[600:]
+	accuracy := func(predictions []float64) float64 {
+		return harness.Accuracy(predictions, test, 0.5)
+	}
+
+	rf := NewConcurrentRandomForest(12, 0.8)
+	rf.SetSeed(6)
+	rf.Train(train)
+	curve := GrowthCurve[float64](rf, test, accuracy)
+	if len(curve) != 12 {
+		t.Fatalf("curve of %d points", len(curve))
+	}
+	for _, k := range []int{1, 5, 12} {
+		smaller := NewConcurrentRandomForest(k, 0.8)
+		smaller.SetSeed(6)
+		smaller.Train(train)
+		if want := accuracy(harness.Predictions(smaller, test)); curve[k-1] != want {
+			t.Errorf("%d trees: curve %.4f, forest %.4f", k, curve[k-1], want)
+		}
+	}
+	if curve[11] < curve[0] {
+		t.Errorf("12 trees score %.3f, 1 tree %.3f", curve[11], curve[0])
+	}
+
+	records := harness.StringRecords(data)
+	parallel := NewParallelRandomForest(6, 0.8)
+	parallel.SetSeed(6)
+	parallel.Train(records[:600])
+	recordCurve := GrowthCurve[string](parallel, records[600:], accuracy)
+	correct := 0
+	for i, record := range records[600:] {
+		if (parallel.Predict(record[:len(record)-1]) >= 0.5) == (test[i][5] == 1) {
+			correct++
+		}
+	}
+	if want := float64(correct) / float64(len(test)); recordCurve[5] != want {
+		t.Errorf("parallel forest curve ends at %.4f, forest accuracy %.4f", recordCurve[5], want)
+	}
+}
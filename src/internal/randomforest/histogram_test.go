@@ -0,0 +1,65 @@
+package randomforest
+
+import (
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+)
+
+func TestForestsTrainWithHistograms(t *testing.T) {
+	data := harness.Classification(2000, 4, 0.05, 41)
+	train, test := data[:1500], data[1500:]
+
+	opts := decisiontree.DefaultOptions()
+	opts.MaxBins = 32
+
+	sequential := NewSequentialRandomForest(6, 0.8)
+	sequential.SetSeed(3)
+	sequential.SetTreeOptions(opts)
+	sequential.Train(train)
+
+	concurrent := NewConcurrentRandomForest(6, 0.8)
+	concurrent.SetSeed(3)
+	concurrent.SetTreeOptions(opts)
+	concurrent.Train(train)
+
+	// The concurrent tree builds and searches histograms feature by feature
+	// in parallel, which must not change what it learns.
+	want := harness.Predictions(sequential, test)
+	got := harness.Predictions(concurrent, test)
+	if diff := harness.MaxAbsDiff(want, got); diff > 1e-12 {
+		t.Fatalf("predictions differ by up to %g", diff)
+	}
+	if accuracy := harness.Accuracy(want, test, 0.5); accuracy < 0.85 {
+		t.Fatalf("histogram forest accuracy %.3f", accuracy)
+	}
+
+	medianAccuracy := parallelForestAccuracy(DefaultParallelTreeOptions(), train, test)
+	parallelOpts := DefaultParallelTreeOptions()
+	parallelOpts.MaxBins = 32
+	histogramAccuracy := parallelForestAccuracy(parallelOpts, train, test)
+	if histogramAccuracy < medianAccuracy-0.05 {
+		t.Fatalf(
+			"parallel forest accuracy %.3f with histograms, %.3f with medians",
+			histogramAccuracy,
+			medianAccuracy,
+		)
+	}
+}
+
+func parallelForestAccuracy(opts decisiontree.Options, train, test [][]float64) float64 {
+	rf := NewParallelRandomForest(6, 0.8)
+	rf.SetSeed(3)
+	rf.SetTreeOptions(opts)
+	rf.Train(harness.StringRecords(train))
+
+	correct := 0
+	for i, record := range harness.StringRecords(test) {
+		prediction := rf.Predict(record[:len(record)-1])
+		if (prediction >= 0.5) == (test[i][len(test[i])-1] == 1) {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(test))
+}
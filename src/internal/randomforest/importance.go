@@ -0,0 +1,39 @@
+package randomforest
+
+import "concurrente/internal/decisiontree"
+
+// FeatureImportances returns the mean of the trees' normalized impurity
+// decreases, normalized to sum to 1.
+func (rf *SequentialRandomForest) FeatureImportances() []float64 {
+	importances := make([][]float64, len(rf.trees))
+	for i, tree := range rf.trees {
+		importances[i] = tree.FeatureImportances()
+	}
+	return decisiontree.MeanImportances(importances)
+}
+
+// FeatureImportances returns the mean of the trees' normalized impurity
+// decreases, normalized to sum to 1.
+func (rf *ConcurrentRandomForest) FeatureImportances() []float64 {
+	importances := make([][]float64, len(rf.trees))
+	for i, tree := range rf.trees {
+		importances[i] = tree.FeatureImportances()
+	}
+	return decisiontree.MeanImportances(importances)
+}
+
+// FeatureImportances returns the normalized impurity decrease of every
+// column before the label. Column 0 is never split on and stays at 0.
+func (tree *ParallelDecisionTree) FeatureImportances() []float64 {
+	return decisiontree.FeatureImportances(tree.root, tree.numFeatures)
+}
+
+// FeatureImportances returns the mean of the trees' normalized impurity
+// decreases, normalized to sum to 1, indexed like the record columns.
+func (rf *ParallelRandomForest) FeatureImportances() []float64 {
+	importances := make([][]float64, len(rf.trees))
+	for i, tree := range rf.trees {
+		importances[i] = tree.FeatureImportances()
+	}
+	return decisiontree.MeanImportances(importances)
+}
@@ -0,0 +1,56 @@
+package randomforest
+
+import (
+	"math/rand"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestForestFeatureImportances(t *testing.T) {
+	// The label depends only on feature 1; features 0 and 2 are noise.
+	rng := rand.New(rand.NewSource(4))
+	data := make([][]float64, 500)
+	for i := range data {
+		x := rng.Float64()*2 - 1
+		label := 0.0
+		if x > 0 {
+			label = 1
+		}
+		data[i] = []float64{rng.Float64(), x, rng.Float64(), label}
+	}
+
+	sequential := NewSequentialRandomForest(5, 0.8)
+	sequential.SetSeed(2)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(5, 0.8)
+	concurrent.SetSeed(2)
+	concurrent.Train(data)
+	parallel := NewParallelRandomForest(5, 0.8)
+	parallel.SetSeed(2)
+	parallel.Train(harness.StringRecords(data))
+
+	want := sequential.FeatureImportances()
+	if diff := harness.MaxAbsDiff(want, concurrent.FeatureImportances()); diff > 1e-12 {
+		t.Errorf("sequential and concurrent forests differ by %g", diff)
+	}
+	for name, importances := range map[string][]float64{
+		"sequential": want,
+		"parallel":   parallel.FeatureImportances(),
+	} {
+		sum := 0.0
+		for _, value := range importances {
+			sum += value
+		}
+		if len(importances) != 3 || !harness.WithinTolerance(sum, 1, 1e-12) {
+			t.Errorf("%s: importances %v", name, importances)
+			continue
+		}
+		if importances[1] < 0.8 {
+			t.Errorf("%s: informative feature has importance %g: %v", name, importances[1], importances)
+		}
+	}
+	if parallel.FeatureImportances()[0] != 0 {
+		t.Error("the parallel forest never splits on column 0")
+	}
+}
@@ -0,0 +1,219 @@
+package randomforest
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/metrics"
+)
+
+// OOB is the out-of-bag estimate of a forest: every training row predicted
+// only by the trees whose bootstrap sample did not draw it, which makes the
+// training rows a validation set no tree has learned from.
+type OOB struct {
+	// Predictions[i] is the mean prediction for training row i of the trees
+	// that did not see it, or NaN when every tree did.
+	Predictions []float64
+	// Probabilities[i] is the mean class distribution of those trees, nil
+	// for regression forests and for rows every tree saw.
+	Probabilities [][]float64
+	// Trees[i] is how many trees row i is out of bag for.
+	Trees []int
+	// Score is the accuracy of the class with the highest mean probability,
+	// as PredictClass picks it, or the R² of a regression forest, over the
+	// rows with at least one such tree.
+	Score float64
+	// Rows is the number of rows Score covers.
+	Rows int
+}
+
+// InBag returns the training rows each tree's bootstrap sample drew, one
+// index per drawn row, so rows drawn more than once repeat.
+func (rf *SequentialRandomForest) InBag() [][]int {
+	return rf.inBag
+}
+
+// InBag returns the training rows each tree's bootstrap sample drew, one
+// index per drawn row, so rows drawn more than once repeat.
+func (rf *ConcurrentRandomForest) InBag() [][]int {
+	return rf.inBag
+}
+
+// InBag returns the training rows each tree's bootstrap sample drew, one
+// index per drawn row, so rows drawn more than once repeat.
+func (rf *ParallelRandomForest) InBag() [][]int {
+	return rf.inBag
+}
+
+// OOB returns the out-of-bag estimate of the forest on data, which must be
+// the rows it was trained on, in the same order.
+func (rf *SequentialRandomForest) OOB(data [][]float64) (OOB, error) {
+	return floatOOB(data, rf.inBag, rf.numClasses, func(tree int, sample []float64) (float64, []float64) {
+		return rf.trees[tree].Predict(sample), rf.trees[tree].PredictProba(sample)
+	})
+}
+
+// OOB returns the out-of-bag estimate of the forest on data, which must be
+// the rows it was trained on, in the same order.
+func (rf *ConcurrentRandomForest) OOB(data [][]float64) (OOB, error) {
+	return floatOOB(data, rf.inBag, rf.numClasses, func(tree int, sample []float64) (float64, []float64) {
+		return rf.trees[tree].Predict(sample), rf.trees[tree].PredictProba(sample)
+	})
+}
+
+// OOB returns the out-of-bag estimate of the forest on data, which must be
+// the records it was trained on, in the same order.
+func (rf *ParallelRandomForest) OOB(data [][]string) (OOB, error) {
+	if err := checkInBag(len(data), rf.inBag); err != nil {
+		return OOB{}, err
+	}
+	oob := outOfBag(len(data), rf.inBag, len(rf.classes), func(tree, row int) (float64, []float64) {
+		sample := data[row][:len(data[row])-1]
+		return rf.trees[tree].Predict(sample), rf.trees[tree].PredictProba(sample)
+	})
+	index := make(map[string]int, len(rf.classes))
+	for i, class := range rf.classes {
+		index[class] = i
+	}
+	labels := make([]float64, len(data))
+	for i, record := range data {
+		labels[i] = float64(index[record[len(record)-1]])
+	}
+	oob.score(labels, len(rf.classes))
+	return oob, nil
+}
+
+// floatOOB is OOB for forests of float64 rows whose last column is the label.
+func floatOOB(
+	data [][]float64,
+	inBag [][]int,
+	numClasses int,
+	predict func(tree int, sample []float64) (float64, []float64),
+) (OOB, error) {
+	if err := checkInBag(len(data), inBag); err != nil {
+		return OOB{}, err
+	}
+	oob := outOfBag(len(data), inBag, numClasses, func(tree, row int) (float64, []float64) {
+		return predict(tree, data[row][:len(data[row])-1])
+	})
+	labels := make([]float64, len(data))
+	for i, row := range data {
+		labels[i] = row[len(row)-1]
+	}
+	oob.score(labels, numClasses)
+	return oob, nil
+}
+
+// checkInBag reports a forest that has not been trained, or whose bootstrap
+// samples drew rows data does not have.
+func checkInBag(rows int, inBag [][]int) error {
+	if len(inBag) == 0 || inBag[0] == nil {
+		return fmt.Errorf("randomforest: the forest has not been trained")
+	}
+	for _, indices := range inBag {
+		for _, row := range indices {
+			if row >= rows {
+				return fmt.Errorf("randomforest: the forest was trained on more than the %d rows given", rows)
+			}
+		}
+	}
+	return nil
+}
+
+// outOfBag averages, for each of n rows, the predictions and class
+// distributions of the trees whose bootstrap sample missed it. Rows are
+// split into one chunk per CPU; each row adds up its trees in order, so the
+// result does not depend on the number of CPUs.
+func outOfBag(
+	n int,
+	inBag [][]int,
+	numClasses int,
+	predict func(tree, row int) (float64, []float64),
+) OOB {
+	drawn := make([][]bool, len(inBag))
+	var wg sync.WaitGroup
+	wg.Add(len(inBag))
+	for tree, indices := range inBag {
+		go func(tree int, indices []int) {
+			defer wg.Done()
+			drawn[tree] = make([]bool, n)
+			for _, row := range indices {
+				drawn[tree][row] = true
+			}
+		}(tree, indices)
+	}
+	wg.Wait()
+
+	oob := OOB{
+		Predictions: make([]float64, n),
+		Trees:       make([]int, n),
+	}
+	if numClasses > 0 {
+		oob.Probabilities = make([][]float64, n)
+	}
+	workers := max(1, min(runtime.GOMAXPROCS(0), n))
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for row := start; row < end; row++ {
+				sum := 0.0
+				var distributions [][]float64
+				for tree := range inBag {
+					if drawn[tree][row] {
+						continue
+					}
+					prediction, distribution := predict(tree, row)
+					sum += prediction
+					oob.Trees[row]++
+					if numClasses > 0 {
+						distributions = append(distributions, distribution)
+					}
+				}
+				if oob.Trees[row] == 0 {
+					oob.Predictions[row] = math.NaN()
+					continue
+				}
+				oob.Predictions[row] = sum / float64(oob.Trees[row])
+				if numClasses > 0 {
+					oob.Probabilities[row] = averageDistributions(distributions, numClasses)
+				}
+			}
+		}(start, min(start+chunk, n))
+	}
+	wg.Wait()
+	return oob
+}
+
+// score fills in Score and Rows from the labels of the rows, class indices
+// when numClasses is positive and targets otherwise.
+func (oob *OOB) score(labels []float64, numClasses int) {
+	var predictions, targets []float64
+	correct := 0
+	for row, label := range labels {
+		if oob.Trees[row] == 0 {
+			continue
+		}
+		oob.Rows++
+		if numClasses > 0 {
+			if decisiontree.Argmax(oob.Probabilities[row]) == decisiontree.ClassOf(label) {
+				correct++
+			}
+			continue
+		}
+		predictions = append(predictions, oob.Predictions[row])
+		targets = append(targets, label)
+	}
+	switch {
+	case oob.Rows == 0:
+		oob.Score = math.NaN()
+	case numClasses > 0:
+		oob.Score = float64(correct) / float64(oob.Rows)
+	default:
+		oob.Score = metrics.R2(predictions, targets)
+	}
+}
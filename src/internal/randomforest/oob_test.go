@@ -0,0 +1,166 @@
+package randomforest
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"concurrente/internal/harness"
+	"concurrente/internal/metrics"
+)
+
+func TestOOBUsesOnlyTreesThatMissedTheRow(t *testing.T) {
+	data := harness.Classification(400, 4, 0.1, 51)
+	rf := NewSequentialRandomForest(10, 0.8)
+	rf.SetSeed(3)
+	rf.Train(data)
+
+	oob, err := rf.OOB(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, indices := range rf.InBag() {
+		if len(indices) != 320 {
+			t.Fatalf("tree %d drew %d rows", i, len(indices))
+		}
+	}
+	for row := range data {
+		sum, trees := 0.0, 0
+		for i, tree := range rf.trees {
+			if slices.Contains(rf.InBag()[i], row) {
+				continue
+			}
+			sum += tree.Predict(data[row][:4])
+			trees++
+		}
+		if oob.Trees[row] != trees {
+			t.Fatalf("row %d is out of bag for %d trees, OOB counts %d", row, trees, oob.Trees[row])
+		}
+		if trees == 0 {
+			if !math.IsNaN(oob.Predictions[row]) || oob.Probabilities[row] != nil {
+				t.Fatalf("row %d has no trees but predicts %g", row, oob.Predictions[row])
+			}
+			continue
+		}
+		if want := sum / float64(trees); oob.Predictions[row] != want {
+			t.Fatalf("row %d: OOB predicts %g, its trees %g", row, oob.Predictions[row], want)
+		}
+	}
+}
+
+func TestOOBEstimatesHeldOutScore(t *testing.T) {
+	data := harness.Classification(3000, 5, 0.1, 52)
+	train, test := data[:2000], data[2000:]
+
+	sequential := NewSequentialRandomForest(20, 0.8)
+	sequential.SetSeed(4)
+	sequential.Train(train)
+	concurrent := NewConcurrentRandomForest(20, 0.8)
+	concurrent.SetSeed(4)
+	concurrent.Train(train)
+
+	want, err := sequential.OOB(train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := concurrent.OOB(train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Score != got.Score || harness.MaxAbsDiff(want.Predictions, got.Predictions) > 1e-12 {
+		t.Fatalf("sequential OOB score %g, concurrent %g", want.Score, got.Score)
+	}
+	if want.Rows < len(train)*99/100 {
+		t.Errorf("OOB covers %d of %d rows", want.Rows, len(train))
+	}
+
+	correct := 0
+	for _, row := range test {
+		if sequential.PredictClass(row[:len(row)-1]) == int(row[len(row)-1]) {
+			correct++
+		}
+	}
+	heldOut := float64(correct) / float64(len(test))
+	if math.Abs(want.Score-heldOut) > 0.04 {
+		t.Errorf("OOB accuracy %.3f, held-out accuracy %.3f", want.Score, heldOut)
+	}
+}
+
+func TestRegressorOOB(t *testing.T) {
+	data := harness.Regression(2000, 4, 0.1, 53)
+	train, test := data[:1500], data[1500:]
+	rf := NewConcurrentRandomForestRegressor(20, 0.8)
+	rf.SetSeed(5)
+	rf.Train(train)
+
+	oob, err := rf.OOB(train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oob.Probabilities != nil {
+		t.Error("a regression forest returns class probabilities")
+	}
+	heldOut := rf.Score(test).R2
+	if oob.Score < 0.5 || math.Abs(oob.Score-heldOut) > 0.1 {
+		t.Errorf("OOB R² %.3f, held-out R² %.3f", oob.Score, heldOut)
+	}
+
+	predictions, targets := make([]float64, 0, len(train)), make([]float64, 0, len(train))
+	for row, prediction := range oob.Predictions {
+		if oob.Trees[row] > 0 {
+			predictions = append(predictions, prediction)
+			targets = append(targets, train[row][len(train[row])-1])
+		}
+	}
+	if want := metrics.R2(predictions, targets); oob.Score != want {
+		t.Errorf("OOB score %g, R² of the OOB predictions %g", oob.Score, want)
+	}
+}
+
+func TestParallelForestOOB(t *testing.T) {
+	records := harness.StringRecords(harness.Classification(1500, 5, 0.1, 54))
+	train, test := records[:1000], records[1000:]
+	scores := make([]float64, 2)
+	var rf *ParallelRandomForest
+	for i, workers := range []int{1, 4} {
+		rf = NewParallelRandomForest(12, 0.8)
+		rf.SetSeed(6)
+		rf.SetNumWorkers(workers)
+		rf.Train(train)
+		oob, err := rf.OOB(train)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for row, p := range oob.Probabilities {
+			if p != nil && len(p) != len(rf.Classes()) {
+				t.Fatalf("row %d has %d class probabilities", row, len(p))
+			}
+		}
+		scores[i] = oob.Score
+	}
+	if scores[0] != scores[1] {
+		t.Fatalf("OOB accuracy %.3f with 1 worker, %.3f with 4", scores[0], scores[1])
+	}
+
+	correct := 0
+	for _, record := range test {
+		if rf.PredictClass(record[:len(record)-1]) == record[len(record)-1] {
+			correct++
+		}
+	}
+	if heldOut := float64(correct) / float64(len(test)); math.Abs(scores[0]-heldOut) > 0.06 {
+		t.Errorf("OOB accuracy %.3f, held-out accuracy %.3f", scores[0], heldOut)
+	}
+}
+
+func TestOOBRejectsOtherData(t *testing.T) {
+	data := harness.Classification(200, 3, 0.1, 55)
+	rf := NewSequentialRandomForest(3, 1)
+	if _, err := rf.OOB(data); err == nil {
+		t.Error("an untrained forest has an OOB estimate")
+	}
+	rf.Train(data)
+	if _, err := rf.OOB(data[:20]); err == nil {
+		t.Error("OOB accepts fewer rows than the forest was trained on")
+	}
+}
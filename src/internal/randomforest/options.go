@@ -0,0 +1,16 @@
+package randomforest
+
+import (
+	"math/rand"
+
+	"concurrente/internal/decisiontree"
+)
+
+// treeOptions returns the options of one tree of a forest: the forest's,
+// with the seed of the tree's feature subsets drawn from the generator that
+// drew its bootstrap sample. Every tree thus samples features differently,
+// and the same way each time the forest is trained with the same seed.
+func treeOptions(opts decisiontree.Options, rng *rand.Rand) decisiontree.Options {
+	opts.Seed = rng.Int63()
+	return opts
+}
@@ -0,0 +1,214 @@
+package randomforest
+
+import (
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+)
+
+func depthOf(node *decisiontree.Node) int {
+	if node.Left == nil && node.Right == nil {
+		return 0
+	}
+	return 1 + max(depthOf(node.Left), depthOf(node.Right))
+}
+
+func leavesOf(node *decisiontree.Node) int {
+	if node.Left == nil && node.Right == nil {
+		return 1
+	}
+	return leavesOf(node.Left) + leavesOf(node.Right)
+}
+
+func TestForestsPassTreeOptionsThrough(t *testing.T) {
+	data := harness.Classification(300, 4, 0.2, 41)
+	opts := decisiontree.Options{MaxDepth: 2}
+
+	parallel := NewParallelRandomForest(4, 0.8)
+	parallel.SetTreeOptions(opts)
+	parallel.Train(harness.StringRecords(data))
+	for i, tree := range parallel.trees {
+		if d := depthOf(tree.root); d > 2 {
+			t.Errorf("parallel tree %d has depth %d", i, d)
+		}
+	}
+
+	limited := NewParallelRandomForest(4, 0.8)
+	limited.SetTreeOptions(decisiontree.Options{MaxDepth: 10, MaxLeafNodes: 5})
+	limited.Train(harness.StringRecords(data))
+	for i, tree := range limited.trees {
+		if leaves := leavesOf(tree.root); leaves > 5 {
+			t.Errorf("parallel tree %d has %d leaves", i, leaves)
+		}
+	}
+
+	sequential := NewSequentialRandomForest(3, 0.8)
+	sequential.SetSeed(1)
+	sequential.SetTreeOptions(opts)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(3, 0.8)
+	concurrent.SetSeed(1)
+	concurrent.SetTreeOptions(opts)
+	concurrent.Train(data)
+
+	want := harness.Predictions(sequential, data)
+	got := harness.Predictions(concurrent, data)
+	if diff := harness.MaxAbsDiff(want, got); diff > 1e-12 {
+		t.Fatalf("forests with the same options differ by %g", diff)
+	}
+}
+
+func TestParallelTreeMinSamplesLeaf(t *testing.T) {
+	data := harness.StringRecords(harness.Classification(400, 4, 0.1, 42))
+	tree := NewParallelDecisionTree(decisiontree.Options{MaxDepth: 10, MinSamplesLeaf: 30})
+	tree.Train(data)
+
+	var walk func(node *decisiontree.Node, rows [][]string)
+	walk = func(node *decisiontree.Node, rows [][]string) {
+		if node.Left == nil && node.Right == nil {
+			if len(rows) < 30 {
+				t.Errorf("leaf holds %d rows, want at least 30", len(rows))
+			}
+			return
+		}
+		left, right := tree.splitData(rows, node.Feature, node.Threshold)
+		walk(node.Left, left)
+		walk(node.Right, right)
+	}
+	walk(tree.root, data)
+}
+
+func TestParallelTreeCriteria(t *testing.T) {
+	data := harness.Classification(1200, 5, 0.05, 43)
+	train, test := harness.StringRecords(data[:800]), data[800:]
+
+	predictions := func(opts decisiontree.Options) []float64 {
+		tree := NewParallelDecisionTree(opts)
+		tree.Train(train)
+		preds := make([]float64, len(test))
+		for i, record := range harness.StringRecords(test) {
+			preds[i] = tree.Predict(record[:len(record)-1])
+		}
+		return preds
+	}
+
+	defaults := predictions(DefaultParallelTreeOptions())
+	gini := DefaultParallelTreeOptions()
+	gini.Criterion = decisiontree.Gini
+	if diff := harness.MaxAbsDiff(defaults, predictions(gini)); diff != 0 {
+		t.Fatalf("explicit Gini changed predictions by up to %g", diff)
+	}
+
+	// A single median-split tree is a weak learner, so the criteria are only
+	// expected to do about as well as the default.
+	giniAccuracy := harness.Accuracy(defaults, test, 0.5)
+	for _, criterion := range decisiontree.Criteria {
+		opts := DefaultParallelTreeOptions()
+		opts.Criterion = criterion
+		if accuracy := harness.Accuracy(predictions(opts), test, 0.5); accuracy < giniAccuracy-0.05 {
+			t.Errorf("%s: accuracy %.3f, gini %.3f", criterion, accuracy, giniAccuracy)
+		}
+	}
+}
+
+func TestParallelTreePruning(t *testing.T) {
+	data := harness.Classification(900, 5, 0.2, 44)
+	train, validation := harness.StringRecords(data[:600]), harness.StringRecords(data[600:])
+
+	full := NewParallelDecisionTree(DefaultParallelTreeOptions())
+	full.Train(train)
+	path := full.CostComplexityPath()
+
+	opts := DefaultParallelTreeOptions()
+	opts.CCPAlpha = path.Alphas[len(path.Alphas)/2]
+	pruned := NewParallelDecisionTree(opts)
+	pruned.Train(train)
+	if leavesOf(pruned.root) >= leavesOf(full.root) {
+		t.Errorf("ccpAlpha left %d leaves of %d", leavesOf(pruned.root), leavesOf(full.root))
+	}
+
+	errors := func(tree *ParallelDecisionTree) int {
+		n := 0
+		for _, record := range validation {
+			if tree.PredictClass(record[:len(record)-1]) != record[len(record)-1] {
+				n++
+			}
+		}
+		return n
+	}
+	before := errors(full)
+	fullLeaves := leavesOf(full.root)
+	full.PruneReducedError(validation)
+	if errors(full) > before || leavesOf(full.root) >= fullLeaves {
+		t.Errorf(
+			"reduced-error pruning: %d errors with %d leaves, %d with %d before",
+			errors(full), leavesOf(full.root), before, fullLeaves,
+		)
+	}
+}
+
+func TestForestsSampleFeaturesPerSplit(t *testing.T) {
+	data := harness.Classification(800, 9, 0.1, 43)
+	opts := decisiontree.Options{MaxDepth: 6, MaxFeatures: decisiontree.SqrtFeatures}
+
+	sequential := NewSequentialRandomForest(12, 0.8)
+	sequential.SetSeed(2)
+	sequential.SetTreeOptions(opts)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(12, 0.8)
+	concurrent.SetSeed(2)
+	concurrent.SetTreeOptions(opts)
+	concurrent.Train(data)
+	want := harness.Predictions(sequential, data)
+	if diff := harness.MaxAbsDiff(want, harness.Predictions(concurrent, data)); diff > 1e-12 {
+		t.Fatalf("forests with the same seed differ by %g", diff)
+	}
+
+	bagged := NewSequentialRandomForest(12, 0.8)
+	bagged.SetSeed(2)
+	bagged.SetTreeOptions(decisiontree.Options{MaxDepth: 6})
+	bagged.Train(data)
+	rootFeatures := func(trees []*decisiontree.SequentialDecisionTree) map[int]bool {
+		features := make(map[int]bool)
+		for _, tree := range trees {
+			features[tree.Root().Feature] = true
+		}
+		return features
+	}
+	sampled, all := rootFeatures(sequential.trees), rootFeatures(bagged.trees)
+	if len(sampled) <= len(all) {
+		t.Errorf("roots split on %v with sampled features, %v without", sampled, all)
+	}
+}
+
+func TestParallelForestSamplesFeaturesPerSplit(t *testing.T) {
+	data := harness.StringRecords(harness.Classification(600, 8, 0.1, 44))
+	opts := DefaultParallelTreeOptions()
+	opts.MaxFeatures = decisiontree.FeatureCount(2)
+
+	forests := make([]*ParallelRandomForest, 2)
+	for i, workers := range []int{1, 4} {
+		forests[i] = NewParallelRandomForest(8, 0.8)
+		forests[i].SetSeed(9)
+		forests[i].SetNumWorkers(workers)
+		forests[i].SetTreeOptions(opts)
+		forests[i].Train(data)
+	}
+	features := make(map[int]bool)
+	for i, tree := range forests[0].trees {
+		if tree.opts.Seed == forests[0].trees[(i+1)%8].opts.Seed {
+			t.Fatalf("trees %d and %d share a seed", i, (i+1)%8)
+		}
+		features[tree.root.Feature] = true
+	}
+	if len(features) < 2 {
+		t.Errorf("every root splits feature %v", features)
+	}
+	for i, record := range data {
+		sample := record[:len(record)-1]
+		if want, got := forests[0].Predict(sample), forests[1].Predict(sample); want != got {
+			t.Fatalf("record %d: 1 worker predicts %g, 4 workers %g", i, want, got)
+		}
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"math"
 	"math/rand"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
+
+	"concurrente/internal/decisiontree"
 )
 
 type ParallelRandomForest struct {
@@ -13,34 +16,105 @@ type ParallelRandomForest struct {
 	numTrees    int
 	subsetRatio float64
 	numWorkers  int
+	seed        int64
+	treeOpts    decisiontree.Options
+	// classes are the labels the trees predict; class i is classes[i].
+	classes []string
+	// inBag[i] are the training rows tree i's bootstrap sample drew.
+	inBag [][]int
 }
 
+// ParallelDecisionTree is the tree grown by ParallelRandomForest. It works on
+// the raw string records, splits each feature at the value in the middle of
+// the node's rows and sends values below the threshold to the left.
 type ParallelDecisionTree struct {
-	root *Node
+	root       *decisiontree.Node
+	opts       decisiontree.Options
+	classes    []string
+	classIndex map[string]int
+	// numFeatures is the number of columns before the label in the records
+	// Train was given.
+	numFeatures int
 }
 
-type Node struct {
-	Feature    int
-	Threshold  float64
-	Left       *Node
-	Right      *Node
-	Prediction float64
+// DefaultParallelTreeOptions returns the limits ParallelDecisionTree has
+// always used.
+func DefaultParallelTreeOptions() decisiontree.Options {
+	return decisiontree.Options{MaxDepth: 10, MinSamplesSplit: 2}
 }
 
 func NewParallelRandomForest(numTrees int, subsetRatio float64) *ParallelRandomForest {
 	return &ParallelRandomForest{
-		trees:       make([]*ParallelDecisionTree, numTrees),
 		numTrees:    numTrees,
 		subsetRatio: subsetRatio,
 		numWorkers:  runtime.GOMAXPROCS(0),
+		seed:        rand.Int63(),
+		treeOpts:    DefaultParallelTreeOptions(),
 	}
 }
 
+// SetTreeOptions sets the stopping criteria of the trees grown by Train.
+func (rf *ParallelRandomForest) SetTreeOptions(opts decisiontree.Options) {
+	rf.treeOpts = opts
+}
+
+// SetSeed fixes the seed the bootstrap samples are drawn from. Tree i uses
+// seed+i regardless of which worker builds it.
+func (rf *ParallelRandomForest) SetSeed(seed int64) {
+	rf.seed = seed
+}
+
+// SetNumWorkers sets how many goroutines build trees, which defaults to
+// GOMAXPROCS.
+func (rf *ParallelRandomForest) SetNumWorkers(numWorkers int) {
+	if numWorkers > 0 {
+		rf.numWorkers = numWorkers
+	}
+}
+
+// SetClasses fixes the order of the labels, so class i of PredictProba is
+// classes[i]. Labels in the training data that are not listed follow in
+// sorted order. By default every label is sorted, which makes "NO" class 0
+// and "SI" class 1.
+func (rf *ParallelRandomForest) SetClasses(classes []string) {
+	rf.classes = classes
+}
+
 func (rf *ParallelRandomForest) Train(data [][]string) {
+	rf.train(data, nil)
+}
+
+// TrainWeighted is Train with bootstrap samples that draw row i with
+// probability proportional to weights[i]. The trees then train on their
+// samples unweighted, since a heavier row already appears more often. Nil
+// weights train like Train. It returns an error, and leaves the forest as it
+// was, unless there is one non-negative, finite weight per row and they add
+// up to more than 0.
+func (rf *ParallelRandomForest) TrainWeighted(data [][]string, weights []float64) error {
+	cumulative, err := cumulativeWeights(weights, len(data))
+	if err != nil {
+		return err
+	}
+	rf.train(data, cumulative)
+	return nil
+}
+
+func (rf *ParallelRandomForest) train(data [][]string, cumulative []float64) {
+	// Classes come from all of data so every tree numbers them the same way,
+	// even when its bootstrap sample misses one.
+	rf.classes = classesOf(data, rf.classes)
+	rf.trees = make([]*ParallelDecisionTree, rf.numTrees)
+	rf.inBag = make([][]int, rf.numTrees)
+	rf.growTrees(data, cumulative, 0)
+}
+
+// growTrees trains the trees from first on bootstrap samples of data, shared
+// out among the workers.
+func (rf *ParallelRandomForest) growTrees(data [][]string, cumulative []float64, first int) {
 	var wg sync.WaitGroup
-	treeChan := make(chan int, rf.numTrees)
+	treeChan := make(chan int, rf.numTrees-first)
 
-	for i := 0; i < rf.numTrees; i++ {
+	for i := first; i < rf.numTrees; i++ {
 		treeChan <- i
 	}
 	close(treeChan)
@@ -50,20 +124,26 @@ func (rf *ParallelRandomForest) Train(data [][]string) {
 		go func() {
 			defer wg.Done()
 			for treeIndex := range treeChan {
-				bootstrapSample := rf.createBootstrapSample(data)
-				tree := &ParallelDecisionTree{}
+				rng := rand.New(rand.NewSource(rf.seed + int64(treeIndex)))
+				bootstrapSample, indices := rf.createBootstrapSample(data, cumulative, rng)
+				tree := NewParallelDecisionTree(treeOptions(rf.treeOpts, rng))
+				tree.SetClasses(rf.classes)
 				tree.Train(bootstrapSample)
 				rf.trees[treeIndex] = tree
+				rf.inBag[treeIndex] = indices
 			}
 		}()
 	}
 	wg.Wait()
 }
 
+// Predict returns the trees' predictions averaged, which for two classes is
+// the share of the vote for class 1 of Classes. It only makes sense for
+// binary forests; with more classes use PredictClass or PredictProba.
 func (rf *ParallelRandomForest) Predict(sample []string) float64 {
-	predictions := make([]float64, rf.numTrees)
+	predictions := make([]float64, len(rf.trees))
 	var wg sync.WaitGroup
-	wg.Add(rf.numTrees)
+	wg.Add(len(rf.trees))
 
 	for i := range rf.trees {
 		go func(index int) {
@@ -76,23 +156,35 @@ func (rf *ParallelRandomForest) Predict(sample []string) float64 {
 	return rf.majorityVote(predictions)
 }
 
-func (rf *ParallelRandomForest) createBootstrapSample(data [][]string) [][]string {
+func (rf *ParallelRandomForest) createBootstrapSample(
+	data [][]string,
+	cumulative []float64,
+	rng *rand.Rand,
+) ([][]string, []int) {
 	sampleSize := int(float64(len(data)) * rf.subsetRatio)
 	sample := make([][]string, sampleSize)
+
+	// rand.Rand is not safe for concurrent use, so the indices are drawn up
+	// front and only the copies run in parallel.
+	indices := make([]int, sampleSize)
+	for i := range indices {
+		indices[i] = drawIndex(rng, len(data), cumulative)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(sampleSize)
 
 	for i := 0; i < sampleSize; i++ {
 		go func(index int) {
 			defer wg.Done()
-			randomIndex := rand.Intn(len(data))
+			randomIndex := indices[index]
 			sample[index] = make([]string, len(data[randomIndex]))
 			copy(sample[index], data[randomIndex])
 		}(i)
 	}
 	wg.Wait()
 
-	return sample
+	return sample, indices
 }
 
 func (rf *ParallelRandomForest) majorityVote(predictions []float64) float64 {
@@ -103,48 +195,155 @@ func (rf *ParallelRandomForest) majorityVote(predictions []float64) float64 {
 	return sum / float64(len(predictions))
 }
 
-func (tree *ParallelDecisionTree) Train(data [][]string) {
-	tree.root = tree.buildTree(data, 0)
+func NewParallelDecisionTree(opts decisiontree.Options) *ParallelDecisionTree {
+	return &ParallelDecisionTree{opts: opts}
 }
 
-func (tree *ParallelDecisionTree) buildTree(data [][]string, depth int) *Node {
-	if len(data) == 0 {
-		return &Node{Prediction: 0}
+// SetClasses fixes the order of the labels, like
+// ParallelRandomForest.SetClasses.
+func (tree *ParallelDecisionTree) SetClasses(classes []string) {
+	tree.classes = classes
+}
+
+func (tree *ParallelDecisionTree) Train(data [][]string) {
+	tree.classes = classesOf(data, tree.classes)
+	tree.numFeatures = 0
+	if len(data) > 0 {
+		tree.numFeatures = len(data[0]) - 1
+	}
+	tree.classIndex = make(map[string]int, len(tree.classes))
+	for i, class := range tree.classes {
+		tree.classIndex[class] = i
+	}
+
+	var splitter decisiontree.Splitter[string] = parallelSplitter{tree}
+	if tree.opts.MaxBins > 0 && len(data) > 0 {
+		splitter = decisiontree.NewHistogramSplitter(data, splitter, tree.histogramConfig(len(data[0])))
 	}
+	tree.root = decisiontree.Grow(data, splitter, tree.opts)
+	if tree.opts.CCPAlpha > 0 {
+		tree.Prune(tree.opts.CCPAlpha)
+	}
+}
+
+// CostComplexityPath returns the pruning path of the trained tree.
+func (tree *ParallelDecisionTree) CostComplexityPath() decisiontree.PruningPath {
+	return decisiontree.CostComplexityPath(tree.root)
+}
+
+// Prune replaces the tree with its cost-complexity pruning at alpha.
+func (tree *ParallelDecisionTree) Prune(alpha float64) {
+	tree.root = decisiontree.PruneCostComplexity(tree.root, alpha)
+}
+
+// PruneReducedError prunes the tree against validation records, routed like
+// Predict routes samples.
+func (tree *ParallelDecisionTree) PruneReducedError(validation [][]string) {
+	tree.root = decisiontree.PruneReducedError(tree.root, validation, tree.splitNode, tree.label)
+}
 
-	if depth >= 10 || len(data) < 2 {
-		return &Node{Prediction: tree.calculatePrediction(data)}
+// splitNode partitions records like node routes samples.
+func (tree *ParallelDecisionTree) splitNode(data [][]string, node *decisiontree.Node) ([][]string, [][]string) {
+	return tree.splitData(data, node.Feature, node.Threshold)
+}
+
+// histogramConfig searches the same features as findBestSplit, skipping the
+// first column and the label, and drops values that do not parse like
+// splitData does.
+func (tree *ParallelDecisionTree) histogramConfig(numColumns int) decisiontree.HistogramConfig[string] {
+	features := make([]int, 0, numColumns)
+	for feature := 1; feature < numColumns-1; feature++ {
+		features = append(features, feature)
+	}
+	return decisiontree.HistogramConfig[string]{
+		Features: features,
+		Value: func(row []string, feature int) (float64, bool) {
+			val, err := strconv.ParseFloat(row[feature], 64)
+			return val, err == nil
+		},
+		Label:          tree.label,
+		Partition:      tree.splitData,
+		Criterion:      tree.criterion(),
+		Strict:         true,
+		MaxBins:        tree.opts.MaxBins,
+		MinSamplesLeaf: max(1, tree.opts.MinSamplesLeaf),
 	}
+}
 
-	bestFeature, bestThreshold := tree.findBestSplit(data)
+type parallelSplitter struct {
+	tree *ParallelDecisionTree
+}
 
-	leftData, rightData := tree.splitData(data, bestFeature, bestThreshold)
+func (s parallelSplitter) Impurity(data [][]string) float64 {
+	return s.tree.calculateImpurity(data)
+}
+
+func (s parallelSplitter) LeafValue(data [][]string) float64 {
+	return s.tree.calculatePrediction(data)
+}
 
-	return &Node{
+func (s parallelSplitter) LeafCounts(data [][]string) []float64 {
+	return s.tree.classCounts(data)
+}
+
+func (s parallelSplitter) BestSplit(data [][]string) (decisiontree.Split[string], bool) {
+	return s.BestSplitAmong(data, nil, nil)
+}
+
+// NumCandidates counts the columns findBestSplit searches: every one but the
+// first and the label.
+func (s parallelSplitter) NumCandidates() int {
+	return max(0, s.tree.numFeatures-1)
+}
+
+// BestSplitAmong searches column c+1 for each candidate c, or every column
+// findBestSplit searches when candidates is nil.
+func (s parallelSplitter) BestSplitAmong(
+	data [][]string,
+	_ any,
+	candidates []int,
+) (decisiontree.Split[string], bool) {
+	bestFeature, bestThreshold, bestImpurity := s.tree.findBestSplit(data, candidates)
+	if bestFeature == -1 {
+		return decisiontree.Split[string]{}, false
+	}
+
+	leftData, rightData := s.tree.splitData(data, bestFeature, bestThreshold)
+	return decisiontree.Split[string]{
 		Feature:   bestFeature,
 		Threshold: bestThreshold,
-		Left:      tree.buildTree(leftData, depth+1),
-		Right:     tree.buildTree(rightData, depth+1),
-	}
+		Impurity:  bestImpurity,
+		Left:      leftData,
+		Right:     rightData,
+	}, true
 }
 
-func (tree *ParallelDecisionTree) findBestSplit(data [][]string) (int, float64) {
-	bestFeature := 0
+// findBestSplit returns feature -1 when no median split leaves rows on both
+// sides. It searches column c+1 for each of candidates, or every column but
+// the first and the label when candidates is nil.
+func (tree *ParallelDecisionTree) findBestSplit(data [][]string, candidates []int) (int, float64, float64) {
+	bestFeature := -1
 	bestThreshold := 0.0
-	bestGini := float64(1)
+	bestImpurity := math.Inf(1)
 
-	for feature := 1; feature < len(data[0])-1; feature++ {
+	if candidates == nil {
+		for feature := 1; feature < len(data[0])-1; feature++ {
+			candidates = append(candidates, feature-1)
+		}
+	}
+	for _, candidate := range candidates {
+		feature := candidate + 1
 		threshold := tree.findMedian(data, feature)
-		gini := tree.calculateGiniIndex(data, feature, threshold)
+		impurity := tree.calculateImpurityIndex(data, feature, threshold)
 
-		if gini < bestGini {
-			bestGini = gini
+		if impurity < bestImpurity {
+			bestImpurity = impurity
 			bestFeature = feature
 			bestThreshold = threshold
 		}
 	}
 
-	return bestFeature, bestThreshold
+	return bestFeature, bestThreshold, bestImpurity
 }
 
 func (tree *ParallelDecisionTree) findMedian(data [][]string, feature int) float64 {
@@ -160,13 +359,16 @@ func (tree *ParallelDecisionTree) findMedian(data [][]string, feature int) float
 	return values[len(values)/2]
 }
 
-func (tree *ParallelDecisionTree) calculateGiniIndex(
+// calculateImpurityIndex returns the weighted impurity of splitting data at
+// threshold, or +Inf when a side keeps fewer than MinSamplesLeaf rows (and at
+// least one). Rows whose value does not parse are left out.
+func (tree *ParallelDecisionTree) calculateImpurityIndex(
 	data [][]string,
 	feature int,
 	threshold float64,
 ) float64 {
-	leftCount, rightCount := 0, 0
-	leftPositive, rightPositive := 0, 0
+	criterion := tree.criterion()
+	left, right := criterion.NewStats(), criterion.NewStats()
 
 	for _, row := range data {
 		val, err := strconv.ParseFloat(row[feature], 64)
@@ -175,39 +377,57 @@ func (tree *ParallelDecisionTree) calculateGiniIndex(
 		}
 
 		if val < threshold {
-			leftCount++
-			if row[len(row)-1] == "SI" {
-				leftPositive++
-			}
+			left.Add(tree.label(row))
 		} else {
-			rightCount++
-			if row[len(row)-1] == "SI" {
-				rightPositive++
-			}
+			right.Add(tree.label(row))
 		}
 	}
 
-	if leftCount == 0 || rightCount == 0 {
-		return 1.0
+	minLeaf := max(1, tree.opts.MinSamplesLeaf)
+	if left.Count() < minLeaf || right.Count() < minLeaf {
+		return math.Inf(1)
 	}
 
-	leftGini := 1.0 - math.Pow(
-		float64(leftPositive)/float64(leftCount),
-		2,
-	) - math.Pow(
-		float64(leftCount-leftPositive)/float64(leftCount),
-		2,
-	)
-	rightGini := 1.0 - math.Pow(
-		float64(rightPositive)/float64(rightCount),
-		2,
-	) - math.Pow(
-		float64(rightCount-rightPositive)/float64(rightCount),
-		2,
-	)
-
-	totalGini := (float64(leftCount)*leftGini + float64(rightCount)*rightGini) / float64(len(data))
-	return totalGini
+	return (float64(left.Count())*left.Impurity() + float64(right.Count())*right.Impurity()) /
+		float64(len(data))
+}
+
+func (tree *ParallelDecisionTree) calculateImpurity(data [][]string) float64 {
+	stats := tree.criterion().NewStats()
+	for _, row := range data {
+		stats.Add(tree.label(row))
+	}
+	return stats.Impurity()
+}
+
+func (tree *ParallelDecisionTree) criterion() decisiontree.Criterion {
+	if tree.opts.Criterion == nil {
+		return decisiontree.Gini
+	}
+	return tree.opts.Criterion
+}
+
+// label is the class index of the record's last column.
+func (tree *ParallelDecisionTree) label(row []string) float64 {
+	return float64(tree.classIndex[row[len(row)-1]])
+}
+
+// classesOf returns classes followed by the labels of data it does not list,
+// sorted.
+func classesOf(data [][]string, classes []string) []string {
+	listed := make(map[string]bool, len(classes))
+	for _, class := range classes {
+		listed[class] = true
+	}
+	var extra []string
+	for _, row := range data {
+		if class := row[len(row)-1]; !listed[class] {
+			listed[class] = true
+			extra = append(extra, class)
+		}
+	}
+	sort.Strings(extra)
+	return append(append([]string(nil), classes...), extra...)
 }
 
 func (tree *ParallelDecisionTree) splitData(
@@ -237,9 +457,7 @@ func (tree *ParallelDecisionTree) calculatePrediction(data [][]string) float64 {
 	sum := 0.0
 	count := 0
 	for _, row := range data {
-		if row[len(row)-1] == "SI" {
-			sum += 1
-		}
+		sum += tree.label(row)
 		count++
 	}
 	if count == 0 {
@@ -248,7 +466,41 @@ func (tree *ParallelDecisionTree) calculatePrediction(data [][]string) float64 {
 	return sum / float64(count)
 }
 
+// classCounts is the number of rows of data in each class.
+func (tree *ParallelDecisionTree) classCounts(data [][]string) []float64 {
+	counts := make([]float64, len(tree.classes))
+	for _, row := range data {
+		counts[tree.classIndex[row[len(row)-1]]]++
+	}
+	return counts
+}
+
 func (tree *ParallelDecisionTree) Predict(sample []string) float64 {
+	return tree.leaf(sample).Prediction
+}
+
+// PredictProba returns the class distribution of the leaf sample falls into,
+// smoothed by the tree options' Smoothing and indexed like the tree's
+// classes. A sample whose value for a split does not parse stops at that
+// node and gets the distribution of its training rows.
+func (tree *ParallelDecisionTree) PredictProba(sample []string) []float64 {
+	return tree.opts.Smoothing.Probabilities(tree.leaf(sample), tree.root)
+}
+
+// PredictClass returns the most frequent label of the leaf sample falls into.
+func (tree *ParallelDecisionTree) PredictClass(sample []string) string {
+	return tree.classes[decisiontree.Argmax(tree.leaf(sample).Distribution)]
+}
+
+// PredictLeaf returns the ID of the node sample stops at: its leaf, or the
+// split whose value does not parse.
+func (tree *ParallelDecisionTree) PredictLeaf(sample []string) int {
+	return tree.leaf(sample).ID
+}
+
+// leaf walks sample down the tree, stopping early at a split whose value
+// does not parse.
+func (tree *ParallelDecisionTree) leaf(sample []string) *decisiontree.Node {
 	node := tree.root
 	for node.Left != nil && node.Right != nil {
 		val, err := strconv.ParseFloat(sample[node.Feature], 64)
@@ -261,6 +513,5 @@ func (tree *ParallelDecisionTree) Predict(sample []string) float64 {
 			node = node.Right
 		}
 	}
-	return node.Prediction
+	return node
 }
-
@@ -0,0 +1,59 @@
+package randomforest
+
+import (
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/metrics"
+)
+
+// SequentialRandomForestRegressor averages regression trees grown one after
+// another on bootstrap samples. Its trees minimize MSE unless SetTreeOptions
+// picks another criterion.
+type SequentialRandomForestRegressor struct {
+	*SequentialRandomForest
+}
+
+func NewSequentialRandomForestRegressor(
+	numTrees int,
+	subsetRatio float64,
+) *SequentialRandomForestRegressor {
+	rf := NewSequentialRandomForest(numTrees, subsetRatio)
+	rf.treeOpts = decisiontree.DefaultRegressionOptions()
+	return &SequentialRandomForestRegressor{rf}
+}
+
+// Score returns the R² and RMSE of the forest on data, whose last column is
+// the target.
+func (rf *SequentialRandomForestRegressor) Score(data [][]float64) metrics.Regression {
+	return scoreRegression(rf, data)
+}
+
+// ConcurrentRandomForestRegressor is SequentialRandomForestRegressor with the
+// trees grown and queried in parallel.
+type ConcurrentRandomForestRegressor struct {
+	*ConcurrentRandomForest
+}
+
+func NewConcurrentRandomForestRegressor(
+	numTrees int,
+	subsetRatio float64,
+) *ConcurrentRandomForestRegressor {
+	rf := NewConcurrentRandomForest(numTrees, subsetRatio)
+	rf.treeOpts = decisiontree.DefaultRegressionOptions()
+	return &ConcurrentRandomForestRegressor{rf}
+}
+
+// Score returns the R² and RMSE of the forest on data, whose last column is
+// the target.
+func (rf *ConcurrentRandomForestRegressor) Score(data [][]float64) metrics.Regression {
+	return scoreRegression(rf, data)
+}
+
+func scoreRegression(model interface{ Predict([]float64) float64 }, data [][]float64) metrics.Regression {
+	predictions := make([]float64, len(data))
+	targets := make([]float64, len(data))
+	for i, row := range data {
+		predictions[i] = model.Predict(row[:len(row)-1])
+		targets[i] = row[len(row)-1]
+	}
+	return metrics.EvaluateRegression(predictions, targets)
+}
@@ -0,0 +1,47 @@
+package randomforest
+
+import (
+	"testing"
+
+	"concurrente/internal/decisiontree"
+	"concurrente/internal/harness"
+	"concurrente/internal/metrics"
+)
+
+func TestRegressorsMatchAndFit(t *testing.T) {
+	data := harness.Regression(1500, 4, 0.1, 51)
+	train, test := data[:1000], data[1000:]
+
+	sequential := NewSequentialRandomForestRegressor(10, 0.8)
+	sequential.SetSeed(4)
+	sequential.Train(train)
+
+	concurrent := NewConcurrentRandomForestRegressor(10, 0.8)
+	concurrent.SetSeed(4)
+	concurrent.Train(train)
+
+	want := harness.Predictions(sequential, test)
+	got := harness.Predictions(concurrent, test)
+	if diff := harness.MaxAbsDiff(want, got); diff > 1e-12 {
+		t.Fatalf("predictions differ by up to %g", diff)
+	}
+
+	score := concurrent.Score(test)
+	if score != sequential.Score(test) {
+		t.Fatalf("scores differ: %+v and %+v", score, sequential.Score(test))
+	}
+	if score.R2 < 0.8 {
+		t.Fatalf("forest R² %.3f", score.R2)
+	}
+
+	tree := decisiontree.NewSequentialRegressionTree()
+	tree.Train(train)
+	targets := make([]float64, len(test))
+	for i, row := range test {
+		targets[i] = row[len(row)-1]
+	}
+	treeScore := metrics.EvaluateRegression(harness.Predictions(tree, test), targets)
+	if score.RMSE > treeScore.RMSE {
+		t.Fatalf("forest RMSE %.3f is worse than a single tree's %.3f", score.RMSE, treeScore.RMSE)
+	}
+}
@@ -10,41 +10,101 @@ type SequentialRandomForest struct {
 	trees       []*decisiontree.SequentialDecisionTree
 	numTrees    int
 	subsetRatio float64
+	seed        int64
+	treeOpts    decisiontree.Options
+	// inBag[i] are the training rows tree i's bootstrap sample drew.
+	inBag [][]int
+	// numClasses is the number of classes in the data Train was given, or 0
+	// when the trees are regression trees.
+	numClasses int
 }
 
 func NewSequentialRandomForest(numTrees int, subsetRatio float64) *SequentialRandomForest {
 	return &SequentialRandomForest{
-		trees:       make([]*decisiontree.SequentialDecisionTree, numTrees),
 		numTrees:    numTrees,
 		subsetRatio: subsetRatio,
+		seed:        rand.Int63(),
+		treeOpts:    decisiontree.DefaultOptions(),
 	}
 }
 
+// SetTreeOptions sets the stopping criteria of the trees grown by Train.
+func (rf *SequentialRandomForest) SetTreeOptions(opts decisiontree.Options) {
+	rf.treeOpts = opts
+}
+
+// SetSeed fixes the seed the bootstrap samples are drawn from. Tree i uses
+// seed+i, so two forests with the same seed train on the same samples.
+func (rf *SequentialRandomForest) SetSeed(seed int64) {
+	rf.seed = seed
+}
+
+// Train grows the forest on data, whose last column is the label. It panics
+// with the error TrainWeighted would return for labels that are not classes.
 func (rf *SequentialRandomForest) Train(data [][]float64) {
-	for i := 0; i < rf.numTrees; i++ {
-		bootstrapSample := rf.createBootstrapSample(data)
-		tree := decisiontree.NewSequentialDecisionTree()
+	if err := rf.TrainWeighted(data, nil); err != nil {
+		panic(err)
+	}
+}
+
+// TrainWeighted is Train with bootstrap samples that draw row i with
+// probability proportional to weights[i]. The trees then train on their
+// samples unweighted, since a heavier row already appears more often. Nil
+// weights train like Train. It returns an error, and leaves the forest as it
+// was, unless there is one non-negative, finite weight per row and they add
+// up to more than 0, or the labels fail decisiontree.CheckLabels.
+func (rf *SequentialRandomForest) TrainWeighted(data [][]float64, weights []float64) error {
+	if err := decisiontree.CheckLabels(rf.treeOpts.Criterion, data); err != nil {
+		return err
+	}
+	cumulative, err := cumulativeWeights(weights, len(data))
+	if err != nil {
+		return err
+	}
+	rf.train(data, cumulative)
+	return nil
+}
+
+func (rf *SequentialRandomForest) train(data [][]float64, cumulative []float64) {
+	rf.trees = make([]*decisiontree.SequentialDecisionTree, rf.numTrees)
+	rf.inBag = make([][]int, rf.numTrees)
+	rf.numClasses = grownClasses(rf.treeOpts, 0, data)
+	rf.growTrees(data, cumulative, 0)
+}
+
+// growTrees trains the trees from first on bootstrap samples of data.
+func (rf *SequentialRandomForest) growTrees(data [][]float64, cumulative []float64, first int) {
+	for i := first; i < rf.numTrees; i++ {
+		rng := rand.New(rand.NewSource(rf.seed + int64(i)))
+		bootstrapSample, indices := rf.createBootstrapSample(data, cumulative, rng)
+		tree := decisiontree.NewSequentialDecisionTreeWithOptions(treeOptions(rf.treeOpts, rng))
 		tree.Train(bootstrapSample)
 		rf.trees[i] = tree
+		rf.inBag[i] = indices
 	}
 }
 
 func (rf *SequentialRandomForest) Predict(sample []float64) float64 {
-	predictions := make([]float64, rf.numTrees)
+	predictions := make([]float64, len(rf.trees))
 	for i, tree := range rf.trees {
 		predictions[i] = tree.Predict(sample)
 	}
 	return rf.majorityVote(predictions)
 }
 
-func (rf *SequentialRandomForest) createBootstrapSample(data [][]float64) [][]float64 {
+func (rf *SequentialRandomForest) createBootstrapSample(
+	data [][]float64,
+	cumulative []float64,
+	rng *rand.Rand,
+) ([][]float64, []int) {
 	sampleSize := int(float64(len(data)) * rf.subsetRatio)
 	sample := make([][]float64, sampleSize)
+	indices := make([]int, sampleSize)
 	for i := 0; i < sampleSize; i++ {
-		randomIndex := rand.Intn(len(data))
-		sample[i] = data[randomIndex]
+		indices[i] = drawIndex(rng, len(data), cumulative)
+		sample[i] = data[indices[i]]
 	}
-	return sample
+	return sample, indices
 }
 
 func (rf *SequentialRandomForest) majorityVote(predictions []float64) float64 {
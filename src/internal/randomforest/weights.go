@@ -0,0 +1,46 @@
+package randomforest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// cumulativeWeights returns the running sums of the weights of rows training
+// rows, or nil when there are no weights. It returns an error unless there is
+// one weight per row, each non-negative and finite, and they add up to more
+// than 0, so that drawIndex can draw every row with positive weight and no
+// other.
+func cumulativeWeights(weights []float64, rows int) ([]float64, error) {
+	if weights == nil {
+		return nil, nil
+	}
+	if len(weights) != rows {
+		return nil, fmt.Errorf("randomforest: %d weights for %d rows", len(weights), rows)
+	}
+	cumulative := make([]float64, len(weights))
+	sum := 0.0
+	for i, weight := range weights {
+		if !(weight >= 0) || math.IsInf(weight, 1) {
+			return nil, fmt.Errorf("randomforest: weight %d is %g, want a non-negative number", i, weight)
+		}
+		sum += weight
+		cumulative[i] = sum
+	}
+	if !(sum > 0) || math.IsInf(sum, 1) {
+		return nil, fmt.Errorf("randomforest: weights add up to %g, want a positive number", sum)
+	}
+	return cumulative, nil
+}
+
+// drawIndex draws one of n rows for a bootstrap sample: uniformly when
+// cumulative is nil, and otherwise with probability proportional to the
+// row's weight, cumulative holding the running sums of the weights.
+func drawIndex(rng *rand.Rand, n int, cumulative []float64) int {
+	if cumulative == nil {
+		return rng.Intn(n)
+	}
+	target := rng.Float64() * cumulative[n-1]
+	return sort.Search(n, func(i int) bool { return cumulative[i] > target })
+}
@@ -0,0 +1,135 @@
+package randomforest
+
+import (
+	"math/rand"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestDrawIndexFollowsWeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cumulative, err := cumulativeWeights([]float64{1, 3, 0, 0.5, 0.5}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int, 5)
+	const draws = 100000
+	for i := 0; i < draws; i++ {
+		counts[drawIndex(rng, 5, cumulative)]++
+	}
+	for i, want := range []float64{0.2, 0.6, 0, 0.1, 0.1} {
+		if got := float64(counts[i]) / draws; !harness.WithinTolerance(got, want, 0.01) {
+			t.Errorf("row %d drawn %.3f of the time, want %.2f", i, got, want)
+		}
+	}
+}
+
+func TestNilWeightsTrainLikeTrain(t *testing.T) {
+	data := harness.Classification(400, 4, 0.1, 3)
+	test := harness.Classification(200, 4, 0.1, 4)
+
+	trained := NewConcurrentRandomForest(5, 0.8)
+	trained.SetSeed(7)
+	trained.Train(data)
+	weighted := NewConcurrentRandomForest(5, 0.8)
+	weighted.SetSeed(7)
+	if err := weighted.TrainWeighted(data, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := harness.Predictions(trained, test)
+	if diff := harness.MaxAbsDiff(want, harness.Predictions(weighted, test)); diff != 0 {
+		t.Fatalf("predictions differ by up to %g", diff)
+	}
+}
+
+func TestWeightedBootstrapFavoursHeavyRows(t *testing.T) {
+	// Half the rows say 1 and half say 0 for the same features, so only the
+	// weights decide what the forests predict.
+	rng := rand.New(rand.NewSource(5))
+	var data [][]float64
+	var weights []float64
+	var records [][]string
+	for i := 0; i < 200; i++ {
+		x := rng.Float64()
+		data = append(data, []float64{x, x, 0}, []float64{x, x, 1})
+		weights = append(weights, 1, 9)
+	}
+	records = harness.StringRecords(data)
+
+	sequential := NewSequentialRandomForest(5, 1)
+	if err := sequential.TrainWeighted(data, weights); err != nil {
+		t.Fatal(err)
+	}
+	concurrent := NewConcurrentRandomForest(5, 1)
+	if err := concurrent.TrainWeighted(data, weights); err != nil {
+		t.Fatal(err)
+	}
+	parallel := NewParallelRandomForest(5, 1)
+	if err := parallel.TrainWeighted(records, weights); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, predict := range map[string]func(i int) float64{
+		"sequential": func(i int) float64 { return sequential.Predict(data[i][:2]) },
+		"concurrent": func(i int) float64 { return concurrent.Predict(data[i][:2]) },
+		"parallel":   func(i int) float64 { return parallel.Predict(records[i][:2]) },
+	} {
+		ones := 0
+		for i := range data {
+			if predict(i) >= 0.5 {
+				ones++
+			}
+		}
+		if share := float64(ones) / float64(len(data)); share < 0.9 {
+			t.Errorf("%s: predicts the heavy class for %.2f of the rows", name, share)
+		}
+	}
+}
+
+func TestTrainWeightedRejectsBadWeights(t *testing.T) {
+	data := harness.Classification(200, 4, 0.1, 5)
+	records := harness.StringRecords(data)
+	test := harness.Classification(100, 4, 0.1, 6)
+
+	sequential := NewSequentialRandomForest(3, 0.8)
+	sequential.Train(data)
+	concurrent := NewConcurrentRandomForest(3, 0.8)
+	concurrent.Train(data)
+	parallel := NewParallelRandomForest(3, 0.8)
+	parallel.Train(records)
+	before := harness.Predictions(sequential, test)
+
+	zeros := make([]float64, len(data))
+	negative := make([]float64, len(data))
+	for i := range negative {
+		negative[i] = 1
+	}
+	negative[7] = -1
+	for name, weights := range map[string][]float64{
+		"short":    zeros[:10],
+		"all zero": zeros,
+		"negative": negative,
+	} {
+		if err := sequential.TrainWeighted(data, weights); err == nil {
+			t.Errorf("%s: sequential forest accepted the weights", name)
+		}
+		if err := concurrent.TrainWeighted(data, weights); err == nil {
+			t.Errorf("%s: concurrent forest accepted the weights", name)
+		}
+		if err := parallel.TrainWeighted(records, weights); err == nil {
+			t.Errorf("%s: parallel forest accepted the weights", name)
+		}
+	}
+	signed := harness.SignedLabels(data)
+	if err := sequential.TrainWeighted(signed, nil); err == nil {
+		t.Error("sequential forest accepted -1 labels")
+	}
+	if err := concurrent.TrainWeighted(signed, nil); err == nil {
+		t.Error("concurrent forest accepted -1 labels")
+	}
+	if diff := harness.MaxAbsDiff(before, harness.Predictions(sequential, test)); diff != 0 {
+		t.Errorf("rejected weights changed the forest's predictions by up to %g", diff)
+	}
+}
@@ -10,6 +10,7 @@ type ConcurrentSVM struct {
 	learningRate float64
 	lambda       float64
 	epochs       int
+	mu           sync.Mutex
 }
 
 func NewConcurrentSVM(features int, learningRate, lambda float64, epochs int) *ConcurrentSVM {
@@ -23,6 +24,24 @@ func NewConcurrentSVM(features int, learningRate, lambda float64, epochs int) *C
 }
 
 func (svm *ConcurrentSVM) Train(data [][]float64) {
+	svm.train(data, nil)
+}
+
+// TrainWeighted is Train with the hinge loss gradient of row i scaled by
+// sampleWeights[i]. Nil weights train like Train. It returns an error, and
+// leaves the model as it was, unless there is one non-negative, finite weight
+// per row and they add up to more than 0.
+func (svm *ConcurrentSVM) TrainWeighted(data [][]float64, sampleWeights []float64) error {
+	if sampleWeights != nil {
+		if err := checkWeights(sampleWeights, len(data)); err != nil {
+			return err
+		}
+	}
+	svm.train(data, sampleWeights)
+	return nil
+}
+
+func (svm *ConcurrentSVM) train(data [][]float64, sampleWeights []float64) {
 	var wg sync.WaitGroup
 	numGoroutines := 4 // Adjust based on your system's capabilities
 
@@ -30,6 +49,16 @@ func (svm *ConcurrentSVM) Train(data [][]float64) {
 		wg.Add(numGoroutines)
 		chunkSize := len(data) / numGoroutines
 
+		// Every chunk starts from the weights at the beginning of the epoch, so
+		// the goroutines never read what another one is writing. Their changes
+		// are added in chunk order once all have finished, so the float sums,
+		// and the trained model, are the same on every run.
+		weights := make([]float64, len(svm.weights))
+		copy(weights, svm.weights)
+		bias := svm.bias
+		weightDeltas := make([][]float64, numGoroutines)
+		biasDeltas := make([]float64, numGoroutines)
+
 		for i := 0; i < numGoroutines; i++ {
 			start := i * chunkSize
 			end := start + chunkSize
@@ -37,68 +66,81 @@ func (svm *ConcurrentSVM) Train(data [][]float64) {
 				end = len(data)
 			}
 
-			go func(start, end int) {
+			var chunkWeights []float64
+			if sampleWeights != nil {
+				chunkWeights = sampleWeights[start:end]
+			}
+			go func(chunk, start, end int) {
 				defer wg.Done()
-				svm.trainChunk(data[start:end])
-			}(start, end)
+				weightDeltas[chunk], biasDeltas[chunk] = svm.trainChunk(data[start:end], chunkWeights, weights, bias)
+			}(i, start, end)
 		}
 
 		wg.Wait()
+		for i := range weightDeltas {
+			svm.updateGlobalWeights(weightDeltas[i], biasDeltas[i])
+		}
 	}
 }
 
-func (svm *ConcurrentSVM) trainChunk(chunk [][]float64) {
-	localWeights := make([]float64, len(svm.weights))
-	localBias := 0.0
-
-	for _, sample := range chunk {
+// trainChunk runs the sequential update rule over chunk on a private copy of
+// the epoch's starting weights and returns the resulting change.
+func (svm *ConcurrentSVM) trainChunk(
+	chunk [][]float64,
+	sampleWeights, weights []float64,
+	bias float64,
+) ([]float64, float64) {
+	localWeights := make([]float64, len(weights))
+	copy(localWeights, weights)
+	localBias := bias
+
+	for i, sample := range chunk {
 		features := sample[:len(sample)-1]
 		label := sample[len(sample)-1]
-		prediction := svm.predict(features)
+		prediction := score(localWeights, localBias, features)
 
 		// Hinge loss gradient
 		if label*prediction < 1 {
-			svm.updateLocalWeights(localWeights, &localBias, features, label)
+			weight := sampleWeight(sampleWeights, i)
+			svm.updateLocalWeights(localWeights, &localBias, features, label, weight)
 		}
 
 		// L2 regularization
 		for i := range localWeights {
-			localWeights[i] -= svm.learningRate * svm.lambda * svm.weights[i]
+			localWeights[i] -= svm.learningRate * svm.lambda * localWeights[i]
 		}
 	}
 
-	svm.updateGlobalWeights(localWeights, localBias)
+	for i := range localWeights {
+		localWeights[i] -= weights[i]
+	}
+	return localWeights, localBias - bias
 }
 
 func (svm *ConcurrentSVM) predict(features []float64) float64 {
-	sum := svm.bias
-	for i, feature := range features {
-		sum += svm.weights[i] * feature
-	}
-	return sum
+	return score(svm.weights, svm.bias, features)
 }
 
 func (svm *ConcurrentSVM) updateLocalWeights(
 	localWeights []float64,
 	localBias *float64,
 	features []float64,
-	label float64,
+	label, weight float64,
 ) {
 	for i, feature := range features {
-		localWeights[i] += svm.learningRate * (label * feature)
+		localWeights[i] += svm.learningRate * weight * (label * feature)
 	}
-	*localBias += svm.learningRate * label
+	*localBias += svm.learningRate * weight * label
 }
 
-func (svm *ConcurrentSVM) updateGlobalWeights(localWeights []float64, localBias float64) {
-	var mutex sync.Mutex
-	mutex.Lock()
-	defer mutex.Unlock()
+func (svm *ConcurrentSVM) updateGlobalWeights(weightDelta []float64, biasDelta float64) {
+	svm.mu.Lock()
+	defer svm.mu.Unlock()
 
 	for i := range svm.weights {
-		svm.weights[i] += localWeights[i]
+		svm.weights[i] += weightDelta[i]
 	}
-	svm.bias += localBias
+	svm.bias += biasDelta
 }
 
 func (svm *ConcurrentSVM) Predict(sample []float64) float64 {
@@ -108,3 +150,11 @@ func (svm *ConcurrentSVM) Predict(sample []float64) float64 {
 	}
 	return 0
 }
+
+func score(weights []float64, bias float64, features []float64) float64 {
+	sum := bias
+	for i, feature := range features {
+		sum += weights[i] * feature
+	}
+	return sum
+}
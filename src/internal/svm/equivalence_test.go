@@ -0,0 +1,43 @@
+package svm
+
+import (
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestConcurrentSVMLearnsSameModelAsSequential(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		data := harness.SignedLabels(harness.Classification(2000, 6, 0.02, seed))
+		train, test := data[:1500], data[1500:]
+
+		sequential := NewSequentialSVM(6, 0.001, 0.01, 20)
+		sequential.Train(train)
+		concurrent := NewConcurrentSVM(6, 0.001, 0.01, 20)
+		concurrent.Train(train)
+
+		want := harness.Predictions(sequential, test)
+		got := harness.Predictions(concurrent, test)
+
+		sequentialAccuracy := harness.Accuracy(want, test, 0.5)
+		concurrentAccuracy := harness.Accuracy(got, test, 0.5)
+		if sequentialAccuracy < 0.9 || concurrentAccuracy < 0.9 {
+			t.Fatalf(
+				"seed %d: accuracy sequential %.3f, concurrent %.3f",
+				seed,
+				sequentialAccuracy,
+				concurrentAccuracy,
+			)
+		}
+		if agreement := harness.Agreement(want, got, 0.5); agreement < 0.95 {
+			t.Fatalf("seed %d: models agree on %.3f of the test rows", seed, agreement)
+		}
+		similarity := harness.CosineSimilarity(
+			append(sequential.weights, sequential.bias),
+			append(concurrent.weights, concurrent.bias),
+		)
+		if similarity < 0.95 {
+			t.Fatalf("seed %d: weight vectors have cosine similarity %.3f", seed, similarity)
+		}
+	}
+}
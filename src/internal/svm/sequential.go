@@ -1,5 +1,10 @@
 package svm
 
+import (
+	"fmt"
+	"math"
+)
+
 type SequentialSVM struct {
 	weights      []float64
 	bias         float64
@@ -19,15 +24,33 @@ func NewSequentialSVM(features int, learningRate, lambda float64, epochs int) *S
 }
 
 func (svm *SequentialSVM) Train(data [][]float64) {
+	svm.train(data, nil)
+}
+
+// TrainWeighted is Train with the hinge loss gradient of row i scaled by
+// weights[i]. Nil weights train like Train. It returns an error, and leaves
+// the model as it was, unless there is one non-negative, finite weight per
+// row and they add up to more than 0.
+func (svm *SequentialSVM) TrainWeighted(data [][]float64, weights []float64) error {
+	if weights != nil {
+		if err := checkWeights(weights, len(data)); err != nil {
+			return err
+		}
+	}
+	svm.train(data, weights)
+	return nil
+}
+
+func (svm *SequentialSVM) train(data [][]float64, weights []float64) {
 	for epoch := 0; epoch < svm.epochs; epoch++ {
-		for _, sample := range data {
+		for i, sample := range data {
 			features := sample[:len(sample)-1]
 			label := sample[len(sample)-1]
 			prediction := svm.predict(features)
 
 			// Hinge loss gradient
 			if label*prediction < 1 {
-				svm.updateWeights(features, label)
+				svm.updateWeights(features, label, sampleWeight(weights, i))
 			}
 
 			// L2 regularization
@@ -46,11 +69,11 @@ func (svm *SequentialSVM) predict(features []float64) float64 {
 	return sum
 }
 
-func (svm *SequentialSVM) updateWeights(features []float64, label float64) {
+func (svm *SequentialSVM) updateWeights(features []float64, label, weight float64) {
 	for i, feature := range features {
-		svm.weights[i] += svm.learningRate * (label * feature)
+		svm.weights[i] += svm.learningRate * weight * (label * feature)
 	}
-	svm.bias += svm.learningRate * label
+	svm.bias += svm.learningRate * weight * label
 }
 
 func (svm *SequentialSVM) Predict(sample []float64) float64 {
@@ -60,3 +83,30 @@ func (svm *SequentialSVM) Predict(sample []float64) float64 {
 	}
 	return 0
 }
+
+// checkWeights returns an error unless weights holds one non-negative,
+// finite weight for each of rows training rows, adding up to more than 0.
+func checkWeights(weights []float64, rows int) error {
+	if len(weights) != rows {
+		return fmt.Errorf("svm: %d weights for %d rows", len(weights), rows)
+	}
+	sum := 0.0
+	for i, weight := range weights {
+		if !(weight >= 0) || math.IsInf(weight, 1) {
+			return fmt.Errorf("svm: weight %d is %g, want a non-negative number", i, weight)
+		}
+		sum += weight
+	}
+	if !(sum > 0) || math.IsInf(sum, 1) {
+		return fmt.Errorf("svm: weights add up to %g, want a positive number", sum)
+	}
+	return nil
+}
+
+// sampleWeight is the weight of row i, 1 when there are no weights.
+func sampleWeight(weights []float64, i int) float64 {
+	if weights == nil {
+		return 1
+	}
+	return weights[i]
+}
@@ -0,0 +1,78 @@
+package svm
+
+import (
+	"math"
+	"testing"
+
+	"concurrente/internal/harness"
+)
+
+func TestUnitWeightsTrainTheUnweightedSVM(t *testing.T) {
+	data := harness.SignedLabels(harness.Classification(800, 4, 0.05, 2))
+	ones := make([]float64, len(data))
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	sequential, weightedSequential := NewSequentialSVM(4, 0.001, 0.01, 5), NewSequentialSVM(4, 0.001, 0.01, 5)
+	sequential.Train(data)
+	if err := weightedSequential.TrainWeighted(data, ones); err != nil {
+		t.Fatal(err)
+	}
+	concurrent, weightedConcurrent := NewConcurrentSVM(4, 0.001, 0.01, 5), NewConcurrentSVM(4, 0.001, 0.01, 5)
+	concurrent.Train(data)
+	if err := weightedConcurrent.TrainWeighted(data, ones); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := harness.MaxAbsDiff(
+		append(sequential.weights, sequential.bias),
+		append(weightedSequential.weights, weightedSequential.bias),
+	); diff != 0 {
+		t.Errorf("sequential weights differ by %g", diff)
+	}
+	if diff := harness.MaxAbsDiff(
+		append(concurrent.weights, concurrent.bias),
+		append(weightedConcurrent.weights, weightedConcurrent.bias),
+	); diff != 0 {
+		t.Errorf("concurrent weights differ by %g", diff)
+	}
+}
+
+func TestWeightsScaleTheHingeGradient(t *testing.T) {
+	// One positive and one negative row on the same point: the heavier one
+	// pulls the bias to its side.
+	data := [][]float64{{0.5, 1}, {0.5, -1}}
+	for _, weights := range [][]float64{{3, 1}, {1, 3}} {
+		svm := NewSequentialSVM(1, 0.01, 0, 1)
+		if err := svm.TrainWeighted(data, weights); err != nil {
+			t.Fatal(err)
+		}
+		want := 1.0
+		if weights[1] > weights[0] {
+			want = 0
+		}
+		if got := svm.Predict([]float64{0.5}); got != want {
+			t.Errorf("weights %v: predicts %g, want %g", weights, got, want)
+		}
+	}
+}
+
+func TestTrainWeightedRejectsBadWeights(t *testing.T) {
+	data := [][]float64{{0.5, 1}, {0.5, -1}, {1, 1}}
+	for name, weights := range map[string][]float64{
+		"short":    {1, 1},
+		"zero":     {0, 0, 0},
+		"negative": {1, -1, 1},
+		"nan":      {1, math.NaN(), 1},
+	} {
+		sequential := NewSequentialSVM(1, 0.01, 0, 1)
+		if err := sequential.TrainWeighted(data, weights); err == nil || sequential.bias != 0 {
+			t.Errorf("%s: sequential SVM trained with error %v", name, err)
+		}
+		concurrent := NewConcurrentSVM(1, 0.01, 0, 1)
+		if err := concurrent.TrainWeighted(data, weights); err == nil || concurrent.bias != 0 {
+			t.Errorf("%s: concurrent SVM trained with error %v", name, err)
+		}
+	}
+}
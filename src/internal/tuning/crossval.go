@@ -0,0 +1,44 @@
+package tuning
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// FitFunc trains a model on train and returns its score on test.
+type FitFunc[T any] func(train, test [][]T) (float64, error)
+
+// CrossValidate shuffles data with seed, splits it into folds parts and
+// returns the mean score of fit over the folds, each fold serving once as the
+// test set. data itself is not reordered.
+func CrossValidate[T any](data [][]T, folds int, seed int64, fit FitFunc[T]) (float64, error) {
+	if folds < 2 {
+		return 0, fmt.Errorf("tuning: need at least 2 folds, got %d", folds)
+	}
+	if len(data) < folds {
+		return 0, fmt.Errorf("tuning: %d rows cannot be split into %d folds", len(data), folds)
+	}
+
+	shuffled := make([][]T, len(data))
+	copy(shuffled, data)
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	total := 0.0
+	for fold := 0; fold < folds; fold++ {
+		start := fold * len(shuffled) / folds
+		end := (fold + 1) * len(shuffled) / folds
+
+		test := shuffled[start:end]
+		train := make([][]T, 0, len(shuffled)-len(test))
+		train = append(train, shuffled[:start]...)
+		train = append(train, shuffled[end:]...)
+
+		score, err := fit(train, test)
+		if err != nil {
+			return 0, fmt.Errorf("fold %d: %w", fold+1, err)
+		}
+		total += score
+	}
+	return total / float64(folds), nil
+}
@@ -0,0 +1,55 @@
+package tuning
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+type leaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	Trial      int     `json:"trial"`
+	Params     Params  `json:"params"`
+	Budget     int     `json:"budget"`
+	Rung       int     `json:"rung,omitempty"`
+	Score      float64 `json:"score"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// WriteJSON writes the leaderboard as an indented JSON array.
+func (l Leaderboard) WriteJSON(w io.Writer) error {
+	entries := make([]leaderboardEntry, len(l))
+	for i, trial := range l {
+		entries[i] = leaderboardEntry{
+			Rank:       i + 1,
+			Trial:      trial.ID,
+			Params:     trial.Params,
+			Budget:     trial.Budget,
+			Rung:       trial.Rung,
+			Score:      trial.Score,
+			DurationMS: float64(trial.Duration.Microseconds()) / 1000,
+		}
+		if trial.Err != nil {
+			entries[i].Error = trial.Err.Error()
+			entries[i].Score = 0
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// Save writes the leaderboard to filename as JSON.
+func (l Leaderboard) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := l.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
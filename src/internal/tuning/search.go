@@ -0,0 +1,235 @@
+package tuning
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trial is one evaluation of the objective.
+type Trial struct {
+	ID     int
+	Params Params
+	// Budget is the amount of resource the trial may spend, such as training
+	// rows or trees. Its meaning is up to the objective.
+	Budget int
+	// Workers is the number of CPUs the trial is allowed to keep busy.
+	Workers int
+	// Rung is the successive halving round that ran the trial, 0 otherwise.
+	Rung int
+
+	Score    float64
+	Duration time.Duration
+	Err      error
+}
+
+// Objective trains and scores a model for a trial. Higher scores are better.
+type Objective func(trial Trial) (float64, error)
+
+// Config bounds how a search runs its trials.
+type Config struct {
+	// CPUs is the total number of CPUs the search may keep busy. Zero means
+	// runtime.GOMAXPROCS(0).
+	CPUs int
+	// CPUsPerTrial is how many CPUs a single trial uses, for example the
+	// worker count of a ParallelRandomForest. Zero means 1.
+	CPUsPerTrial int
+	// Budget is the resource each grid or random search trial receives and
+	// the resource of the last successive halving rung.
+	Budget int
+	// MinBudget is the resource of the first successive halving rung.
+	MinBudget int
+	// Eta is the successive halving reduction factor. Zero means 3.
+	Eta int
+	// Seed drives random and successive halving sampling.
+	Seed int64
+}
+
+func (c Config) cpusPerTrial() int {
+	if c.CPUsPerTrial <= 0 {
+		return 1
+	}
+	return c.CPUsPerTrial
+}
+
+// concurrentTrials is how many trials fit in the CPU budget at once.
+func (c Config) concurrentTrials() int {
+	cpus := c.CPUs
+	if cpus <= 0 {
+		cpus = runtime.GOMAXPROCS(0)
+	}
+	if n := cpus / c.cpusPerTrial(); n > 1 {
+		return n
+	}
+	return 1
+}
+
+func (c Config) eta() int {
+	if c.Eta < 2 {
+		return 3
+	}
+	return c.Eta
+}
+
+// GridSearch evaluates every combination of the space's Values.
+func GridSearch(space Space, objective Objective, cfg Config) (Leaderboard, error) {
+	if err := space.validate(); err != nil {
+		return nil, err
+	}
+	candidates, err := space.grid()
+	if err != nil {
+		return nil, err
+	}
+	trials := newTrials(candidates, cfg.Budget, cfg, 0, 0)
+	runTrials(trials, objective, cfg)
+	return newLeaderboard(trials), nil
+}
+
+// RandomSearch evaluates numTrials assignments sampled from the space.
+func RandomSearch(space Space, numTrials int, objective Objective, cfg Config) (Leaderboard, error) {
+	if err := space.validate(); err != nil {
+		return nil, err
+	}
+	if numTrials <= 0 {
+		return nil, fmt.Errorf("tuning: numTrials must be positive, got %d", numTrials)
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	candidates := make([]Params, numTrials)
+	for i := range candidates {
+		candidates[i] = space.sample(rng)
+	}
+	trials := newTrials(candidates, cfg.Budget, cfg, 0, 0)
+	runTrials(trials, objective, cfg)
+	return newLeaderboard(trials), nil
+}
+
+// SuccessiveHalving samples numConfigs assignments and evaluates them with
+// cfg.MinBudget. After each rung only the best 1/Eta survive and their budget
+// is multiplied by Eta, until a single candidate remains or the budget
+// reaches cfg.Budget.
+func SuccessiveHalving(
+	space Space,
+	numConfigs int,
+	objective Objective,
+	cfg Config,
+) (Leaderboard, error) {
+	if err := space.validate(); err != nil {
+		return nil, err
+	}
+	if numConfigs <= 0 {
+		return nil, fmt.Errorf("tuning: numConfigs must be positive, got %d", numConfigs)
+	}
+	if cfg.MinBudget <= 0 || cfg.MinBudget > cfg.Budget {
+		return nil, fmt.Errorf(
+			"tuning: need 0 < MinBudget <= Budget, got %d and %d",
+			cfg.MinBudget,
+			cfg.Budget,
+		)
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	candidates := make([]Params, numConfigs)
+	for i := range candidates {
+		candidates[i] = space.sample(rng)
+	}
+
+	var all []Trial
+	budget := cfg.MinBudget
+	for rung := 1; ; rung++ {
+		trials := newTrials(candidates, budget, cfg, rung, len(all))
+		runTrials(trials, objective, cfg)
+		all = append(all, trials...)
+
+		if len(candidates) == 1 || budget >= cfg.Budget {
+			break
+		}
+
+		ranked := newLeaderboard(trials)
+		keep := len(candidates) / cfg.eta()
+		if keep < 1 {
+			keep = 1
+		}
+		candidates = candidates[:0]
+		for _, trial := range ranked[:keep] {
+			if trial.Err == nil {
+				candidates = append(candidates, trial.Params)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		budget *= cfg.eta()
+		if budget > cfg.Budget {
+			budget = cfg.Budget
+		}
+	}
+	return newLeaderboard(all), nil
+}
+
+func newTrials(candidates []Params, budget int, cfg Config, rung, firstID int) []Trial {
+	trials := make([]Trial, len(candidates))
+	for i, params := range candidates {
+		trials[i] = Trial{
+			ID:      firstID + i + 1,
+			Params:  params,
+			Budget:  budget,
+			Workers: cfg.cpusPerTrial(),
+			Rung:    rung,
+		}
+	}
+	return trials
+}
+
+// runTrials evaluates the trials in place, keeping at most
+// cfg.concurrentTrials() of them running at once.
+func runTrials(trials []Trial, objective Objective, cfg Config) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, cfg.concurrentTrials())
+
+	for i := range trials {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(trial *Trial) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			trial.Score, trial.Err = objective(*trial)
+			trial.Duration = time.Since(start)
+		}(&trials[i])
+	}
+
+	wg.Wait()
+}
+
+// Leaderboard lists trials from best to worst. Trials that ran with a larger
+// budget rank above those that ran with less, and failed trials come last.
+type Leaderboard []Trial
+
+func newLeaderboard(trials []Trial) Leaderboard {
+	board := make(Leaderboard, len(trials))
+	copy(board, trials)
+	sort.SliceStable(board, func(i, j int) bool {
+		a, b := board[i], board[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if a.Budget != b.Budget {
+			return a.Budget > b.Budget
+		}
+		return a.Score > b.Score
+	})
+	return board
+}
+
+// Best returns the top trial, or false when every trial failed.
+func (l Leaderboard) Best() (Trial, bool) {
+	if len(l) == 0 || l[0].Err != nil {
+		return Trial{}, false
+	}
+	return l[0], true
+}
@@ -0,0 +1,137 @@
+package tuning
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func quadratic(trial Trial) (float64, error) {
+	x, y := trial.Params["x"], trial.Params["y"]
+	return -(x-2)*(x-2) - (y+1)*(y+1), nil
+}
+
+func TestGridSearchFindsOptimum(t *testing.T) {
+	space := Space{
+		{Name: "x", Values: []float64{0, 1, 2, 3}},
+		{Name: "y", Values: []float64{-2, -1, 0}},
+	}
+	board, err := GridSearch(space, quadratic, Config{CPUs: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(board) != 12 {
+		t.Fatalf("got %d trials, want 12", len(board))
+	}
+	best, ok := board.Best()
+	if !ok || best.Params["x"] != 2 || best.Params["y"] != -1 {
+		t.Fatalf("best trial %v, want x=2 y=-1", best.Params)
+	}
+}
+
+func TestRandomSearchRespectsCPUBudget(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	objective := func(trial Trial) (float64, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		score, err := quadratic(trial)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return score, err
+	}
+
+	space := Space{
+		{Name: "x", Min: -5, Max: 5},
+		{Name: "y", Min: 1, Max: 100, Log: true, Integer: true},
+	}
+	board, err := RandomSearch(space, 40, objective, Config{CPUs: 6, CPUsPerTrial: 2, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning > 3 {
+		t.Fatalf("%d trials ran at once with a budget of 3", maxRunning)
+	}
+	for _, trial := range board {
+		if trial.Workers != 2 {
+			t.Fatalf("trial %d got %d workers, want 2", trial.ID, trial.Workers)
+		}
+		y := trial.Params["y"]
+		if y < 1 || y > 100 || y != math.Round(y) {
+			t.Fatalf("trial %d sampled y=%g outside the integer range [1, 100]", trial.ID, y)
+		}
+	}
+}
+
+func TestSuccessiveHalvingPromotesBestCandidates(t *testing.T) {
+	space := Space{{Name: "x", Values: []float64{0, 1, 2, 3, 4, 5, 6, 7, 8}}}
+	objective := func(trial Trial) (float64, error) {
+		if trial.Params["x"] == 8 {
+			return 0, errors.New("diverged")
+		}
+		return trial.Params["x"] * float64(trial.Budget), nil
+	}
+
+	board, err := SuccessiveHalving(
+		space,
+		9,
+		objective,
+		Config{Budget: 90, MinBudget: 10, Eta: 3, Seed: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	perRung := map[int]int{}
+	for _, trial := range board {
+		perRung[trial.Rung]++
+	}
+	if perRung[1] != 9 || perRung[2] != 3 || perRung[3] != 1 {
+		t.Fatalf("trials per rung %v, want 9, 3 and 1", perRung)
+	}
+	best, ok := board.Best()
+	if !ok || best.Budget != 90 {
+		t.Fatalf("best trial ran with budget %d, want 90", best.Budget)
+	}
+	if last := board[len(board)-1]; last.Err == nil {
+		t.Fatalf("failed trials should rank last, got %+v", last)
+	}
+}
+
+func TestCrossValidateUsesEveryRowOnce(t *testing.T) {
+	data := make([][]float64, 103)
+	for i := range data {
+		data[i] = []float64{float64(i)}
+	}
+
+	seen := make(map[float64]int)
+	score, err := CrossValidate(data, 5, 3, func(train, test [][]float64) (float64, error) {
+		if len(train)+len(test) != len(data) {
+			t.Fatalf("fold has %d train and %d test rows", len(train), len(test))
+		}
+		for _, row := range test {
+			seen[row[0]]++
+		}
+		return float64(len(test)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(data) {
+		t.Fatalf("%d rows were tested, want %d", len(seen), len(data))
+	}
+	for value, count := range seen {
+		if count != 1 {
+			t.Fatalf("row %g was tested %d times", value, count)
+		}
+	}
+	if math.Abs(score-float64(len(data))/5) > 1e-9 {
+		t.Fatalf("mean fold size %g, want %g", score, float64(len(data))/5)
+	}
+}
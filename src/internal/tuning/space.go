@@ -0,0 +1,131 @@
+// Package tuning searches hyperparameters for any model in the project.
+//
+// A search draws candidate Params from a Space, hands each one to an
+// Objective as a Trial and ranks the scores in a Leaderboard. Trials run
+// concurrently, bounded by the CPU budget in Config.
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Params holds one hyperparameter assignment keyed by parameter name.
+type Params map[string]float64
+
+// Int returns the named parameter rounded to the nearest integer.
+func (p Params) Int(name string) int {
+	return int(math.Round(p[name]))
+}
+
+// Dimension describes the values a single hyperparameter may take. Grid
+// search only uses Values; random search samples from Values when present and
+// from [Min, Max] otherwise.
+type Dimension struct {
+	Name    string
+	Values  []float64
+	Min     float64
+	Max     float64
+	Integer bool
+	Log     bool
+}
+
+// Space is the set of hyperparameters being searched.
+type Space []Dimension
+
+func (s Space) validate() error {
+	if len(s) == 0 {
+		return fmt.Errorf("tuning: empty search space")
+	}
+	seen := make(map[string]bool, len(s))
+	for _, dim := range s {
+		if dim.Name == "" {
+			return fmt.Errorf("tuning: dimension without a name")
+		}
+		if seen[dim.Name] {
+			return fmt.Errorf("tuning: duplicate dimension %q", dim.Name)
+		}
+		seen[dim.Name] = true
+		if len(dim.Values) == 0 {
+			if dim.Max < dim.Min {
+				return fmt.Errorf("tuning: dimension %q has Max < Min", dim.Name)
+			}
+			if dim.Log && dim.Min <= 0 {
+				return fmt.Errorf("tuning: log dimension %q needs Min > 0", dim.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// grid returns the cartesian product of every dimension's Values.
+func (s Space) grid() ([]Params, error) {
+	combinations := []Params{{}}
+	for _, dim := range s {
+		if len(dim.Values) == 0 {
+			return nil, fmt.Errorf("tuning: dimension %q has no grid values", dim.Name)
+		}
+		next := make([]Params, 0, len(combinations)*len(dim.Values))
+		for _, partial := range combinations {
+			for _, value := range dim.Values {
+				params := make(Params, len(partial)+1)
+				for name, v := range partial {
+					params[name] = v
+				}
+				params[dim.Name] = value
+				next = append(next, params)
+			}
+		}
+		combinations = next
+	}
+	return combinations, nil
+}
+
+// sample draws one random assignment from the space.
+func (s Space) sample(rng *rand.Rand) Params {
+	params := make(Params, len(s))
+	for _, dim := range s {
+		params[dim.Name] = dim.sample(rng)
+	}
+	return params
+}
+
+func (d Dimension) sample(rng *rand.Rand) float64 {
+	if len(d.Values) > 0 {
+		return d.Values[rng.Intn(len(d.Values))]
+	}
+
+	var value float64
+	if d.Log {
+		value = math.Exp(math.Log(d.Min) + rng.Float64()*(math.Log(d.Max)-math.Log(d.Min)))
+	} else {
+		value = d.Min + rng.Float64()*(d.Max-d.Min)
+	}
+	if d.Integer {
+		value = math.Round(value)
+	}
+	return value
+}
+
+// names returns the dimension names of the params in a stable order.
+func (p Params) names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p Params) String() string {
+	s := ""
+	for i, name := range p.names() {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%s=%g", name, p[name])
+	}
+	return s
+}
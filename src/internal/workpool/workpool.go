@@ -0,0 +1,224 @@
+// Package workpool runs fork-join tasks on a fixed set of workers that steal
+// work from each other.
+//
+// Every worker owns a deque of tasks. A worker pushes the tasks it forks at
+// the bottom and pops from the bottom too, so it keeps working on the most
+// recent, smallest and cache-warm piece of its own problem. An idle worker
+// steals from the top of another worker's deque, which in a recursive
+// divide-and-conquer computation is the oldest and largest piece of work
+// left. Joining a task never leaves work queued behind a blocked worker:
+// while the task is unfinished the worker runs other queued tasks, so a pool
+// of N workers never deadlocks on nested joins, and it only sleeps when no
+// task is queued anywhere.
+package workpool
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool is a set of workers. It must be closed when no longer needed.
+type Pool struct {
+	workers []*Worker
+
+	// pending counts queued tasks. Idle workers sleep on cond until it is
+	// positive or the pool is closed, and joining workers until it is
+	// positive or the task they join is done.
+	pending atomic.Int64
+	mu      sync.Mutex
+	cond    *sync.Cond
+	closed  bool
+	wg      sync.WaitGroup
+
+	steals atomic.Int64
+}
+
+// Worker is the handle a task uses to fork and join subtasks.
+type Worker struct {
+	pool *Pool
+	id   int
+
+	mu    sync.Mutex
+	deque []*Task
+}
+
+// Task is a forked unit of work.
+type Task struct {
+	fn   func(w *Worker)
+	done atomic.Bool
+	// joined says a worker sleeps on the pool's cond until the task is done.
+	joined atomic.Bool
+}
+
+// New starts a pool of workers goroutines, or GOMAXPROCS of them when workers
+// is not positive.
+func New(workers int) *Pool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &Pool{workers: make([]*Worker, workers)}
+	p.cond = sync.NewCond(&p.mu)
+	for i := range p.workers {
+		p.workers[i] = &Worker{pool: p, id: i}
+	}
+	p.wg.Add(workers)
+	for _, w := range p.workers {
+		go w.loop()
+	}
+	return p
+}
+
+// Workers returns the number of workers.
+func (p *Pool) Workers() int {
+	return len(p.workers)
+}
+
+// Steals returns how many tasks were taken from another worker's deque so
+// far.
+func (p *Pool) Steals() int64 {
+	return p.steals.Load()
+}
+
+// Run executes fn as a task on the pool and waits for it and everything it
+// joins to finish.
+func (p *Pool) Run(fn func(w *Worker)) {
+	done := make(chan struct{})
+	p.workers[0].push(&Task{fn: func(w *Worker) {
+		defer close(done)
+		fn(w)
+	}})
+	<-done
+}
+
+// Close stops the workers once the queued tasks have run.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// Fork queues fn to run on the pool, usually on another worker, and returns
+// a task to Join.
+func (w *Worker) Fork(fn func(w *Worker)) *Task {
+	t := &Task{fn: fn}
+	w.push(t)
+	return t
+}
+
+// Join returns when t has run. Meanwhile the worker runs its own queued tasks,
+// t itself first if nobody stole it, and steals from other workers. When
+// there is nothing to run, another worker is running t, and the worker
+// sleeps until t is done or a task is queued.
+func (w *Worker) Join(t *Task) {
+	p := w.pool
+	for !t.done.Load() {
+		next := w.pop()
+		if next == nil {
+			next = w.steal()
+		}
+		if next != nil {
+			w.run(next)
+			continue
+		}
+
+		// run reads joined after setting done, so either the check below
+		// sees done or run broadcasts once this worker waits.
+		t.joined.Store(true)
+		p.mu.Lock()
+		for !t.done.Load() && p.pending.Load() == 0 {
+			p.cond.Wait()
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (w *Worker) loop() {
+	defer w.pool.wg.Done()
+	p := w.pool
+	for {
+		next := w.pop()
+		if next == nil {
+			next = w.steal()
+		}
+		if next != nil {
+			w.run(next)
+			continue
+		}
+
+		p.mu.Lock()
+		for p.pending.Load() == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		stop := p.closed && p.pending.Load() == 0
+		p.mu.Unlock()
+		if stop {
+			return
+		}
+	}
+}
+
+func (w *Worker) run(t *Task) {
+	t.fn(w)
+	t.done.Store(true)
+	if t.joined.Load() {
+		p := w.pool
+		p.mu.Lock()
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+func (w *Worker) push(t *Task) {
+	w.mu.Lock()
+	w.deque = append(w.deque, t)
+	w.mu.Unlock()
+
+	// pending is raised before taking p.mu, so a worker checking it under
+	// p.mu either sees the task or is already waiting for this signal.
+	p := w.pool
+	p.pending.Add(1)
+	p.mu.Lock()
+	p.cond.Signal()
+	p.mu.Unlock()
+}
+
+// pop takes the newest task of the worker's own deque.
+func (w *Worker) pop() *Task {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(w.deque)
+	if n == 0 {
+		return nil
+	}
+	t := w.deque[n-1]
+	w.deque[n-1] = nil
+	w.deque = w.deque[:n-1]
+	w.pool.pending.Add(-1)
+	return t
+}
+
+// steal takes the oldest task of the first other worker, starting after w,
+// that has one.
+func (w *Worker) steal() *Task {
+	workers := w.pool.workers
+	for i := 1; i < len(workers); i++ {
+		victim := workers[(w.id+i)%len(workers)]
+		victim.mu.Lock()
+		if len(victim.deque) == 0 {
+			victim.mu.Unlock()
+			continue
+		}
+		t := victim.deque[0]
+		victim.deque[0] = nil
+		victim.deque = victim.deque[1:]
+		victim.mu.Unlock()
+
+		w.pool.pending.Add(-1)
+		w.pool.steals.Add(1)
+		return t
+	}
+	return nil
+}
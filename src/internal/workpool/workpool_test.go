@@ -0,0 +1,109 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fib(w *Worker, n int) int {
+	if n < 2 {
+		return n
+	}
+	var left int
+	task := w.Fork(func(w *Worker) { left = fib(w, n-1) })
+	right := fib(w, n-2)
+	w.Join(task)
+	return left + right
+}
+
+func TestNestedForkJoin(t *testing.T) {
+	for _, workers := range []int{1, 2, 8} {
+		pool := New(workers)
+		var got int
+		pool.Run(func(w *Worker) { got = fib(w, 20) })
+		pool.Close()
+		if got != 6765 {
+			t.Fatalf("%d workers: fib(20) = %d", workers, got)
+		}
+	}
+}
+
+func TestIdleWorkersSteal(t *testing.T) {
+	pool := New(4)
+	defer pool.Close()
+
+	var ran atomic.Int64
+	start := time.Now()
+	pool.Run(func(w *Worker) {
+		tasks := make([]*Task, 8)
+		for i := range tasks {
+			tasks[i] = w.Fork(func(*Worker) {
+				time.Sleep(20 * time.Millisecond)
+				ran.Add(1)
+			})
+		}
+		for _, task := range tasks {
+			w.Join(task)
+		}
+	})
+
+	if ran.Load() != 8 {
+		t.Fatalf("%d of 8 tasks ran", ran.Load())
+	}
+	if pool.Steals() == 0 {
+		t.Error("no task was stolen")
+	}
+	// Sleeping tasks overlap on the four workers even with a single CPU.
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("8 tasks of 20ms took %v on 4 workers", elapsed)
+	}
+}
+
+func TestPoolRunsSeveralComputations(t *testing.T) {
+	pool := New(3)
+	defer pool.Close()
+	for i := 0; i < 50; i++ {
+		var got int
+		pool.Run(func(w *Worker) { got = fib(w, 10) })
+		if got != 55 {
+			t.Fatalf("run %d: fib(10) = %d", i, got)
+		}
+	}
+}
+
+func TestJoiningWorkerRunsNewTasks(t *testing.T) {
+	pool := New(2)
+	defer pool.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		pool.Run(func(w *Worker) {
+			task := w.Fork(func(*Worker) {
+				close(started)
+				<-release
+			})
+			// Blocking here leaves the task to the other worker, so Join
+			// finds nothing to run and sleeps.
+			<-started
+			w.Join(task)
+		})
+		close(finished)
+	}()
+
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	// The other worker is stuck in the stolen task, so only the joining
+	// worker can run this one.
+	pool.Run(func(*Worker) { close(release) })
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Join did not return after the stolen task finished")
+	}
+	if pool.Steals() == 0 {
+		t.Error("the blocked task was not stolen")
+	}
+}